@@ -0,0 +1,256 @@
+// Package app builds the service graph and HTTP router shared by the
+// standalone server (main.go) and the serverless entrypoint (api/index.go).
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	netmail "net/mail"
+	"time"
+
+	"github.com/gorilla/handlers"
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"neomovies-api/pkg/background"
+	"neomovies-api/pkg/cache"
+	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/metrics"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/monitor"
+	"neomovies-api/pkg/oauth"
+	"neomovies-api/pkg/openapi"
+	"neomovies-api/pkg/ratelimit"
+	"neomovies-api/pkg/services"
+)
+
+// App owns every long-lived dependency of the API. It is built once per
+// process and is safe for concurrent use.
+type App struct {
+	cfg           *config.Config
+	db            *mongo.Database
+	log           *slog.Logger
+	spec          *openapi.Spec
+	router        *mux.Router
+	handler       http.Handler
+	outbox        *services.OutboxService
+	digest        *services.DigestService
+	notifications *services.NotificationsService
+}
+
+// deps holds the services the route table is built from.
+type deps struct {
+	tmdb          *services.TMDBService
+	sessions      *services.SessionService
+	audit         *services.AuditService
+	outbox        *services.OutboxService
+	email         *services.EmailService
+	digest        *services.DigestService
+	follows       *services.FollowsService
+	notifications *services.NotificationsService
+	auth          *services.AuthService
+	movies        *services.MovieService
+	tv            *services.TVService
+	favorites     *services.FavoritesService
+	torrents      *services.TorrentService
+	reactions     *services.ReactionsService
+	watchHistory  *services.WatchHistoryService
+	lists         *services.ListsService
+	admin         *services.AdminService
+	limiter       *ratelimit.Limiter
+}
+
+// New wires services, ensures MongoDB indexes, registers all routes and
+// builds the OpenAPI document. Index creation failures are logged but not
+// fatal; a route registered without OpenAPI metadata is.
+func New(ctx context.Context, cfg *config.Config, db *mongo.Database, log *slog.Logger) (*App, error) {
+	tmdbCache, err := cache.New(cfg.TMDBCache, cfg.TMDBCacheSize, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize TMDB cache: %w", err)
+	}
+	metrics.RegisterCache("tmdb", tmdbCache)
+
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		limiter, err = ratelimit.New(cfg.RateLimitStore, db, cfg.RateLimits, cfg.RateLimitTrustProxy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize rate limiter: %w", err)
+		}
+	}
+
+	tmdbService := services.NewTMDBServiceWithConfig(cfg.TMDBAccessToken, cfg.TMDBBaseURL, tmdbCache)
+	mailer, err := newMailer(cfg, log)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := mail.LoadTemplates()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load email templates: %w", err)
+	}
+	outboxService := services.NewOutboxService(db, mailer)
+	emailService := services.NewEmailService(cfg, outboxService, templates)
+	sessionService := services.NewSessionService(db, cfg.JWTSecret)
+	auditService := services.NewAuditService(db)
+
+	d := &deps{
+		tmdb:          tmdbService,
+		sessions:      sessionService,
+		audit:         auditService,
+		outbox:        outboxService,
+		email:         emailService,
+		digest:        services.NewDigestService(db, cfg, tmdbService, emailService),
+		follows:       services.NewFollowsService(db, tmdbService),
+		notifications: services.NewNotificationsService(db, tmdbService, emailService, cfg.NotificationsInterval),
+		auth:          services.NewAuthService(db, cfg.JWTSecret, emailService, sessionService, auditService, cfg.BaseURL, cfg.FrontendURL, oauthProviders(cfg)),
+		movies:        services.NewMovieService(db, tmdbService),
+		tv:            services.NewTVService(db, tmdbService),
+		favorites:     services.NewFavoritesService(db, tmdbService),
+		torrents:      services.NewTorrentServiceWithConfig(cfg.RedAPIBaseURL, cfg.RedAPIKey),
+		reactions:     services.NewReactionsService(db),
+		watchHistory:  services.NewWatchHistoryService(db, tmdbService),
+		lists:         services.NewListsService(db, tmdbService),
+		admin:         services.NewAdminService(db, sessionService, auditService, tmdbService, outboxService),
+		limiter:       limiter,
+	}
+
+	a := &App{cfg: cfg, db: db, log: log, outbox: outboxService, digest: d.digest, notifications: d.notifications}
+	a.ensureIndexes(ctx, d)
+
+	a.spec = openapi.NewSpec(openapi.Info{
+		Title:       "Neo Movies API",
+		Description: "Современный API для поиска фильмов и сериалов с интеграцией TMDB и поддержкой авторизации",
+		Version:     config.Version,
+	}, models.APIResponse{}, models.ErrorResponse{})
+	a.router = a.routes(d)
+	// Маршрут без метаданных — ошибка разработки, поэтому не стартуем вовсе
+	if _, err := a.spec.Build(a.router); err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI document: %w", err)
+	}
+
+	corsHandler := handlers.CORS(
+		handlers.AllowedOrigins([]string{"*"}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		handlers.AllowedHeaders([]string{"Authorization", "Content-Type", "Accept", "Origin", "X-Requested-With", "X-CSRF-Token", "X-Request-ID"}),
+		handlers.AllowCredentials(),
+		handlers.ExposedHeaders([]string{"Authorization", "Content-Type", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After", "X-Request-ID"}),
+	)
+
+	a.router.Use(monitor.CaptureRoute())
+	a.handler = monitor.RequestLogger(log)(corsHandler(a.router))
+
+	return a, nil
+}
+
+// ServeHTTP makes App usable directly as the server handler.
+func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.handler.ServeHTTP(w, r)
+}
+
+// Start runs the background workers until ctx is done. Only the
+// long-running server calls it: a serverless instance sends each email in
+// the request that queued it, retries them when a scheduler calls
+// /cron/outbox and leaves digests and notifications to a server.
+func (a *App) Start(ctx context.Context) {
+	background.Go("email outbox worker", func() { a.outbox.Run(ctx) })
+	if a.cfg.DigestEnabled {
+		background.Go("recommendation digest", func() { a.digest.Run(ctx) })
+	}
+	if a.cfg.NotificationsEnabled {
+		background.Go("release notifications", func() { a.notifications.Run(ctx) })
+	}
+}
+
+// Router returns the underlying route table, without CORS and access
+// logging applied.
+func (a *App) Router() *mux.Router {
+	return a.router
+}
+
+// newMailer builds the email transport selected by MAIL_TRANSPORT.
+func newMailer(cfg *config.Config, log *slog.Logger) (mail.Mailer, error) {
+	if cfg.MailTransport == "log" {
+		return mail.NewLogMailer(log), nil
+	}
+
+	// Адрес проверен при загрузке конфигурации, пустой допустим до первой отправки
+	var from netmail.Address
+	if cfg.MailFrom != "" {
+		addr, err := mail.ParseAddress(cfg.MailFrom, cfg.MailFromName)
+		if err != nil {
+			return nil, err
+		}
+		from = addr
+	}
+
+	if cfg.MailTransport == "file" {
+		return mail.NewFileMailer(cfg.MailDir, from, log), nil
+	}
+	return mail.NewSMTP(mail.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Security: cfg.SMTPSecurity,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		From:     from,
+	}), nil
+}
+
+// oauthProviders registers every provider that has client credentials. The
+// redirect URL defaults to the provider callback under BASE_URL.
+func oauthProviders(cfg *config.Config) *oauth.Registry {
+	configs := []struct {
+		name                 string
+		id, secret, redirect string
+		build                func(oauth.Config) oauth.Provider
+	}{
+		{"google", cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL, oauth.NewGoogle},
+		{"github", cfg.GitHubClientID, cfg.GitHubClientSecret, cfg.GitHubRedirectURL, oauth.NewGitHub},
+		{"yandex", cfg.YandexClientID, cfg.YandexClientSecret, cfg.YandexRedirectURL, oauth.NewYandex},
+		{"vk", cfg.VKClientID, cfg.VKClientSecret, cfg.VKRedirectURL, oauth.NewVK},
+	}
+
+	var providers []oauth.Provider
+	for _, c := range configs {
+		if c.id == "" || c.secret == "" {
+			continue
+		}
+		redirect := c.redirect
+		if redirect == "" {
+			redirect = cfg.BaseURL + "/api/v1/auth/" + c.name + "/callback"
+		}
+		providers = append(providers, c.build(oauth.Config{
+			ClientID:     c.id,
+			ClientSecret: c.secret,
+			RedirectURL:  redirect,
+			HTTPClient:   metrics.NewClient(c.name, 10*time.Second),
+		}))
+	}
+	return oauth.NewRegistry(providers...)
+}
+
+func (a *App) ensureIndexes(ctx context.Context, d *deps) {
+	indexers := []struct {
+		name string
+		fn   func(context.Context) error
+	}{
+		{"session", d.sessions.EnsureIndexes},
+		{"auth", d.auth.EnsureIndexes},
+		{"audit", d.audit.EnsureIndexes},
+		{"email outbox", d.outbox.EnsureIndexes},
+		{"digest", d.digest.EnsureIndexes},
+		{"follows", d.follows.EnsureIndexes},
+		{"notifications", d.notifications.EnsureIndexes},
+		{"watch history", d.watchHistory.EnsureIndexes},
+		{"lists", d.lists.EnsureIndexes},
+		{"favorites", d.favorites.EnsureIndexes},
+		{"reactions", d.reactions.EnsureIndexes},
+	}
+	for _, idx := range indexers {
+		if err := idx.fn(ctx); err != nil {
+			a.log.Warn("failed to create indexes", "collection", idx.name, "error", err)
+		}
+	}
+}
@@ -0,0 +1,650 @@
+package app
+
+import (
+	"github.com/gorilla/mux"
+
+	appHandlers "neomovies-api/pkg/handlers"
+	"neomovies-api/pkg/health"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/metrics"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/oauth"
+	"neomovies-api/pkg/openapi"
+	"neomovies-api/pkg/services"
+)
+
+// Параметры, общие для многих маршрутов
+var (
+	pageParam        = openapi.Query("page", openapi.Integer().Default(1).Min(1).Max(500), "Номер страницы")
+	languageParam    = openapi.Query("language", openapi.String().Default("ru-RU"), "Язык ответа")
+	mediaTypeQuery   = openapi.Query("type", openapi.Enum("movie", "tv").Default("movie"), "Тип медиа: movie или tv")
+	mediaTypePath    = openapi.Path("mediaType", openapi.Enum("movie", "tv"), "Тип медиа")
+	cursorParam      = openapi.Query("cursor", openapi.String(), "Курсор из nextCursor предыдущей страницы")
+	mediaFilterParam = openapi.Query("mediaType", openapi.Enum("movie", "tv"), "Фильтр по типу медиа")
+	movieIDParam     = openapi.Path("id", openapi.Integer(), "ID фильма в TMDB")
+	tvIDParam        = openapi.Path("id", openapi.Integer(), "ID сериала в TMDB")
+	imdbIDParam      = openapi.Path("imdb_id", openapi.String(), "IMDb ID, например tt0133093")
+	providerParam    = openapi.Path("provider", openapi.Enum(oauth.Supported...), "OAuth-провайдер")
+
+	titleParams = []openapi.Param{
+		openapi.Query("title", openapi.String(), "Название"),
+		openapi.Query("originalTitle", openapi.String(), "Оригинальное название"),
+		openapi.Query("year", openapi.String(), "Год выпуска"),
+	}
+)
+
+// routes registers the full route table. Every route carries OpenAPI
+// metadata; Build in app.New reports routes that were registered without it.
+func (a *App) routes(d *deps) *mux.Router {
+	cfg := a.cfg
+	limiter := d.limiter
+	doc := a.spec.Describe
+
+	authHandler := appHandlers.NewAuthHandler(d.auth)
+	movieHandler := appHandlers.NewMovieHandler(d.movies)
+	tvHandler := appHandlers.NewTVHandler(d.tv)
+	favoritesHandler := appHandlers.NewFavoritesHandler(d.favorites)
+	docsHandler := appHandlers.NewDocsHandler(a.spec)
+	searchHandler := appHandlers.NewSearchHandler(d.tmdb)
+	categoriesHandler := appHandlers.NewCategoriesHandler(d.tmdb)
+	playersHandler := appHandlers.NewPlayersHandler(cfg)
+	webtorrentHandler := appHandlers.NewWebTorrentHandler(d.tmdb)
+	torrentsHandler := appHandlers.NewTorrentsHandler(d.torrents, d.tmdb)
+	reactionsHandler := appHandlers.NewReactionsHandler(d.reactions)
+	watchHistoryHandler := appHandlers.NewWatchHistoryHandler(d.watchHistory)
+	listsHandler := appHandlers.NewListsHandler(d.lists)
+	adminHandler := appHandlers.NewAdminHandler(d.admin, d.email)
+	digestHandler := appHandlers.NewDigestHandler(d.digest)
+	followsHandler := appHandlers.NewFollowsHandler(d.follows)
+	notificationsHandler := appHandlers.NewNotificationsHandler(d.notifications)
+	imagesHandler := appHandlers.NewImagesHandler()
+	healthHandler := appHandlers.NewHealthHandler(d.tmdb, d.torrents)
+	cronHandler := appHandlers.NewCronHandler(d.outbox, cfg.CronSecret)
+
+	r := mux.NewRouter()
+
+	doc(r.HandleFunc("/", docsHandler.ServeDocs).Methods("GET"), openapi.Hidden())
+	doc(r.HandleFunc("/openapi.json", docsHandler.GetOpenAPISpec).Methods("GET"), openapi.Hidden())
+	doc(r.Handle("/metrics", metrics.Handler(cfg.MetricsToken)).Methods("GET"), openapi.Hidden())
+	doc(r.HandleFunc("/cron/outbox", cronHandler.ProcessOutbox).Methods("GET"), openapi.Hidden())
+	doc(r.HandleFunc("/health/live", healthHandler.Live).Methods("GET"),
+		openapi.Op("Health", "Liveness").Describe("Процесс запущен. Зависимости не проверяются").
+			ReturnsRaw(map[string]interface{}{}).Response(200, "Процесс жив, в ответе версия и коммит сборки"))
+	doc(r.HandleFunc("/health/ready", healthHandler.Ready).Methods("GET"),
+		openapi.Op("Health", "Readiness").Describe("Проверка MongoDB, TMDB и RedAPI с таймаутами. Для каждой зависимости возвращаются статус и задержка. Результат кэшируется на 5 секунд").
+			ReturnsRaw(map[string]interface{}{}).
+			Response(200, "Критичные зависимости доступны (status ok или degraded)").
+			Response(503, "Недоступна MongoDB или TMDB"))
+
+	api := r.PathPrefix("/api/v1").Subrouter()
+	api.Use(limiter.Middleware("default"))
+
+	doc(api.HandleFunc("/health", appHandlers.HealthCheck).Methods("GET"),
+		openapi.Op("Health", "Health Check").Describe("Проверка работоспособности API").
+			Returns(map[string]interface{}{}).Response(200, "API работает корректно"))
+
+	doc(api.Handle("/auth/register", limiter.Wrap("auth", authHandler.Register)).Methods("POST"),
+		openapi.Op("Authentication", "Регистрация пользователя").Describe("Создание нового аккаунта пользователя").
+			Accepts(models.RegisterRequest{}).Returns(models.AuthResponse{}).
+			Response(201, "Пользователь успешно зарегистрирован").
+			Response(409, "Пользователь с таким email уже существует"))
+	doc(api.Handle("/auth/login", limiter.Wrap("auth", authHandler.Login)).Methods("POST"),
+		openapi.Op("Authentication", "Авторизация пользователя").Describe("Получение JWT токена для доступа к приватным эндпоинтам").
+			Accepts(models.LoginRequest{}).Returns(models.AuthResponse{}).
+			Response(200, "Успешная авторизация").
+			Response(401, "Неверный email или пароль").
+			Response(403, "Email не подтвержден или аккаунт заблокирован").
+			Response(429, "Слишком много неудачных попыток, в details.retryAfter — секунды до следующей"))
+	doc(api.Handle("/auth/verify", limiter.Wrap("auth", authHandler.VerifyEmail)).Methods("POST"),
+		openapi.Op("Authentication", "Подтверждение email").Describe("Подтверждение email пользователя с помощью кода").
+			Accepts(models.VerifyEmailRequest{}).Returns(nil).
+			Response(200, "Email успешно подтвержден").
+			Response(400, "Неверный или истекший код. После 5 неверных попыток код аннулируется").
+			Response(429, "Слишком много неудачных попыток"))
+	doc(api.Handle("/auth/resend-code", limiter.Wrap("email", authHandler.ResendVerificationCode)).Methods("POST"),
+		openapi.Op("Authentication", "Повторная отправка кода").Describe("Повторная отправка кода верификации на email. Ответ одинаков для любых адресов").
+			Accepts(models.ResendCodeRequest{}).Returns(nil).
+			Response(200, "Запрос принят"))
+	doc(api.Handle("/auth/refresh", limiter.Wrap("refresh", authHandler.RefreshToken)).Methods("POST"),
+		openapi.Op("Authentication", "Обновить токены").Describe("Обмен refresh токена на новую пару access/refresh токенов. Старый refresh токен становится недействительным").
+			Accepts(models.RefreshTokenRequest{}).Returns(models.AuthResponse{}).
+			Response(200, "Новая пара токенов").
+			Response(401, "Refresh токен недействителен или истек"))
+	doc(api.Handle("/auth/forgot-password", limiter.Wrap("email", authHandler.ForgotPassword)).Methods("POST"),
+		openapi.Op("Authentication", "Запрос сброса пароля").Describe("Отправка одноразовой ссылки для сброса пароля на email. Ответ одинаков для зарегистрированных и незарегистрированных адресов").
+			Accepts(models.ForgotPasswordRequest{}).Returns(nil).
+			Response(200, "Запрос принят").
+			Response(429, "Слишком много запросов для этого email"))
+	doc(api.Handle("/auth/reset-password", limiter.Wrap("auth", authHandler.ResetPassword)).Methods("POST"),
+		openapi.Op("Authentication", "Сброс пароля").Describe("Установка нового пароля по токену из письма. Все активные сессии пользователя завершаются").
+			Accepts(models.ResetPasswordRequest{}).Returns(nil).
+			Response(200, "Пароль изменен").
+			Response(400, "Токен недействителен или истек"))
+	doc(api.Handle("/digest/unsubscribe", limiter.Wrap("digest", digestHandler.Unsubscribe)).Methods("POST"),
+		openapi.Op("Digest", "Отписаться от рекомендаций").Describe("Отписка по подписанной ссылке из письма, без входа в аккаунт. Этот же URL указан в заголовке List-Unsubscribe для отписки в один клик. Ссылка действует 90 дней. В ответе — токен для POST /digest/subscribe, действующий час").
+			Params(openapi.Query("token", openapi.String(), "Токен из ссылки в письме; можно передать и в теле")).
+			Accepts(models.DigestTokenRequest{}).Returns(models.DigestUnsubscribeResponse{}).
+			Response(200, "Подписка отключена").
+			Response(400, "Недействительный или истекший токен"))
+	doc(api.Handle("/digest/subscribe", limiter.Wrap("digest", digestHandler.Resubscribe)).Methods("POST"),
+		openapi.Op("Digest", "Подписаться снова").Describe("Возврат подписки со страницы отписки по токену resubscribeToken из ответа POST /digest/unsubscribe. Токен из письма здесь не принимается").
+			Params(openapi.Query("token", openapi.String(), "resubscribeToken; можно передать и в теле")).
+			Accepts(models.DigestTokenRequest{}).Returns(nil).
+			Response(200, "Подписка включена").
+			Response(400, "Недействительный или истекший токен"))
+	doc(api.HandleFunc("/auth/{provider}/login", authHandler.OAuthLogin).Methods("GET"),
+		openapi.Op("Authentication", "OAuth: начало").Describe("Редирект на страницу авторизации провайдера").
+			Params(providerParam).
+			Response(302, "Redirect to provider").
+			Response(404, "Неизвестный провайдер").
+			Response(503, "Провайдер не сконфигурирован"))
+	doc(api.HandleFunc("/auth/{provider}/callback", authHandler.OAuthCallback).Methods("GET"),
+		openapi.Op("Authentication", "OAuth: коллбек").Describe("Обработка кода авторизации: вход (с созданием аккаунта или привязкой к аккаунту с тем же подтвержденным email) либо привязка провайдера, начатая через /auth/{provider}/link. State проверяется по cookie oauth_state браузера, начавшего вход или привязку. После входа браузер перенаправляется на FRONTEND_URL/auth/callback с одноразовым кодом для POST /auth/oauth/exchange; JSON с токенами возвращается при Accept: application/json или response=json").
+			Params(providerParam, openapi.Query("state", openapi.String(), "").Require(), openapi.Query("code", openapi.String(), "").Require()).
+			Returns(models.AuthResponse{}).
+			Response(200, "Успешная авторизация или привязка").
+			Response(302, "Redirect to frontend").
+			Response(400, "Неверный state или ошибка обмена кода").
+			Response(409, "Аккаунт провайдера привязан к другому пользователю или email не подтвержден провайдером").
+			Response(502, "Ошибка запроса профиля у провайдера"))
+	doc(api.Handle("/auth/oauth/exchange", limiter.Wrap("auth", authHandler.OAuthExchange)).Methods("POST"),
+		openapi.Op("Authentication", "OAuth: обмен кода").Describe("Обмен одноразового кода из редиректа после OAuth-входа на пару токенов. Код действует минуту").
+			Accepts(models.OAuthExchangeRequest{}).Returns(models.AuthResponse{}).
+			Response(200, "Успешная авторизация").
+			Response(400, "Код недействителен или истек"))
+
+	doc(api.HandleFunc("/search/multi", searchHandler.MultiSearch).Methods("GET"),
+		openapi.Op("Search", "Мультипоиск").Describe("Поиск фильмов, сериалов и актеров").
+			Params(openapi.Query("query", openapi.String(), "Поисковый запрос").Require(), pageParam, languageParam).
+			Returns(models.MultiSearchResponse{}).Response(200, "Результаты поиска"))
+
+	doc(api.HandleFunc("/categories", categoriesHandler.GetCategories).Methods("GET"),
+		openapi.Op("Categories", "Получить категории").Describe("Получение списка категорий фильмов").
+			Returns([]appHandlers.Category{}).Response(200, "Список категорий"))
+	doc(api.HandleFunc("/categories/{id}/movies", categoriesHandler.GetMoviesByCategory).Methods("GET"),
+		openapi.Op("Categories", "Фильмы по категории").Describe("Получение фильмов по категории. Устаревший алиас /categories/{id}/media").
+			Params(openapi.Path("id", openapi.Integer(), "ID категории"), mediaTypeQuery, pageParam, languageParam).
+			Returns(map[string]interface{}{}).Response(200, "Фильмы категории"))
+	doc(api.HandleFunc("/categories/{id}/media", categoriesHandler.GetMediaByCategory).Methods("GET"),
+		openapi.Op("Categories", "Медиа по категории").Describe("Получение фильмов или сериалов по категории").
+			Params(openapi.Path("id", openapi.Integer(), "ID категории"), mediaTypeQuery, pageParam, languageParam).
+			Returns(map[string]interface{}{}).Response(200, "Медиа по категории"))
+
+	doc(api.HandleFunc("/players/alloha/{imdb_id}", playersHandler.GetAllohaPlayer).Methods("GET"),
+		openapi.Op("Players", "Плеер Alloha").Describe("Получение плеера Alloha по IMDb ID").
+			Params(imdbIDParam).Produces("text/html").Response(200, "HTML со встроенным плеером"))
+	doc(api.HandleFunc("/players/lumex/{imdb_id}", playersHandler.GetLumexPlayer).Methods("GET"),
+		openapi.Op("Players", "Плеер Lumex").Describe("Получение плеера Lumex по IMDb ID").
+			Params(imdbIDParam).Produces("text/html").Response(200, "HTML со встроенным плеером"))
+	doc(api.HandleFunc("/players/vibix/{imdb_id}", playersHandler.GetVibixPlayer).Methods("GET"),
+		openapi.Op("Players", "Vibix плеер по IMDb ID").Describe("Возвращает HTML-страницу с iframe Vibix для указанного IMDb ID").
+			Params(imdbIDParam).Produces("text/html").
+			Response(200, "HTML со встроенным Vibix плеером").
+			Response(404, "Фильм не найден").
+			Response(503, "VIBIX_TOKEN не настроен"))
+
+	doc(api.HandleFunc("/webtorrent/player", webtorrentHandler.OpenPlayer).Methods("GET"),
+		openapi.Op("WebTorrent", "WebTorrent плеер").Describe("Открытие WebTorrent плеера с магнет ссылкой. Плеер работает полностью на стороне клиента.").
+			Params(
+				openapi.Query("magnet", openapi.String(), "Магнет ссылка торрента"),
+				openapi.Header("X-Magnet-Link", openapi.String(), "Магнет ссылка через заголовок (альтернативный способ)"),
+			).
+			Produces("text/html").
+			Response(200, "HTML страница с WebTorrent плеером").
+			Response(400, "Отсутствует магнет ссылка"))
+	doc(api.HandleFunc("/webtorrent/metadata", webtorrentHandler.GetMetadata).Methods("GET"),
+		openapi.Op("WebTorrent", "Метаданные медиа").Describe("Получение метаданных фильма или сериала по названию для WebTorrent плеера").
+			Params(openapi.Query("query", openapi.String(), "Название для поиска (извлеченное из торрента)").Require()).
+			Returns(appHandlers.MediaMetadata{}).
+			Response(200, "Метаданные найдены").
+			Response(400, "Отсутствует параметр query").
+			Response(404, "Метаданные не найдены"))
+
+	doc(api.Handle("/torrents/search/{imdbId}", limiter.Wrap("torrents", torrentsHandler.SearchTorrents)).Methods("GET"),
+		openapi.Op("Torrents", "Поиск торрентов").Describe("Поиск торрентов по IMDB ID с фильтрацией, сортировкой и группировкой").
+			Params(
+				openapi.Path("imdbId", openapi.String(), "IMDB ID фильма или сериала"),
+				openapi.Query("type", openapi.Enum("movie", "tv", "serial").Default("movie"), "Тип контента"),
+				openapi.Query("season", openapi.Integer(), "Номер сезона (для сериалов)"),
+				openapi.Query("quality", openapi.String(), "Список качеств через запятую, например 1080p,2160p"),
+				openapi.Query("minQuality", openapi.String(), "Минимальное качество"),
+				openapi.Query("maxQuality", openapi.String(), "Максимальное качество"),
+				openapi.Query("excludeQualities", openapi.String(), "Исключаемые качества через запятую"),
+				openapi.Query("hdr", openapi.Boolean(), "Только HDR"),
+				openapi.Query("hevc", openapi.Boolean(), "Только HEVC"),
+				openapi.Query("sortBy", openapi.String().Default("seeders"), "Поле сортировки"),
+				openapi.Query("sortOrder", openapi.Enum("asc", "desc").Default("desc"), "Порядок сортировки"),
+				openapi.Query("groupByQuality", openapi.Boolean(), "Группировать по качеству"),
+				openapi.Query("groupBySeason", openapi.Boolean(), "Группировать по сезонам"),
+			).
+			Returns(map[string]interface{}{}).
+			Response(200, "Результаты поиска торрентов").
+			Response(404, "Торренты не найдены"))
+	doc(api.Handle("/torrents/movies", limiter.Wrap("torrents", torrentsHandler.SearchMovies)).Methods("GET"),
+		openapi.Op("Torrents", "Торренты фильмов").Describe("Поиск торрентов фильма по названию").
+			Params(titleParams...).Returns(map[string]interface{}{}).Response(200, "Результаты поиска"))
+	doc(api.Handle("/torrents/series", limiter.Wrap("torrents", torrentsHandler.SearchSeries)).Methods("GET"),
+		openapi.Op("Torrents", "Торренты сериалов").Describe("Поиск торрентов сериала по названию, с фильтром по сезону").
+			Params(append(titleParams, openapi.Query("season", openapi.Integer(), "Номер сезона"))...).
+			Returns(map[string]interface{}{}).Response(200, "Результаты поиска"))
+	doc(api.Handle("/torrents/anime", limiter.Wrap("torrents", torrentsHandler.SearchAnime)).Methods("GET"),
+		openapi.Op("Torrents", "Торренты аниме").Describe("Поиск торрентов аниме по названию").
+			Params(titleParams...).Returns(map[string]interface{}{}).Response(200, "Результаты поиска"))
+	doc(api.Handle("/torrents/seasons", limiter.Wrap("torrents", torrentsHandler.GetAvailableSeasons)).Methods("GET"),
+		openapi.Op("Torrents", "Доступные сезоны").Describe("Список сезонов сериала, для которых есть торренты").
+			Params(titleParams...).Returns(map[string]interface{}{}).
+			Response(200, "Список сезонов").
+			Response(400, "Не указано название"))
+	doc(api.Handle("/torrents/search", limiter.Wrap("torrents", torrentsHandler.SearchByQuery)).Methods("GET"),
+		openapi.Op("Torrents", "Поиск торрентов по запросу").Describe("Универсальный поиск торрентов по строке запроса").
+			Params(
+				openapi.Query("query", openapi.String(), "Поисковый запрос").Require(),
+				openapi.Query("type", openapi.Enum("movie", "series", "tv", "anime").Default("movie"), "Тип контента"),
+				openapi.Query("year", openapi.String(), "Год выпуска"),
+			).
+			Returns(map[string]interface{}{}).
+			Response(200, "Результаты поиска").
+			Response(400, "Не указан запрос"))
+
+	doc(api.HandleFunc("/reactions/{mediaType}/{mediaId}/counts", reactionsHandler.GetReactionCounts).Methods("GET"),
+		openapi.Op("Reactions", "Количество реакций").Describe("Получение количества реакций для медиа").
+			Params(mediaTypePath, openapi.Path("mediaId", openapi.String(), "ID медиа")).
+			ReturnsRaw(models.ReactionCounts{}).Response(200, "Количество реакций"))
+
+	doc(api.HandleFunc("/images/{size}/{path:.*}", imagesHandler.GetImage).Methods("GET"),
+		openapi.Op("Images", "Изображения").Describe("Прокси для изображений TMDB").
+			Params(openapi.Path("size", openapi.String(), "Размер изображения"), openapi.Path("path", openapi.String(), "Путь к изображению")).
+			Produces("image/*").Response(200, "Изображение"))
+
+	doc(api.HandleFunc("/lists/public/{slug}", listsHandler.GetPublicList).Methods("GET"),
+		openapi.Op("Lists", "Публичный список").Describe("Просмотр публичного списка по ссылке без авторизации").
+			Returns(models.UserList{}).
+			Response(200, "Список").
+			Response(404, "Список не найден или закрыт"))
+
+	doc(api.HandleFunc("/movies/search", movieHandler.Search).Methods("GET"),
+		openapi.Op("Movies", "Поиск фильмов").Describe("Поиск фильмов по названию с поддержкой фильтров").
+			Params(
+				openapi.Query("query", openapi.String(), "Поисковый запрос").Require(), pageParam, languageParam,
+				openapi.Query("year", openapi.Integer(), "Год выпуска"),
+			).
+			Returns(models.TMDBResponse{}).Response(200, "Результаты поиска фильмов"))
+	doc(api.HandleFunc("/movies/popular", movieHandler.Popular).Methods("GET"),
+		openapi.Op("Movies", "Популярные фильмы").Describe("Получение списка популярных фильмов").
+			Params(pageParam, languageParam).Returns(models.TMDBResponse{}).Response(200, "Список популярных фильмов"))
+	doc(api.HandleFunc("/movies/top-rated", movieHandler.TopRated).Methods("GET"),
+		openapi.Op("Movies", "Топ рейтинг фильмов").Describe("Получение списка фильмов с высоким рейтингом").
+			Params(pageParam, languageParam).Returns(models.TMDBResponse{}).Response(200, "Список фильмов с высоким рейтингом"))
+	doc(api.HandleFunc("/movies/upcoming", movieHandler.Upcoming).Methods("GET"),
+		openapi.Op("Movies", "Скоро в прокате").Describe("Получение списка фильмов, которые скоро выйдут в прокат").
+			Params(pageParam, languageParam).Returns(models.TMDBResponse{}).Response(200, "Список фильмов, которые скоро выйдут"))
+	doc(api.HandleFunc("/movies/now-playing", movieHandler.NowPlaying).Methods("GET"),
+		openapi.Op("Movies", "Сейчас в прокате").Describe("Получение списка фильмов, которые сейчас в прокате").
+			Params(pageParam, languageParam).Returns(models.TMDBResponse{}).Response(200, "Список фильмов в прокате"))
+	doc(api.HandleFunc("/movies/{id}", movieHandler.GetByID).Methods("GET"),
+		openapi.Op("Movies", "Получить фильм по ID").Describe("Подробная информация о фильме").
+			Params(movieIDParam, languageParam).Returns(models.Movie{}).Response(200, "Информация о фильме").Response(404, "Фильм не найден в TMDB"))
+	doc(api.HandleFunc("/movies/{id}/recommendations", movieHandler.GetRecommendations).Methods("GET"),
+		openapi.Op("Movies", "Рекомендации фильмов").Describe("Получение рекомендаций фильмов на основе выбранного").
+			Params(movieIDParam, pageParam, languageParam).Returns(models.TMDBResponse{}).Response(200, "Рекомендуемые фильмы"))
+	doc(api.HandleFunc("/movies/{id}/similar", movieHandler.GetSimilar).Methods("GET"),
+		openapi.Op("Movies", "Похожие фильмы").Describe("Получение похожих фильмов").
+			Params(movieIDParam, pageParam, languageParam).Returns(models.TMDBResponse{}).Response(200, "Похожие фильмы"))
+	doc(api.HandleFunc("/movies/{id}/external-ids", movieHandler.GetExternalIDs).Methods("GET"),
+		openapi.Op("Movies", "Внешние идентификаторы фильма").Describe("Получить внешние ID (IMDb, TVDB, Facebook и др.) для фильма по TMDB ID").
+			Params(movieIDParam).Returns(models.ExternalIDs{}).Response(200, "Внешние идентификаторы фильма"))
+
+	doc(api.HandleFunc("/tv/search", tvHandler.Search).Methods("GET"),
+		openapi.Op("TV Series", "Поиск сериалов").Describe("Поиск сериалов по названию").
+			Params(openapi.Query("query", openapi.String(), "Поисковый запрос").Require(), pageParam, languageParam).
+			Returns(models.TMDBTVResponse{}).Response(200, "Результаты поиска сериалов"))
+	doc(api.HandleFunc("/tv/popular", tvHandler.Popular).Methods("GET"),
+		openapi.Op("TV Series", "Популярные сериалы").Describe("Получение списка популярных сериалов").
+			Params(pageParam, languageParam).Returns(models.TMDBTVResponse{}).Response(200, "Список популярных сериалов"))
+	doc(api.HandleFunc("/tv/top-rated", tvHandler.TopRated).Methods("GET"),
+		openapi.Op("TV Series", "Топ рейтинг сериалов").Describe("Получение списка сериалов с высоким рейтингом").
+			Params(pageParam, languageParam).Returns(models.TMDBTVResponse{}).Response(200, "Список сериалов с высоким рейтингом"))
+	doc(api.HandleFunc("/tv/on-the-air", tvHandler.OnTheAir).Methods("GET"),
+		openapi.Op("TV Series", "В эфире").Describe("Получение списка сериалов, которые сейчас в эфире").
+			Params(pageParam, languageParam).Returns(models.TMDBTVResponse{}).Response(200, "Список сериалов в эфире"))
+	doc(api.HandleFunc("/tv/airing-today", tvHandler.AiringToday).Methods("GET"),
+		openapi.Op("TV Series", "Сегодня в эфире").Describe("Получение списка сериалов, которые выходят сегодня").
+			Params(pageParam, languageParam).Returns(models.TMDBTVResponse{}).Response(200, "Список сериалов, выходящих сегодня"))
+	doc(api.HandleFunc("/tv/{id}", tvHandler.GetByID).Methods("GET"),
+		openapi.Op("TV Series", "Получить сериал по ID").Describe("Подробная информация о сериале").
+			Params(tvIDParam, languageParam).Returns(models.TVShow{}).Response(200, "Информация о сериале").Response(404, "Сериал не найден в TMDB"))
+	doc(api.HandleFunc("/tv/{id}/recommendations", tvHandler.GetRecommendations).Methods("GET"),
+		openapi.Op("TV Series", "Рекомендации сериалов").Describe("Получение рекомендаций сериалов на основе выбранного").
+			Params(tvIDParam, pageParam, languageParam).Returns(models.TMDBTVResponse{}).Response(200, "Рекомендуемые сериалы"))
+	doc(api.HandleFunc("/tv/{id}/similar", tvHandler.GetSimilar).Methods("GET"),
+		openapi.Op("TV Series", "Похожие сериалы").Describe("Получение похожих сериалов").
+			Params(tvIDParam, pageParam, languageParam).Returns(models.TMDBTVResponse{}).Response(200, "Похожие сериалы"))
+	doc(api.HandleFunc("/tv/{id}/external-ids", tvHandler.GetExternalIDs).Methods("GET"),
+		openapi.Op("TV Series", "Внешние идентификаторы сериала").Describe("Получить внешние ID (IMDb, TVDB, Facebook и др.) для сериала по TMDB ID").
+			Params(tvIDParam).Returns(models.ExternalIDs{}).Response(200, "Внешние идентификаторы сериала"))
+
+	protectedRoute := a.spec.Secure(api.PathPrefix(""))
+	protected := protectedRoute.Subrouter()
+	protected.Use(middleware.JWTAuth(cfg.JWTSecret, d.sessions))
+	protected.Use(limiter.Middleware("user"))
+
+	doc(protected.HandleFunc("/favorites", favoritesHandler.GetFavorites).Methods("GET"),
+		openapi.Op("Favorites", "Получить избранное").Describe("Список избранных фильмов и сериалов пользователя с курсорной пагинацией").
+			Params(
+				cursorParam,
+				openapi.Query("limit", openapi.Integer().Default(20).Min(1).Max(100), "Размер страницы"),
+				openapi.Query("sort", openapi.Enum("createdAt", "title").Default("createdAt"), "Поле сортировки"),
+				openapi.Query("order", openapi.Enum("asc", "desc"), "По умолчанию desc для createdAt и asc для title"),
+				mediaFilterParam,
+			).
+			Returns(models.PaginatedResponse{}).
+			Response(200, "Страница избранного").
+			Response(400, "Некорректные параметры пагинации"))
+	doc(protected.HandleFunc("/favorites/{id}", favoritesHandler.AddToFavorites).Methods("POST"),
+		openapi.Op("Favorites", "Добавить в избранное").Describe("Добавление фильма или сериала в избранное").
+			Params(openapi.Path("id", openapi.String(), "ID медиа"), mediaTypeQuery).Returns(nil).Response(200, "Добавлено в избранное"))
+	doc(protected.HandleFunc("/favorites/{id}", favoritesHandler.RemoveFromFavorites).Methods("DELETE"),
+		openapi.Op("Favorites", "Удалить из избранного").Describe("Удаление фильма или сериала из избранного").
+			Params(openapi.Path("id", openapi.String(), "ID медиа"), mediaTypeQuery).Returns(nil).Response(200, "Удалено из избранного"))
+	doc(protected.HandleFunc("/favorites/{id}/check", favoritesHandler.CheckIsFavorite).Methods("GET"),
+		openapi.Op("Favorites", "Проверить избранное").Describe("Проверка, находится ли медиа в избранном").
+			Params(openapi.Path("id", openapi.String(), "ID медиа"), mediaTypeQuery).
+			Returns(map[string]bool{}).Response(200, "Статус избранного"))
+
+	doc(protected.HandleFunc("/auth/profile", authHandler.GetProfile).Methods("GET"),
+		openapi.Op("Authentication", "Получить профиль пользователя").Describe("Получение информации о текущем пользователе").
+			Returns(models.User{}).Response(200, "Информация о пользователе"))
+	doc(protected.HandleFunc("/auth/profile", authHandler.UpdateProfile).Methods("PUT"),
+		openapi.Op("Authentication", "Обновить профиль пользователя").Describe("Обновление информации о пользователе").
+			Accepts(models.UpdateProfileRequest{}).Returns(models.User{}).Response(200, "Профиль успешно обновлен"))
+	doc(protected.Handle("/auth/email/change", limiter.Wrap("email", authHandler.ChangeEmail)).Methods("POST"),
+		openapi.Op("Authentication", "Сменить email").Describe("Отправка кода подтверждения на новый адрес. Требует текущий пароль; email меняется только после подтверждения").
+			Accepts(models.ChangeEmailRequest{}).Returns(nil).
+			Response(200, "Код отправлен на новый адрес").
+			Response(403, "Неверный текущий пароль").
+			Response(409, "Адрес уже занят или у аккаунта нет пароля"))
+	doc(protected.Handle("/auth/email/confirm", limiter.Wrap("auth", authHandler.ConfirmEmailChange)).Methods("POST"),
+		openapi.Op("Authentication", "Подтвердить смену email").Describe("Переключение аккаунта на новый адрес по коду из письма").
+			Accepts(models.ConfirmEmailChangeRequest{}).Returns(models.User{}).
+			Response(200, "Email изменен").
+			Response(400, "Неверный или истекший код").
+			Response(409, "Адрес уже занят"))
+	doc(protected.Handle("/auth/password/change", limiter.Wrap("auth", authHandler.ChangePassword)).Methods("POST"),
+		openapi.Op("Authentication", "Сменить пароль").Describe("Смена пароля с проверкой текущего. Все сессии, кроме текущей, завершаются").
+			Accepts(models.ChangePasswordRequest{}).Returns(nil).
+			Response(200, "Пароль изменен").
+			Response(403, "Неверный текущий пароль").
+			Response(409, "У аккаунта нет пароля"))
+	doc(protected.HandleFunc("/auth/{provider}/link", authHandler.LinkProvider).Methods("POST"),
+		openapi.Op("Authentication", "Привязать провайдера").Describe("URL авторизации у провайдера для привязки к текущему аккаунту. После согласия провайдер вернет пользователя на /auth/{provider}/callback. Ответ ставит cookie oauth_state, без которой коллбек отклоняется, поэтому запрос нужно делать с credentials").
+			Params(providerParam).Returns(map[string]string{}).
+			Response(200, "URL для перехода в поле url").
+			Response(404, "Неизвестный провайдер").
+			Response(503, "Провайдер не сконфигурирован"))
+	doc(protected.HandleFunc("/auth/{provider}/link", authHandler.UnlinkProvider).Methods("DELETE"),
+		openapi.Op("Authentication", "Отвязать провайдера").Describe("Удаление привязанного аккаунта провайдера. Единственный способ входа без пароля отвязать нельзя").
+			Params(providerParam).Returns(models.User{}).
+			Response(200, "Провайдер отвязан").
+			Response(404, "Провайдер не привязан").
+			Response(409, "Это единственный способ входа"))
+	doc(protected.HandleFunc("/auth/profile", authHandler.DeleteAccount).Methods("DELETE"),
+		openapi.Op("Authentication", "Удалить аккаунт пользователя").Describe("Полное и безвозвратное удаление аккаунта пользователя и всех связанных с ним данных (избранное, реакции, списки)").
+			Returns(nil).Response(200, "Аккаунт успешно удален"))
+	doc(protected.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST"),
+		openapi.Op("Authentication", "Выход").Describe("Завершение текущей сессии и отзыв access токена").
+			Returns(nil).Response(200, "Сессия завершена"))
+	doc(protected.HandleFunc("/auth/logout-all", authHandler.LogoutAll).Methods("POST"),
+		openapi.Op("Authentication", "Выход со всех устройств").Describe("Завершение всех сессий пользователя").
+			Returns(nil).Response(200, "Все сессии завершены"))
+
+	doc(protected.HandleFunc("/reactions/{mediaType}/{mediaId}/my-reaction", reactionsHandler.GetMyReaction).Methods("GET"),
+		openapi.Op("Reactions", "Моя реакция").Describe("Реакция текущего пользователя на медиа; пустой объект, если реакции нет").
+			Params(mediaTypePath, openapi.Path("mediaId", openapi.String(), "ID медиа")).
+			ReturnsRaw(map[string]string{}).Response(200, "Тип реакции"))
+	doc(protected.HandleFunc("/reactions/{mediaType}/{mediaId}", reactionsHandler.SetReaction).Methods("POST"),
+		openapi.Op("Reactions", "Поставить реакцию").Describe("Установка или замена реакции пользователя: fire, nice, think, bore, shit").
+			Params(mediaTypePath, openapi.Path("mediaId", openapi.String(), "ID медиа")).
+			Accepts(struct {
+				Type string `json:"type" validate:"required"`
+			}{}).
+			Returns(nil).
+			Response(200, "Реакция сохранена").
+			Response(400, "Неизвестный тип реакции"))
+	doc(protected.HandleFunc("/reactions/{mediaType}/{mediaId}", reactionsHandler.RemoveReaction).Methods("DELETE"),
+		openapi.Op("Reactions", "Удалить реакцию").
+			Params(mediaTypePath, openapi.Path("mediaId", openapi.String(), "ID медиа")).
+			Returns(nil).Response(200, "Реакция удалена"))
+	doc(protected.HandleFunc("/reactions/my", reactionsHandler.GetMyReactions).Methods("GET"),
+		openapi.Op("Reactions", "Мои реакции").Describe("Реакции пользователя с курсорной пагинацией, новые первыми").
+			Params(
+				cursorParam,
+				openapi.Query("limit", openapi.Integer().Default(50).Min(1).Max(100), "Размер страницы"),
+				openapi.Query("sort", openapi.Enum("createdAt").Default("createdAt"), "Поле сортировки"),
+				openapi.Query("order", openapi.Enum("asc", "desc").Default("desc"), "Порядок сортировки"),
+				mediaFilterParam,
+			).
+			Returns(models.PaginatedResponse{}).
+			Response(200, "Страница реакций").
+			Response(400, "Некорректные параметры пагинации"))
+
+	doc(protected.HandleFunc("/watch-history", watchHistoryHandler.GetHistory).Methods("GET"),
+		openapi.Op("Watch History", "История просмотров").Describe("Последние записи истории просмотров пользователя").
+			Params(openapi.Query("limit", openapi.Integer().Default(50).Min(1).Max(100), "Количество записей")).
+			Returns([]models.WatchProgress{}).Response(200, "Список записей"))
+	doc(protected.HandleFunc("/watch-history/progress", watchHistoryHandler.RecordProgress).Methods("POST"),
+		openapi.Op("Watch History", "Сохранить прогресс просмотра").Describe("Сохранение позиции воспроизведения фильма или эпизода сериала. При просмотре 90% запись отмечается как досмотренная").
+			Accepts(models.WatchProgressRequest{}).Returns(models.WatchProgress{}).Response(200, "Прогресс сохранен"))
+	doc(protected.HandleFunc("/watch-history/continue", watchHistoryHandler.ContinueWatching).Methods("GET"),
+		openapi.Op("Watch History", "Продолжить просмотр").Describe("Недосмотренные фильмы и сериалы, отсортированные по времени последнего просмотра").
+			Params(openapi.Query("limit", openapi.Integer().Default(20).Min(1).Max(100), "Количество записей")).
+			Returns([]models.WatchProgress{}).Response(200, "Список для продолжения просмотра"))
+	doc(protected.HandleFunc("/watch-history/tv/{id}/watched", watchHistoryHandler.MarkEpisodeWatched).Methods("POST"),
+		openapi.Op("Watch History", "Отметить эпизод просмотренным").
+			Params(openapi.Path("id", openapi.String(), "ID сериала в TMDB")).
+			Accepts(models.EpisodeWatchedRequest{}).Returns(models.WatchProgress{}).Response(200, "Эпизод отмечен"))
+	doc(protected.HandleFunc("/watch-history/tv/{id}/next", watchHistoryHandler.GetNextEpisode).Methods("GET"),
+		openapi.Op("Watch History", "Следующий эпизод").Describe("Вычисляет следующий эпизод после последнего просмотренного по данным сезонов TMDB").
+			Params(openapi.Path("id", openapi.String(), "ID сериала в TMDB")).
+			Returns(models.NextEpisode{}).Response(200, "Следующий эпизод"))
+	doc(protected.HandleFunc("/watch-history/{mediaType}/{id}", watchHistoryHandler.RemoveFromHistory).Methods("DELETE"),
+		openapi.Op("Watch History", "Удалить из истории").Describe("Удаление всех записей фильма или сериала из истории просмотров").
+			Params(mediaTypePath).Returns(nil).Response(200, "Удалено"))
+
+	doc(protected.HandleFunc("/lists", listsHandler.GetLists).Methods("GET"),
+		openapi.Op("Lists", "Мои списки").Describe("Получение всех пользовательских списков, отсортированных по дате изменения").
+			Returns([]models.UserList{}).Response(200, "Списки пользователя"))
+	doc(protected.HandleFunc("/lists", listsHandler.CreateList).Methods("POST"),
+		openapi.Op("Lists", "Создать список").Describe("Создание нового именованного списка").
+			Accepts(models.CreateListRequest{}).Returns(models.UserList{}).
+			Response(201, "Список создан").
+			Response(400, "Не указано название"))
+	doc(protected.HandleFunc("/lists/{id}", listsHandler.GetList).Methods("GET"),
+		openapi.Op("Lists", "Получить список").
+			Returns(models.UserList{}).
+			Response(200, "Список").
+			Response(404, "Список не найден"))
+	doc(protected.HandleFunc("/lists/{id}", listsHandler.UpdateList).Methods("PUT"),
+		openapi.Op("Lists", "Изменить список").Describe("Изменение названия, описания и видимости списка").
+			Accepts(models.UpdateListRequest{}).Returns(models.UserList{}).
+			Response(200, "Список обновлён").
+			Response(404, "Список не найден"))
+	doc(protected.HandleFunc("/lists/{id}", listsHandler.DeleteList).Methods("DELETE"),
+		openapi.Op("Lists", "Удалить список").
+			Returns(nil).
+			Response(200, "Список удалён").
+			Response(404, "Список не найден"))
+	doc(protected.HandleFunc("/lists/{id}/items", listsHandler.AddItem).Methods("POST"),
+		openapi.Op("Lists", "Добавить в список").Describe("Добавление фильма или сериала в конец списка").
+			Accepts(models.ListItemRequest{}).Returns(models.UserList{}).
+			Response(200, "Элемент добавлен").
+			Response(404, "Список не найден").
+			Response(409, "Элемент уже в списке"))
+	doc(protected.HandleFunc("/lists/{id}/items/order", listsHandler.ReorderItems).Methods("PUT"),
+		openapi.Op("Lists", "Изменить порядок").Describe("Передаются все элементы списка в новом порядке").
+			Accepts(models.ReorderListRequest{}).Returns(models.UserList{}).
+			Response(200, "Порядок изменён").
+			Response(400, "Набор элементов не совпадает со списком"))
+	doc(protected.HandleFunc("/lists/{id}/items/{mediaType}/{mediaId}", listsHandler.RemoveItem).Methods("DELETE"),
+		openapi.Op("Lists", "Удалить из списка").
+			Params(mediaTypePath).Returns(models.UserList{}).
+			Response(200, "Элемент удалён").
+			Response(404, "Список не найден"))
+
+	doc(protected.HandleFunc("/digest/subscription", digestHandler.GetSubscription).Methods("GET"),
+		openapi.Op("Digest", "Подписка на рекомендации").Describe("Получает ли пользователь еженедельную подборку рекомендаций по избранному и реакциям").
+			Returns(models.DigestSubscription{}).Response(200, "Состояние подписки"))
+	doc(protected.HandleFunc("/digest/subscription", digestHandler.UpdateSubscription).Methods("PUT"),
+		openapi.Op("Digest", "Изменить подписку").Describe("Включение или отключение рассылки рекомендаций. Подписаться можно только с подтвержденным email").
+			Accepts(models.DigestSubscriptionRequest{}).Returns(models.DigestSubscription{}).
+			Response(200, "Состояние подписки").
+			Response(403, "Email не подтвержден"))
+
+	doc(protected.HandleFunc("/follows", followsHandler.GetFollows).Methods("GET"),
+		openapi.Op("Notifications", "Отслеживаемые тайтлы").Describe("Фильмы и сериалы, о выходе которых пользователь получает уведомления").
+			Params(
+				cursorParam,
+				openapi.Query("limit", openapi.Integer().Default(20).Min(1).Max(100), "Размер страницы"),
+				openapi.Query("sort", openapi.Enum("createdAt", "title").Default("createdAt"), "Поле сортировки"),
+				openapi.Query("order", openapi.Enum("asc", "desc"), "По умолчанию desc для createdAt и asc для title"),
+				mediaFilterParam,
+			).
+			Returns(models.PaginatedResponse{}).
+			Response(200, "Страница отслеживаемых тайтлов"))
+	doc(protected.HandleFunc("/follows/{mediaType}/{mediaId}", followsHandler.Follow).Methods("POST"),
+		openapi.Op("Notifications", "Отслеживать").Describe("Подписка на новые серии сериала или выход фильма. Уведомления приходят только о том, что выйдет после подписки").
+			Params(mediaTypePath, openapi.Path("mediaId", openapi.String(), "ID медиа в TMDB")).
+			Returns(models.Follow{}).
+			Response(200, "Тайтл отслеживается").
+			Response(400, "Неверный тип или ID медиа"))
+	doc(protected.HandleFunc("/follows/{mediaType}/{mediaId}", followsHandler.Unfollow).Methods("DELETE"),
+		openapi.Op("Notifications", "Не отслеживать").
+			Params(mediaTypePath, openapi.Path("mediaId", openapi.String(), "ID медиа в TMDB")).
+			Returns(nil).
+			Response(200, "Тайтл больше не отслеживается").
+			Response(404, "Тайтл не отслеживался"))
+
+	notificationIDParam := openapi.Path("id", openapi.String(), "ID уведомления")
+	doc(protected.HandleFunc("/notifications", notificationsHandler.GetNotifications).Methods("GET"),
+		openapi.Op("Notifications", "Уведомления").Describe("Уведомления о новых сериях и выходе фильмов, новые первыми").
+			Params(
+				openapi.Query("unread", openapi.Boolean(), "Только непрочитанные"),
+				cursorParam,
+				openapi.Query("limit", openapi.Integer().Default(20).Min(1).Max(100), "Размер страницы"),
+				openapi.Query("order", openapi.Enum("asc", "desc"), "По умолчанию desc"),
+				mediaFilterParam,
+			).
+			Returns(models.PaginatedResponse{}).
+			Response(200, "Страница уведомлений"))
+	doc(protected.HandleFunc("/notifications/unread-count", notificationsHandler.GetUnreadCount).Methods("GET"),
+		openapi.Op("Notifications", "Число непрочитанных").
+			Returns(map[string]int64{}).Response(200, "Число в поле unread"))
+	doc(protected.HandleFunc("/notifications/read-all", notificationsHandler.MarkAllRead).Methods("POST"),
+		openapi.Op("Notifications", "Прочитать все").
+			Returns(map[string]int64{}).Response(200, "Число измененных в поле updated"))
+	doc(protected.HandleFunc("/notifications/settings", notificationsHandler.GetSettings).Methods("GET"),
+		openapi.Op("Notifications", "Настройки уведомлений").
+			Returns(models.NotificationSettings{}).Response(200, "Настройки"))
+	doc(protected.HandleFunc("/notifications/settings", notificationsHandler.UpdateSettings).Methods("PUT"),
+		openapi.Op("Notifications", "Изменить настройки уведомлений").Describe("Включение или отключение копий уведомлений на email. Включить можно только с подтвержденным email").
+			Accepts(models.NotificationSettingsRequest{}).Returns(models.NotificationSettings{}).
+			Response(200, "Настройки").
+			Response(403, "Email не подтвержден"))
+	doc(protected.HandleFunc("/notifications/{id}/read", notificationsHandler.MarkRead).Methods("POST"),
+		openapi.Op("Notifications", "Прочитать уведомление").
+			Params(notificationIDParam).Returns(models.Notification{}).
+			Response(200, "Уведомление прочитано").
+			Response(404, "Уведомление не найдено"))
+	doc(protected.HandleFunc("/notifications/{id}", notificationsHandler.DeleteNotification).Methods("DELETE"),
+		openapi.Op("Notifications", "Удалить уведомление").
+			Params(notificationIDParam).Returns(nil).
+			Response(200, "Уведомление удалено").
+			Response(404, "Уведомление не найдено"))
+
+	// Администрирование: поверх JWT проверяется флаг isAdmin
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.RequireAdmin(d.auth))
+
+	userIDParam := openapi.Path("id", openapi.String(), "ID пользователя")
+	doc(admin.HandleFunc("/users", adminHandler.ListUsers).Methods("GET"),
+		openapi.Op("Admin", "Пользователи").Describe("Список и поиск пользователей по email или имени").
+			Params(
+				openapi.Query("q", openapi.String(), "Подстрока email или имени без учета регистра"),
+				openapi.Query("banned", openapi.Enum("true", "false"), "Фильтр по блокировке"),
+				cursorParam,
+				openapi.Query("limit", openapi.Integer().Default(20).Min(1).Max(100), "Размер страницы"),
+				openapi.Query("sort", openapi.Enum("createdAt", "email", "name").Default("createdAt"), "Поле сортировки"),
+				openapi.Query("order", openapi.Enum("asc", "desc"), "По умолчанию desc для createdAt и asc для остальных"),
+			).
+			Returns(models.PaginatedResponse{}).
+			Response(200, "Страница пользователей").
+			Response(403, "Нет прав администратора"))
+	doc(admin.HandleFunc("/users/{id}", adminHandler.GetUser).Methods("GET"),
+		openapi.Op("Admin", "Пользователь").
+			Params(userIDParam).Returns(models.User{}).
+			Response(200, "Пользователь").
+			Response(404, "Пользователь не найден"))
+	doc(admin.HandleFunc("/users/{id}/ban", adminHandler.BanUser).Methods("POST"),
+		openapi.Op("Admin", "Заблокировать пользователя").Describe("Блокировка аккаунта и завершение всех его сессий").
+			Params(userIDParam).Accepts(models.BanUserRequest{}).Returns(models.User{}).
+			Response(200, "Пользователь заблокирован").
+			Response(404, "Пользователь не найден"))
+	doc(admin.HandleFunc("/users/{id}/ban", adminHandler.UnbanUser).Methods("DELETE"),
+		openapi.Op("Admin", "Разблокировать пользователя").
+			Params(userIDParam).Returns(models.User{}).
+			Response(200, "Пользователь разблокирован").
+			Response(404, "Пользователь не найден"))
+	doc(admin.HandleFunc("/users/{id}/verify-email", adminHandler.VerifyEmail).Methods("POST"),
+		openapi.Op("Admin", "Подтвердить email").Describe("Подтверждение email пользователя без кода").
+			Params(userIDParam).Returns(models.User{}).
+			Response(200, "Email подтвержден").
+			Response(404, "Пользователь не найден"))
+	doc(admin.HandleFunc("/stats", adminHandler.Stats).Methods("GET"),
+		openapi.Op("Admin", "Статистика").Describe("Количество пользователей, избранного, реакций и писем в очереди, самые популярные тайтлы").
+			Returns(models.AdminStats{}).Response(200, "Статистика"))
+	doc(admin.HandleFunc("/health", healthHandler.ReadyDetails).Methods("GET"),
+		openapi.Op("Admin", "Состояние зависимостей").Describe("Отчет /health/ready вместе с ошибками проверок, которые не показываются в публичном ответе").
+			Returns(health.Report{}).Response(200, "Отчет о проверках"))
+	doc(admin.HandleFunc("/cache/purge", adminHandler.PurgeCache).Methods("POST"),
+		openapi.Op("Admin", "Очистить кэш").Describe("Удаление всех закэшированных ответов TMDB").
+			Returns(nil).Response(200, "Кэш очищен"))
+
+	emailIDParam := openapi.Path("id", openapi.String(), "ID письма")
+	doc(admin.HandleFunc("/emails", adminHandler.ListEmails).Methods("GET"),
+		openapi.Op("Admin", "Очередь писем").Describe("Письма из email_outbox со статусом, числом попыток и последней ошибкой. Тело письма не возвращается").
+			Params(
+				openapi.Query("status", openapi.Enum("pending", "sending", "sent", "dead"), "Фильтр по статусу"),
+				openapi.Query("to", openapi.String(), "Адрес получателя"),
+				cursorParam,
+				openapi.Query("limit", openapi.Integer().Default(20).Min(1).Max(100), "Размер страницы"),
+				openapi.Query("order", openapi.Enum("asc", "desc"), "По умолчанию desc"),
+			).
+			Returns(models.PaginatedResponse{}).
+			Response(200, "Страница писем"))
+	doc(admin.HandleFunc("/emails/{id}", adminHandler.GetEmail).Methods("GET"),
+		openapi.Op("Admin", "Письмо").
+			Params(emailIDParam).Returns(models.OutboxEmail{}).
+			Response(200, "Письмо").
+			Response(404, "Письмо не найдено"))
+	doc(admin.HandleFunc("/emails/{id}/retry", adminHandler.RetryEmail).Methods("POST"),
+		openapi.Op("Admin", "Повторить отправку").Describe("Возврат письма в очередь с обнуленным счетчиком попыток").
+			Params(emailIDParam).Returns(models.OutboxEmail{}).
+			Response(200, "Письмо снова в очереди").
+			Response(404, "Письмо не найдено").
+			Response(409, "Письмо уже отправлено или его срок действия истек"))
+
+	doc(admin.HandleFunc("/email-templates", adminHandler.ListEmailTemplates).Methods("GET"),
+		openapi.Op("Admin", "Шаблоны писем").Describe("Имена шаблонов писем и поддерживаемые языки").
+			Returns(services.EmailTemplates{}).Response(200, "Шаблоны"))
+	doc(admin.HandleFunc("/email-templates/{name}/preview", adminHandler.PreviewEmailTemplate).Methods("GET"),
+		openapi.Op("Admin", "Предпросмотр письма").Describe("Тема, текстовая и HTML-версия шаблона, заполненного тестовыми данными. Письмо не отправляется").
+			Params(
+				openapi.Path("name", openapi.Enum(services.EmailVerification, services.EmailChange, services.EmailPasswordReset, services.EmailRecommendations, services.EmailNotification), "Имя шаблона"),
+				openapi.Query("locale", openapi.Enum(mail.Locales...), "Язык, по умолчанию ru"),
+			).
+			Returns(mail.Rendered{}).
+			Response(200, "Письмо").
+			Response(404, "Шаблон не найден"))
+
+	return r
+}
@@ -0,0 +1,200 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/openapi"
+)
+
+// routeTable — таблица маршрутов из main.go и api/index.go до переноса в
+// пакет app, дополненная маршрутами, которые появились позже. Маршрут,
+// пропавший из роутера или переименованный, ломает клиентов.
+var routeTable = []string{
+	"GET /",
+	"GET /openapi.json",
+	"GET /metrics",
+	"GET /cron/outbox",
+	"GET /health/live",
+	"GET /health/ready",
+
+	"GET /api/v1/health",
+	"POST /api/v1/auth/register",
+	"POST /api/v1/auth/login",
+	"POST /api/v1/auth/verify",
+	"POST /api/v1/auth/resend-code",
+	"POST /api/v1/auth/refresh",
+	"POST /api/v1/auth/forgot-password",
+	"POST /api/v1/auth/reset-password",
+	// Раньше /auth/google/login и /auth/google/callback
+	"GET /api/v1/auth/{provider}/login",
+	"GET /api/v1/auth/{provider}/callback",
+	"POST /api/v1/auth/oauth/exchange",
+	"GET /api/v1/search/multi",
+	"GET /api/v1/categories",
+	"GET /api/v1/categories/{id}/movies",
+	"GET /api/v1/categories/{id}/media",
+	"GET /api/v1/players/alloha/{imdb_id}",
+	"GET /api/v1/players/lumex/{imdb_id}",
+	"GET /api/v1/players/vibix/{imdb_id}",
+	"GET /api/v1/webtorrent/player",
+	"GET /api/v1/webtorrent/metadata",
+	"GET /api/v1/torrents/search/{imdbId}",
+	"GET /api/v1/torrents/movies",
+	"GET /api/v1/torrents/series",
+	"GET /api/v1/torrents/anime",
+	"GET /api/v1/torrents/seasons",
+	"GET /api/v1/torrents/search",
+	"GET /api/v1/reactions/{mediaType}/{mediaId}/counts",
+	"GET /api/v1/images/{size}/{path:.*}",
+	"GET /api/v1/lists/public/{slug}",
+	"GET /api/v1/movies/search",
+	"GET /api/v1/movies/popular",
+	"GET /api/v1/movies/top-rated",
+	"GET /api/v1/movies/upcoming",
+	"GET /api/v1/movies/now-playing",
+	"GET /api/v1/movies/{id}",
+	"GET /api/v1/movies/{id}/recommendations",
+	"GET /api/v1/movies/{id}/similar",
+	"GET /api/v1/movies/{id}/external-ids",
+	"GET /api/v1/tv/search",
+	"GET /api/v1/tv/popular",
+	"GET /api/v1/tv/top-rated",
+	"GET /api/v1/tv/on-the-air",
+	"GET /api/v1/tv/airing-today",
+	"GET /api/v1/tv/{id}",
+	"GET /api/v1/tv/{id}/recommendations",
+	"GET /api/v1/tv/{id}/similar",
+	"GET /api/v1/tv/{id}/external-ids",
+
+	"GET /api/v1/favorites",
+	"POST /api/v1/favorites/{id}",
+	"DELETE /api/v1/favorites/{id}",
+	"GET /api/v1/favorites/{id}/check",
+	"GET /api/v1/auth/profile",
+	"PUT /api/v1/auth/profile",
+	"DELETE /api/v1/auth/profile",
+	"POST /api/v1/auth/logout",
+	"POST /api/v1/auth/logout-all",
+	"GET /api/v1/reactions/{mediaType}/{mediaId}/my-reaction",
+	"POST /api/v1/reactions/{mediaType}/{mediaId}",
+	"DELETE /api/v1/reactions/{mediaType}/{mediaId}",
+	"GET /api/v1/reactions/my",
+	"GET /api/v1/watch-history",
+	"POST /api/v1/watch-history/progress",
+	"GET /api/v1/watch-history/continue",
+	"POST /api/v1/watch-history/tv/{id}/watched",
+	"GET /api/v1/watch-history/tv/{id}/next",
+	"DELETE /api/v1/watch-history/{mediaType}/{id}",
+	"GET /api/v1/lists",
+	"POST /api/v1/lists",
+	"GET /api/v1/lists/{id}",
+	"PUT /api/v1/lists/{id}",
+	"DELETE /api/v1/lists/{id}",
+	"POST /api/v1/lists/{id}/items",
+	"PUT /api/v1/lists/{id}/items/order",
+	"DELETE /api/v1/lists/{id}/items/{mediaType}/{mediaId}",
+
+	// Добавлены после переноса
+	"POST /api/v1/digest/unsubscribe",
+	"POST /api/v1/digest/subscribe",
+	"GET /api/v1/digest/subscription",
+	"PUT /api/v1/digest/subscription",
+	"POST /api/v1/auth/email/change",
+	"POST /api/v1/auth/email/confirm",
+	"POST /api/v1/auth/password/change",
+	"POST /api/v1/auth/{provider}/link",
+	"DELETE /api/v1/auth/{provider}/link",
+	"GET /api/v1/follows",
+	"POST /api/v1/follows/{mediaType}/{mediaId}",
+	"DELETE /api/v1/follows/{mediaType}/{mediaId}",
+	"GET /api/v1/notifications",
+	"GET /api/v1/notifications/unread-count",
+	"POST /api/v1/notifications/read-all",
+	"GET /api/v1/notifications/settings",
+	"PUT /api/v1/notifications/settings",
+	"POST /api/v1/notifications/{id}/read",
+	"DELETE /api/v1/notifications/{id}",
+	"GET /api/v1/admin/users",
+	"GET /api/v1/admin/users/{id}",
+	"POST /api/v1/admin/users/{id}/ban",
+	"DELETE /api/v1/admin/users/{id}/ban",
+	"POST /api/v1/admin/users/{id}/verify-email",
+	"GET /api/v1/admin/stats",
+	"GET /api/v1/admin/health",
+	"POST /api/v1/admin/cache/purge",
+	"GET /api/v1/admin/emails",
+	"GET /api/v1/admin/emails/{id}",
+	"POST /api/v1/admin/emails/{id}/retry",
+	"GET /api/v1/admin/email-templates",
+	"GET /api/v1/admin/email-templates/{name}/preview",
+}
+
+// testRouter строит роутер на пустых зависимостях: сервисы при регистрации
+// маршрутов не вызываются, а лимитер nil пропускает запросы без проверок.
+func testRouter(t *testing.T) (*App, *mux.Router) {
+	t.Helper()
+	a := &App{
+		cfg:  &config.Config{JWTSecret: "secret"},
+		spec: openapi.NewSpec(openapi.Info{Title: "test"}, models.APIResponse{}, models.ErrorResponse{}),
+	}
+	return a, a.routes(&deps{})
+}
+
+func TestRouteTable(t *testing.T) {
+	_, router := testRouter(t)
+
+	got := map[string]bool{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Префиксы подроутеров сами ничего не обслуживают
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			key := method + " " + path
+			if got[key] {
+				t.Errorf("route %s registered twice", key)
+			}
+			got[key] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{}
+	for _, key := range routeTable {
+		want[key] = true
+		if !got[key] {
+			t.Errorf("route %s is missing", key)
+		}
+	}
+	var extra []string
+	for key := range got {
+		if !want[key] {
+			extra = append(extra, key)
+		}
+	}
+	sort.Strings(extra)
+	if len(extra) > 0 {
+		t.Errorf("routes not in the table:\n%s", strings.Join(extra, "\n"))
+	}
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	a, router := testRouter(t)
+	if _, err := a.spec.Build(router); err != nil {
+		t.Fatal(err)
+	}
+}
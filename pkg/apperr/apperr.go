@@ -0,0 +1,149 @@
+// Package apperr defines typed API errors and the single JSON writer used by
+// every handler and middleware, so clients always receive the same error
+// shape with a stable machine-readable code.
+package apperr
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Stable error codes. Clients may switch on these; the messages are for
+// humans and may change.
+const (
+	CodeBadRequest      = "bad_request"
+	CodeValidation      = "validation_failed"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeTooManyRequests = "too_many_requests"
+	CodeUpstream        = "upstream_error"
+	CodeUpstreamTimeout = "upstream_timeout"
+	CodeUnavailable     = "service_unavailable"
+	CodeInternal        = "internal_error"
+)
+
+// StatusClientClosedRequest is the nginx convention for requests the client
+// abandoned before the response was written.
+const StatusClientClosedRequest = 499
+
+// Error is an error with an HTTP status and a stable code.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	// Details is serialized as is, e.g. field errors of a validation failure.
+	Details interface{}
+	cause   error
+}
+
+// ErrInvalidBody is returned when a request body cannot be decoded.
+var ErrInvalidBody = BadRequest("Invalid request body").WithCode("invalid_body")
+
+// New returns an error with an explicit status and code.
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Validation(message string) *Error {
+	return New(http.StatusBadRequest, CodeValidation, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
+func Unavailable(message string) *Error {
+	return New(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// Upstream reports a failure of an external provider (TMDB, RedAPI, ...).
+// The cause is logged but never sent to the client.
+func Upstream(provider string, cause error) *Error {
+	e := New(http.StatusBadGateway, CodeUpstream, provider+" request failed")
+	if errors.Is(cause, context.DeadlineExceeded) {
+		e.Status, e.Code, e.Message = http.StatusGatewayTimeout, CodeUpstreamTimeout, provider+" request timed out"
+	}
+	e.Details = map[string]string{"provider": provider}
+	e.cause = cause
+	return e
+}
+
+// Internal wraps an unexpected error. Its message is hidden from clients.
+func Internal(cause error) *Error {
+	e := New(http.StatusInternalServerError, CodeInternal, "Internal server error")
+	e.cause = cause
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error { return e.cause }
+
+// WithCode returns a copy with a more specific code, e.g. "list_not_found".
+func (e *Error) WithCode(code string) *Error {
+	c := *e
+	c.Code = code
+	return &c
+}
+
+// WithDetails returns a copy carrying details for the client.
+func (e *Error) WithDetails(details interface{}) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithCause returns a copy that wraps cause. The cause is logged for
+// server-side errors but never sent to the client.
+func (e *Error) WithCause(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// From converts any error into an *Error. Unknown errors become internal
+// errors; a missing Mongo document becomes not found.
+func From(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return NotFound("Resource not found")
+	case errors.Is(err, context.Canceled):
+		// Клиент закрыл соединение, ответ уже никто не прочитает
+		return &Error{Status: StatusClientClosedRequest, Code: "client_closed_request", Message: "Request canceled", cause: err}
+	default:
+		return Internal(err)
+	}
+}
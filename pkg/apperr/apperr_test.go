@@ -0,0 +1,89 @@
+package apperr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"neomovies-api/pkg/models"
+)
+
+func TestFrom(t *testing.T) {
+	notFound := NotFound("List not found").WithCode("list_not_found")
+
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+	}{
+		{"typed", notFound, http.StatusNotFound, "list_not_found"},
+		{"wrapped", fmt.Errorf("lists: %w", notFound), http.StatusNotFound, "list_not_found"},
+		{"no documents", fmt.Errorf("find: %w", mongo.ErrNoDocuments), http.StatusNotFound, CodeNotFound},
+		{"canceled", context.Canceled, StatusClientClosedRequest, "client_closed_request"},
+		{"upstream", Upstream("TMDB", errors.New("connection reset")), http.StatusBadGateway, CodeUpstream},
+		{"upstream timeout", Upstream("TMDB", fmt.Errorf("get: %w", context.DeadlineExceeded)), http.StatusGatewayTimeout, CodeUpstreamTimeout},
+		{"unknown", errors.New("boom"), http.StatusInternalServerError, CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := From(tt.err)
+			if got.Status != tt.status || got.Code != tt.code {
+				t.Errorf("From() = %d %s, want %d %s", got.Status, got.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
+
+func TestWithCopies(t *testing.T) {
+	cause := errors.New("duplicate key")
+	e := Conflict("Already exists").WithCode("list_exists").WithDetails("x").WithCause(cause)
+
+	if ErrInvalidBody.Code != "invalid_body" || ErrInvalidBody.cause != nil {
+		t.Error("With* modified a shared error")
+	}
+	if !errors.Is(e, cause) || e.Error() != "Already exists: duplicate key" {
+		t.Errorf("error = %q", e.Error())
+	}
+}
+
+func TestWriteHidesCause(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"internal", Internal(errors.New("mongo.internal:27017 refused")), http.StatusInternalServerError, "Internal server error"},
+		{"unavailable", Unavailable("Try again later").WithCause(errors.New("pool exhausted")), http.StatusServiceUnavailable, "Try again later"},
+		{"validation", Validation("Invalid email").WithDetails(map[string]string{"email": "invalid"}), http.StatusBadRequest, "Invalid email"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Write(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			if rec.Code != tt.status || rec.Header().Get("Content-Type") != "application/json" {
+				t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			body := rec.Body.String()
+			if strings.Contains(body, "refused") || strings.Contains(body, "pool") {
+				t.Errorf("response leaks the cause: %s", body)
+			}
+			var resp models.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Success || resp.Error != tt.message || resp.Code != From(tt.err).Code {
+				t.Errorf("response = %+v", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package apperr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"neomovies-api/pkg/logger"
+	"neomovies-api/pkg/models"
+)
+
+// Write sends err as a models.ErrorResponse. Server-side failures are
+// logged together with their cause, which is never exposed to the client.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+
+	if e.Status >= http.StatusInternalServerError {
+		logger.FromContext(r.Context()).Error("request failed",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", e.Status,
+			"error_code", e.Code,
+			// Ошибки net/http содержат полный URL запроса вместе с токенами
+			"error", logger.RedactError(err),
+		)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{
+		Success:   false,
+		Error:     e.Message,
+		Code:      e.Code,
+		Details:   e.Details,
+		RequestID: logger.RequestID(r.Context()),
+	})
+}
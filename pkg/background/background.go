@@ -0,0 +1,56 @@
+package background
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Group tracks fire-and-forget goroutines (emails, cub.rip sync) so that
+// the server can wait for them before exiting.
+type Group struct {
+	wg sync.WaitGroup
+}
+
+// Go runs fn in a new goroutine. A panic in fn is logged instead of
+// crashing the process.
+func (g *Group) Go(name string, fn func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		defer func() {
+			if rec := recover(); rec != nil {
+				slog.Error("background task panicked", "task", name, "panic", rec)
+			}
+		}()
+		fn()
+	}()
+}
+
+// Wait blocks until all tasks finish or ctx is done, whichever is first.
+func (g *Group) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var defaultGroup Group
+
+// Go runs fn in the default group.
+func Go(name string, fn func()) {
+	defaultGroup.Go(name, fn)
+}
+
+// Wait waits for the default group.
+func Wait(ctx context.Context) error {
+	return defaultGroup.Wait(ctx)
+}
@@ -0,0 +1,49 @@
+package background
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWaitForTasks(t *testing.T) {
+	var g Group
+	var done atomic.Int32
+	for i := 0; i < 3; i++ {
+		g.Go("task", func() {
+			time.Sleep(10 * time.Millisecond)
+			done.Add(1)
+		})
+	}
+
+	if err := g.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := done.Load(); got != 3 {
+		t.Errorf("finished tasks = %d, want 3", got)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	defer close(release)
+	g.Go("stuck", func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+}
+
+func TestPanicIsRecovered(t *testing.T) {
+	var g Group
+	g.Go("panicking", func() { panic("boom") })
+
+	if err := g.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
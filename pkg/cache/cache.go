@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Cache stores raw upstream responses by key with a per-entry TTL.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	Clear(ctx context.Context) error
+	Stats() Stats
+}
+
+// Stats contains hit/miss counters of a cache instance.
+type Stats struct {
+	Backend string `json:"backend"`
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Sets    uint64 `json:"sets"`
+	Entries int    `json:"entries"`
+}
+
+// HitRatio returns the share of lookups that were served from the cache.
+func (s Stats) HitRatio() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+type counters struct {
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	sets   atomic.Uint64
+}
+
+func (c *counters) record(hit bool) {
+	if hit {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+}
+
+func (c *counters) stats(backend string, entries int) Stats {
+	return Stats{
+		Backend: backend,
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Sets:    c.sets.Load(),
+		Entries: entries,
+	}
+}
+
+// Noop is a cache that never stores anything. Used when caching is disabled.
+type Noop struct{ counters }
+
+func NewNoop() *Noop { return &Noop{} }
+
+func (c *Noop) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.record(false)
+	return nil, false
+}
+
+func (c *Noop) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return nil
+}
+
+func (c *Noop) Delete(ctx context.Context, key string) error { return nil }
+
+func (c *Noop) Clear(ctx context.Context) error { return nil }
+
+func (c *Noop) Stats() Stats { return c.stats("none", 0) }
+
+// New builds a cache for the configured backend: "memory", "mongo" or "none".
+func New(backend string, capacity int, db *mongo.Database) (Cache, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemory(capacity), nil
+	case "mongo":
+		return NewMongo(db, "tmdb_cache")
+	case "none", "off":
+		return NewNoop(), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend: %s", backend)
+	}
+}
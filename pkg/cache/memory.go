@@ -0,0 +1,117 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory is an in-process LRU cache with per-entry expiration.
+type Memory struct {
+	counters
+
+	mu       sync.Mutex
+	capacity int
+	items    map[string]*list.Element
+	order    *list.List
+	now      func() time.Time
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory creates an LRU cache holding at most capacity entries.
+func NewMemory(capacity int) *Memory {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	return &Memory{
+		capacity: capacity,
+		items:    make(map[string]*list.Element),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+func (c *Memory) Get(ctx context.Context, key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.record(false)
+		return nil, false
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(el)
+		c.record(false)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	c.record(true)
+	return entry.value, true
+}
+
+func (c *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(ttl)
+	if el, ok := c.items[key]; ok {
+		entry := el.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(el)
+	} else {
+		c.items[key] = c.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+		for c.order.Len() > c.capacity {
+			c.removeElement(c.order.Back())
+		}
+	}
+
+	c.sets.Add(1)
+	return nil
+}
+
+func (c *Memory) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+	return nil
+}
+
+func (c *Memory) Clear(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	return nil
+}
+
+func (c *Memory) Stats() Stats {
+	c.mu.Lock()
+	entries := c.order.Len()
+	c.mu.Unlock()
+	return c.stats("memory", entries)
+}
+
+func (c *Memory) removeElement(el *list.Element) {
+	entry := el.Value.(*memoryEntry)
+	delete(c.items, entry.key)
+	c.order.Remove(el)
+}
@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(2)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	// Обращение к "a" делает "b" самым старым
+	if _, ok := c.Get(ctx, "a"); !ok {
+		t.Fatal("a: expected hit")
+	}
+	c.Set(ctx, "c", []byte("3"), time.Minute)
+
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("b: expected eviction")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.Get(ctx, key); !ok {
+			t.Errorf("%s: expected hit", key)
+		}
+	}
+	if got := c.Stats().Entries; got != 2 {
+		t.Errorf("entries = %d, want 2", got)
+	}
+}
+
+func TestMemoryExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewMemory(10)
+	c.now = func() time.Time { return now }
+
+	c.Set(ctx, "k", []byte("v"), time.Minute)
+	now = now.Add(59 * time.Second)
+	if v, ok := c.Get(ctx, "k"); !ok || string(v) != "v" {
+		t.Fatalf("before expiry: got %q, %v", v, ok)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := c.Get(ctx, "k"); ok {
+		t.Fatal("after expiry: expected miss")
+	}
+	if got := c.Stats().Entries; got != 0 {
+		t.Errorf("expired entry kept: entries = %d", got)
+	}
+}
+
+func TestMemorySetWithoutTTLIsIgnored(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(10)
+
+	c.Set(ctx, "k", []byte("v"), 0)
+	if _, ok := c.Get(ctx, "k"); ok {
+		t.Fatal("expected miss for zero TTL")
+	}
+}
+
+func TestMemoryStats(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(10)
+
+	c.Set(ctx, "k", []byte("v"), time.Minute)
+	c.Get(ctx, "k")
+	c.Get(ctx, "k")
+	c.Get(ctx, "missing")
+
+	stats := c.Stats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Sets != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if got := stats.HitRatio(); got < 0.66 || got > 0.67 {
+		t.Errorf("hit ratio = %v", got)
+	}
+}
+
+func TestMemoryDeleteAndClear(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemory(10)
+
+	c.Set(ctx, "a", []byte("1"), time.Minute)
+	c.Set(ctx, "b", []byte("2"), time.Minute)
+	c.Delete(ctx, "a")
+	if _, ok := c.Get(ctx, "a"); ok {
+		t.Error("a: expected miss after delete")
+	}
+
+	c.Clear(ctx)
+	if _, ok := c.Get(ctx, "b"); ok {
+		t.Error("b: expected miss after clear")
+	}
+}
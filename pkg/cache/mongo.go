@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Mongo keeps cached responses in a MongoDB collection so that every
+// instance of the API shares the same cache. Expired documents are removed
+// by a TTL index on expiresAt.
+type Mongo struct {
+	counters
+
+	collection *mongo.Collection
+}
+
+type mongoEntry struct {
+	Key       string    `bson:"_id"`
+	Value     []byte    `bson:"value"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// NewMongo creates a cache backed by the given collection and ensures its TTL index.
+func NewMongo(db *mongo.Database, collectionName string) (*Mongo, error) {
+	if collectionName == "" {
+		collectionName = "tmdb_cache"
+	}
+	c := &Mongo{collection: db.Collection(collectionName)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := c.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Mongo) Get(ctx context.Context, key string) ([]byte, bool) {
+	var entry mongoEntry
+	err := c.collection.FindOne(ctx, bson.M{
+		"_id":       key,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&entry)
+	if err != nil {
+		c.record(false)
+		return nil, false
+	}
+	c.record(true)
+	return entry.Value, true
+}
+
+func (c *Mongo) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := c.collection.ReplaceOne(
+		ctx,
+		bson.M{"_id": key},
+		mongoEntry{Key: key, Value: value, ExpiresAt: time.Now().Add(ttl)},
+		options.Replace().SetUpsert(true),
+	)
+	if err == nil {
+		c.sets.Add(1)
+	}
+	return err
+}
+
+func (c *Mongo) Delete(ctx context.Context, key string) error {
+	_, err := c.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+func (c *Mongo) Clear(ctx context.Context) error {
+	_, err := c.collection.DeleteMany(ctx, bson.M{})
+	return err
+}
+
+func (c *Mongo) Stats() Stats {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	entries, err := c.collection.EstimatedDocumentCount(ctx)
+	if err != nil {
+		entries = 0
+	}
+	return c.stats("mongo", int(entries))
+}
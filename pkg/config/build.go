@@ -0,0 +1,10 @@
+package config
+
+// Build information, set at link time:
+//
+//	go build -ldflags "-X neomovies-api/pkg/config.Version=2.1.0 -X neomovies-api/pkg/config.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = "unknown"
+	BuildTime = ""
+)
@@ -1,72 +1,295 @@
 package config
 
 import (
-	"log"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// Config — настройки сервиса. Значения берутся из значений по умолчанию,
+// затем из YAML-файла (если указан) и переопределяются переменными
+// окружения. Поля с тегом secret скрываются при выводе через Print.
 type Config struct {
-	MongoURI        string
-	MongoDBName     string
-	TMDBAccessToken string
-	JWTSecret       string
-	Port            string
-	BaseURL         string
-	NodeEnv         string
-	GmailUser       string
-	GmailPassword   string
-	LumexURL        string
-	AllohaToken     string
-	RedAPIBaseURL   string
-	RedAPIKey       string
-	GoogleClientID  string
-	GoogleClientSecret string
-	GoogleRedirectURL  string
-	FrontendURL        string
-    VibixHost        string
-    VibixToken       string
-}
+	MongoURI           string `yaml:"mongo_uri" secret:"password"`
+	MongoDBName        string `yaml:"mongo_db_name"`
+	TMDBAccessToken    string `yaml:"tmdb_access_token" secret:"true"`
+	JWTSecret          string `yaml:"jwt_secret" secret:"true"`
+	Port               int    `yaml:"port"`
+	BaseURL            string `yaml:"base_url"`
+	NodeEnv            string `yaml:"node_env"`
+	LumexURL           string `yaml:"lumex_url"`
+	AllohaToken        string `yaml:"alloha_token" secret:"true"`
+	RedAPIBaseURL      string `yaml:"redapi_base_url"`
+	RedAPIKey          string `yaml:"redapi_key" secret:"true"`
+	GoogleClientID     string `yaml:"google_client_id"`
+	GoogleClientSecret string `yaml:"google_client_secret" secret:"true"`
+	GoogleRedirectURL  string `yaml:"google_redirect_url"`
+	GitHubClientID     string `yaml:"github_client_id"`
+	GitHubClientSecret string `yaml:"github_client_secret" secret:"true"`
+	GitHubRedirectURL  string `yaml:"github_redirect_url"`
+	YandexClientID     string `yaml:"yandex_client_id"`
+	YandexClientSecret string `yaml:"yandex_client_secret" secret:"true"`
+	YandexRedirectURL  string `yaml:"yandex_redirect_url"`
+	VKClientID         string `yaml:"vk_client_id"`
+	VKClientSecret     string `yaml:"vk_client_secret" secret:"true"`
+	VKRedirectURL      string `yaml:"vk_redirect_url"`
+	FrontendURL        string `yaml:"frontend_url"`
+	VibixHost          string `yaml:"vibix_host"`
+	VibixToken         string `yaml:"vibix_token" secret:"true"`
+
+	MailTransport string `yaml:"mail_transport"`
+	MailFrom      string `yaml:"mail_from"`
+	MailFromName  string `yaml:"mail_from_name"`
+	MailDir       string `yaml:"mail_dir"`
+	SMTPHost      string `yaml:"smtp_host"`
+	SMTPPort      int    `yaml:"smtp_port"`
+	SMTPSecurity  string `yaml:"smtp_security"`
+	SMTPUsername  string `yaml:"smtp_username"`
+	SMTPPassword  string `yaml:"smtp_password" secret:"true"`
+
+	DigestEnabled  bool          `yaml:"digest_enabled"`
+	DigestInterval time.Duration `yaml:"digest_interval"`
+	DigestSize     int           `yaml:"digest_size"`
+	DigestSecret   string        `yaml:"digest_secret" secret:"true"`
+
+	NotificationsEnabled  bool          `yaml:"notifications_enabled"`
+	NotificationsInterval time.Duration `yaml:"notifications_interval"`
+
+	TMDBBaseURL   string `yaml:"tmdb_base_url"`
+	TMDBCache     string `yaml:"tmdb_cache"`
+	TMDBCacheSize int    `yaml:"tmdb_cache_size"`
+
+	RateLimitEnabled    bool   `yaml:"rate_limit_enabled"`
+	RateLimitStore      string `yaml:"rate_limit_store"`
+	RateLimits          string `yaml:"rate_limits"`
+	RateLimitTrustProxy bool   `yaml:"rate_limit_trust_proxy"`
 
-func New() *Config {
-	mongoURI := getMongoURI()
+	LogLevel     string `yaml:"log_level"`
+	LogFormat    string `yaml:"log_format"`
+	MetricsToken string `yaml:"metrics_token" secret:"true"`
+	CronSecret   string `yaml:"cron_secret" secret:"true"`
 
+	ReadTimeout       time.Duration `yaml:"server_read_timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"server_read_header_timeout"`
+	WriteTimeout      time.Duration `yaml:"server_write_timeout"`
+	IdleTimeout       time.Duration `yaml:"server_idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+}
+
+// Defaults возвращает конфигурацию со значениями по умолчанию
+func Defaults() *Config {
 	return &Config{
-		MongoURI:        mongoURI,
-		MongoDBName:     getEnv(EnvMongoDBName, DefaultMongoDBName),
-		TMDBAccessToken: getEnv(EnvTMDBAccessToken, ""),
-		JWTSecret:       getEnv(EnvJWTSecret, DefaultJWTSecret),
-		Port:            getEnv(EnvPort, DefaultPort),
-		BaseURL:         getEnv(EnvBaseURL, DefaultBaseURL),
-		NodeEnv:         getEnv(EnvNodeEnv, DefaultNodeEnv),
-		GmailUser:       getEnv(EnvGmailUser, ""),
-		GmailPassword:   getEnv(EnvGmailPassword, ""),
-		LumexURL:        getEnv(EnvLumexURL, ""),
-		AllohaToken:     getEnv(EnvAllohaToken, ""),
-		RedAPIBaseURL:   getEnv(EnvRedAPIBaseURL, DefaultRedAPIBase),
-		RedAPIKey:       getEnv(EnvRedAPIKey, ""),
-		GoogleClientID:  getEnv(EnvGoogleClientID, ""),
-		GoogleClientSecret: getEnv(EnvGoogleClientSecret, ""),
-		GoogleRedirectURL:  getEnv(EnvGoogleRedirectURL, ""),
-		FrontendURL:        getEnv(EnvFrontendURL, ""),
-        VibixHost:        getEnv(EnvVibixHost, DefaultVibixHost),
-        VibixToken:       getEnv(EnvVibixToken, ""),
+		MongoDBName:           DefaultMongoDBName,
+		JWTSecret:             DefaultJWTSecret,
+		Port:                  DefaultPort,
+		BaseURL:               DefaultBaseURL,
+		NodeEnv:               DefaultNodeEnv,
+		RedAPIBaseURL:         DefaultRedAPIBase,
+		VibixHost:             DefaultVibixHost,
+		MailTransport:         DefaultMailTransport,
+		MailFromName:          DefaultMailFromName,
+		MailDir:               DefaultMailDir,
+		SMTPHost:              DefaultSMTPHost,
+		SMTPPort:              DefaultSMTPPort,
+		SMTPSecurity:          DefaultSMTPSecurity,
+		DigestEnabled:         true,
+		DigestInterval:        DefaultDigestInterval,
+		DigestSize:            DefaultDigestSize,
+		NotificationsEnabled:  true,
+		NotificationsInterval: DefaultNotificationsInterval,
+		TMDBBaseURL:           DefaultTMDBBaseURL,
+		TMDBCache:             DefaultTMDBCache,
+		TMDBCacheSize:         DefaultTMDBCacheSize,
+		RateLimitEnabled:      true,
+		RateLimitStore:        DefaultRateLimitStore,
+		LogLevel:              DefaultLogLevel,
+		ReadTimeout:           DefaultServerReadTimeout,
+		ReadHeaderTimeout:     DefaultServerReadHeaderTimeout,
+		WriteTimeout:          DefaultServerWriteTimeout,
+		IdleTimeout:           DefaultServerIdleTimeout,
+		ShutdownTimeout:       DefaultShutdownTimeout,
+	}
+}
+
+// Load собирает конфигурацию из файла path (может быть пустым) и
+// переменных окружения и проверяет ее. Ошибки чтения файла и разбора
+// переменных возвращаются без конфигурации; при ошибке валидации
+// возвращается и конфигурация, чтобы ее можно было вывести через Print.
+func Load(path string) (*Config, error) {
+	cfg := Defaults()
+
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	// В разработке удобнее читать текстовые логи, в остальных окружениях пишем JSON
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "json"
+		if cfg.NodeEnv == "development" {
+			cfg.LogFormat = "text"
+		}
+	}
+	// Без явного отправителя письма идут от имени SMTP-пользователя
+	if cfg.MailFrom == "" {
+		cfg.MailFrom = cfg.SMTPUsername
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, err
+	}
+
+	if cfg.JWTSecret == DefaultJWTSecret {
+		slog.Warn("using the default JWT secret; set JWT_SECRET before deploying")
+	}
+	if cfg.DigestSecret == "" {
+		slog.Warn("DIGEST_SECRET is not set; unsubscribe links are signed with a key derived from JWT_SECRET")
 	}
+	return cfg, nil
 }
 
-func getMongoURI() string {
-	for _, envVar := range []string{"MONGO_URI", "MONGODB_URI", "DATABASE_URL", "MONGO_URL"} {
-		if value := os.Getenv(envVar); value != "" {
-			log.Printf("DEBUG: Using %s for MongoDB connection", envVar)
-			return value
+// IsProduction сообщает, запущен ли сервис в production
+func (c *Config) IsProduction() bool {
+	return c.NodeEnv == "production"
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	env := &envLoader{}
+
+	env.str(&c.MongoURI, EnvMongoURI, "MONGODB_URI", "DATABASE_URL", "MONGO_URL")
+	env.str(&c.MongoDBName, EnvMongoDBName)
+	env.str(&c.TMDBAccessToken, EnvTMDBAccessToken)
+	env.str(&c.JWTSecret, EnvJWTSecret)
+	env.int(&c.Port, EnvPort)
+	env.str(&c.BaseURL, EnvBaseURL)
+	env.str(&c.NodeEnv, EnvNodeEnv)
+	env.str(&c.LumexURL, EnvLumexURL)
+	env.str(&c.AllohaToken, EnvAllohaToken)
+	env.str(&c.RedAPIBaseURL, EnvRedAPIBaseURL)
+	env.str(&c.RedAPIKey, EnvRedAPIKey)
+	env.str(&c.GoogleClientID, EnvGoogleClientID)
+	env.str(&c.GoogleClientSecret, EnvGoogleClientSecret)
+	env.str(&c.GoogleRedirectURL, EnvGoogleRedirectURL)
+	env.str(&c.GitHubClientID, EnvGitHubClientID)
+	env.str(&c.GitHubClientSecret, EnvGitHubClientSecret)
+	env.str(&c.GitHubRedirectURL, EnvGitHubRedirectURL)
+	env.str(&c.YandexClientID, EnvYandexClientID)
+	env.str(&c.YandexClientSecret, EnvYandexClientSecret)
+	env.str(&c.YandexRedirectURL, EnvYandexRedirectURL)
+	env.str(&c.VKClientID, EnvVKClientID)
+	env.str(&c.VKClientSecret, EnvVKClientSecret)
+	env.str(&c.VKRedirectURL, EnvVKRedirectURL)
+	env.str(&c.FrontendURL, EnvFrontendURL)
+	env.str(&c.VibixHost, EnvVibixHost)
+	env.str(&c.VibixToken, EnvVibixToken)
+	env.str(&c.MailTransport, EnvMailTransport)
+	env.str(&c.MailFromName, EnvMailFromName)
+	env.str(&c.MailDir, EnvMailDir)
+	env.str(&c.SMTPHost, EnvSMTPHost)
+	env.int(&c.SMTPPort, EnvSMTPPort)
+	env.str(&c.SMTPSecurity, EnvSMTPSecurity)
+	// GMAIL_* оставлены для совместимости со старыми окружениями
+	env.str(&c.SMTPUsername, EnvSMTPUsername, EnvGmailUser)
+	env.str(&c.SMTPPassword, EnvSMTPPassword, EnvGmailPassword)
+	env.str(&c.MailFrom, EnvMailFrom)
+	env.bool(&c.DigestEnabled, EnvDigestEnabled)
+	env.duration(&c.DigestInterval, EnvDigestInterval)
+	env.int(&c.DigestSize, EnvDigestSize)
+	env.str(&c.DigestSecret, EnvDigestSecret)
+	env.bool(&c.NotificationsEnabled, EnvNotificationsEnabled)
+	env.duration(&c.NotificationsInterval, EnvNotificationsInterval)
+	env.str(&c.TMDBBaseURL, EnvTMDBBaseURL)
+	env.str(&c.TMDBCache, EnvTMDBCache)
+	env.int(&c.TMDBCacheSize, EnvTMDBCacheSize)
+	env.bool(&c.RateLimitEnabled, EnvRateLimitEnabled)
+	env.str(&c.RateLimitStore, EnvRateLimitStore)
+	env.str(&c.RateLimits, EnvRateLimits)
+	env.bool(&c.RateLimitTrustProxy, EnvRateLimitTrustProxy)
+	env.str(&c.LogLevel, EnvLogLevel)
+	env.str(&c.LogFormat, EnvLogFormat)
+	env.str(&c.MetricsToken, EnvMetricsToken)
+	env.str(&c.CronSecret, EnvCronSecret)
+	env.duration(&c.ReadTimeout, EnvServerReadTimeout)
+	env.duration(&c.ReadHeaderTimeout, EnvServerReadHeaderTimeout)
+	env.duration(&c.WriteTimeout, EnvServerWriteTimeout)
+	env.duration(&c.IdleTimeout, EnvServerIdleTimeout)
+	env.duration(&c.ShutdownTimeout, EnvShutdownTimeout)
+
+	return errors.Join(env.errs...)
+}
+
+// envLoader переопределяет поле, только если переменная задана, и копит
+// ошибки разбора вместо того, чтобы молча подставлять значение по умолчанию
+type envLoader struct {
+	errs []error
+}
+
+// str берет первую непустую переменную из keys
+func (l *envLoader) str(dst *string, keys ...string) {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			*dst = value
+			return
 		}
 	}
-	log.Printf("DEBUG: No MongoDB URI environment variable found")
-	return ""
 }
 
-func getEnv(key, defaultValue string) string {
+func (l *envLoader) int(dst *int, key string) {
 	if value := os.Getenv(key); value != "" {
-		return value
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: invalid integer %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+}
+
+func (l *envLoader) bool(dst *bool, key string) {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: invalid boolean %q", key, value))
+			return
+		}
+		*dst = parsed
 	}
-	return defaultValue
-}
\ No newline at end of file
+}
+
+// duration принимает значения вида "30s", "2m"
+func (l *envLoader) duration(dst *time.Duration, key string) {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			l.errs = append(l.errs, fmt.Errorf("%s: invalid duration %q", key, value))
+			return
+		}
+		*dst = parsed
+	}
+}
@@ -0,0 +1,164 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileAndEnv(t *testing.T) {
+	path := writeConfig(t, `
+mongo_uri: mongodb://localhost:27017
+tmdb_access_token: file-token
+port: 8080
+server_read_timeout: 30s
+`)
+	// Переменные окружения важнее файла
+	t.Setenv(EnvPort, "9090")
+	t.Setenv(EnvShutdownTimeout, "5s")
+	t.Setenv(EnvGmailUser, "legacy@example.com")
+	t.Setenv(EnvGmailPassword, "app-password")
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.TMDBAccessToken != "file-token" || cfg.Port != 9090 {
+		t.Errorf("token = %q, port = %d", cfg.TMDBAccessToken, cfg.Port)
+	}
+	if cfg.ReadTimeout != 30*time.Second || cfg.ShutdownTimeout != 5*time.Second {
+		t.Errorf("read timeout = %v, shutdown timeout = %v", cfg.ReadTimeout, cfg.ShutdownTimeout)
+	}
+	if cfg.WriteTimeout != DefaultServerWriteTimeout {
+		t.Errorf("write timeout = %v, want the default", cfg.WriteTimeout)
+	}
+	if cfg.SMTPUsername != "legacy@example.com" || cfg.MailFrom != "legacy@example.com" {
+		t.Errorf("smtp username = %q, mail from = %q", cfg.SMTPUsername, cfg.MailFrom)
+	}
+	if cfg.LogFormat != "text" {
+		t.Errorf("log format = %q, want text in development", cfg.LogFormat)
+	}
+}
+
+func TestLoadRejectsBadInput(t *testing.T) {
+	t.Run("unknown field", func(t *testing.T) {
+		if _, err := Load(writeConfig(t, "mongo_url: mongodb://localhost\n")); err == nil {
+			t.Fatal("typo in the config file was accepted")
+		}
+	})
+	t.Run("bad env values", func(t *testing.T) {
+		t.Setenv(EnvPort, "eighty")
+		t.Setenv(EnvServerIdleTimeout, "120")
+		_, err := Load("")
+		if err == nil || !strings.Contains(err.Error(), EnvPort) || !strings.Contains(err.Error(), EnvServerIdleTimeout) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func validConfig() *Config {
+	cfg := Defaults()
+	cfg.MongoURI = "mongodb://localhost:27017"
+	cfg.TMDBAccessToken = "token"
+	cfg.LogFormat = "json"
+	return cfg
+}
+
+func productionSecrets(c *Config) {
+	c.NodeEnv = "production"
+	c.JWTSecret = strings.Repeat("j", minProductionSecretLength)
+	c.DigestSecret = strings.Repeat("d", minProductionSecretLength)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		problem string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"mongo scheme", func(c *Config) { c.MongoURI = "localhost:27017" }, "MONGO_URI must start with"},
+		{"default secret in production", func(c *Config) { c.NodeEnv = "production" }, "JWT_SECRET must not use the default value"},
+		{"short secret in production", func(c *Config) { c.NodeEnv = "production"; c.JWTSecret = "short" }, "JWT_SECRET must be at least"},
+		{"digest secret in production", func(c *Config) { productionSecrets(c); c.DigestSecret = "" }, "DIGEST_SECRET is required"},
+		// Ключ ссылок отписки не должен совпадать с ключом сессий
+		{"digest secret reused", func(c *Config) { productionSecrets(c); c.DigestSecret = c.JWTSecret }, "DIGEST_SECRET must differ from JWT_SECRET"},
+		{"short digest secret", func(c *Config) { productionSecrets(c); c.DigestSecret = "short" }, "DIGEST_SECRET must be at least"},
+		{"production", productionSecrets, ""},
+		{"port", func(c *Config) { c.Port = 70000 }, "PORT must be between"},
+		{"relative url", func(c *Config) { c.FrontendURL = "/app" }, "FRONTEND_URL must be an absolute"},
+		{"oauth secret", func(c *Config) { c.GitHubClientID = "id" }, "GITHUB_CLIENT_SECRET is required"},
+		{"mail transport", func(c *Config) { c.MailTransport = "sendmail" }, "MAIL_TRANSPORT must be one of"},
+		{"smtp password", func(c *Config) { c.SMTPUsername = "user" }, "SMTP_PASSWORD is required"},
+		{"timeout", func(c *Config) { c.WriteTimeout = 0 }, "SERVER_WRITE_TIMEOUT must be positive"},
+		// Выключенный дайджест не проверяется
+		{"digest disabled", func(c *Config) { c.DigestEnabled = false; c.DigestSize = 0 }, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.problem == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.problem) {
+				t.Fatalf("err = %v, want %q", err, tt.problem)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllProblems(t *testing.T) {
+	cfg := validConfig()
+	cfg.MongoURI = ""
+	cfg.Port = 0
+	cfg.LogLevel = "verbose"
+
+	var verr *ValidationError
+	if err := cfg.Validate(); !errors.As(err, &verr) || len(verr.Problems) != 3 {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := validConfig()
+	cfg.MongoURI = "mongodb://admin:hunter2@db:27017/?authSource=admin"
+	cfg.JWTSecret = "jwt-secret-value"
+	cfg.SMTPPassword = "smtp-password"
+	cfg.TMDBAccessToken = "tmdb-token-value"
+
+	var buf bytes.Buffer
+	if err := cfg.Print(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "jwt-secret-value", "smtp-password", "tmdb-token-value"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output contains %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "mongodb://admin:REDACTED@db:27017") {
+		t.Errorf("mongo_uri lost its host:\n%s", out)
+	}
+	// Исходная конфигурация не меняется
+	if cfg.JWTSecret != "jwt-secret-value" {
+		t.Error("Print modified the config")
+	}
+}
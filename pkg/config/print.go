@@ -0,0 +1,56 @@
+package config
+
+import (
+	"io"
+	"net/url"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[redacted]"
+
+// Redacted возвращает копию конфигурации со скрытыми секретами. Тег
+// secret:"true" скрывает значение целиком, secret:"password" — только
+// пароль в URL (например, в строке подключения MongoDB).
+func (c *Config) Redacted() *Config {
+	out := *c
+	v := reflect.ValueOf(&out).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		if field.Kind() != reflect.String || field.String() == "" {
+			continue
+		}
+		switch t.Field(i).Tag.Get("secret") {
+		case "true":
+			field.SetString(redacted)
+		case "password":
+			field.SetString(redactURLPassword(field.String()))
+		}
+	}
+	return &out
+}
+
+func redactURLPassword(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if _, ok := u.User.Password(); ok {
+		// Скобки из redacted экранировались бы в URL
+		u.User = url.UserPassword(u.User.Username(), "REDACTED")
+	}
+	return u.String()
+}
+
+// Print пишет итоговую конфигурацию в YAML со скрытыми секретами. Вывод
+// можно использовать как файл конфигурации после подстановки секретов.
+func (c *Config) Print(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// minProductionSecretLength — минимальная длина JWT_SECRET и DIGEST_SECRET
+// в production
+const minProductionSecretLength = 32
+
+// ValidationError перечисляет все найденные проблемы конфигурации сразу,
+// чтобы не исправлять их по одной за запуск
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate проверяет обязательные значения и диапазоны. В production
+// дополнительно запрещены секреты по умолчанию.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.require(c.MongoURI, "MONGO_URI is required")
+	if c.MongoURI != "" && !strings.HasPrefix(c.MongoURI, "mongodb://") && !strings.HasPrefix(c.MongoURI, "mongodb+srv://") {
+		v.add("MONGO_URI must start with mongodb:// or mongodb+srv://")
+	}
+	v.require(c.MongoDBName, "MONGO_DB_NAME is required")
+	v.require(c.TMDBAccessToken, "TMDB_ACCESS_TOKEN is required")
+	v.require(c.JWTSecret, "JWT_SECRET is required")
+
+	if c.IsProduction() {
+		if c.JWTSecret == DefaultJWTSecret {
+			v.add("JWT_SECRET must not use the default value in production")
+		} else if len(c.JWTSecret) < minProductionSecretLength {
+			v.add(fmt.Sprintf("JWT_SECRET must be at least %d characters in production", minProductionSecretLength))
+		}
+		// Ссылки отписки живут в письмах долго, поэтому их ключ отделен от
+		// ключа сессий: утечка одного не должна давать подделывать другое
+		if c.DigestSecret == "" {
+			v.add("DIGEST_SECRET is required in production")
+		} else if c.DigestSecret == c.JWTSecret {
+			v.add("DIGEST_SECRET must differ from JWT_SECRET")
+		} else if len(c.DigestSecret) < minProductionSecretLength {
+			v.add(fmt.Sprintf("DIGEST_SECRET must be at least %d characters in production", minProductionSecretLength))
+		}
+	}
+
+	if c.Port < 1 || c.Port > 65535 {
+		v.add(fmt.Sprintf("PORT must be between 1 and 65535, got %d", c.Port))
+	}
+
+	v.url(c.BaseURL, "BASE_URL", true)
+	v.url(c.TMDBBaseURL, "TMDB_BASE_URL", true)
+	v.url(c.RedAPIBaseURL, "REDAPI_BASE_URL", true)
+	v.url(c.VibixHost, "VIBIX_HOST", true)
+	v.url(c.LumexURL, "LUMEX_URL", false)
+	v.url(c.FrontendURL, "FRONTEND_URL", false)
+	v.url(c.GoogleRedirectURL, "GOOGLE_REDIRECT_URL", false)
+
+	if c.GoogleClientID != "" {
+		v.require(c.GoogleClientSecret, "GOOGLE_CLIENT_SECRET is required when GOOGLE_CLIENT_ID is set")
+		v.require(c.GoogleRedirectURL, "GOOGLE_REDIRECT_URL is required when GOOGLE_CLIENT_ID is set")
+	}
+	// Для остальных провайдеров redirect URL по умолчанию строится из BASE_URL
+	v.oauthProvider("GITHUB", c.GitHubClientID, c.GitHubClientSecret, c.GitHubRedirectURL)
+	v.oauthProvider("YANDEX", c.YandexClientID, c.YandexClientSecret, c.YandexRedirectURL)
+	v.oauthProvider("VK", c.VKClientID, c.VKClientSecret, c.VKRedirectURL)
+
+	v.oneOf(c.MailTransport, "MAIL_TRANSPORT", "smtp", "file", "log")
+	if c.MailFrom != "" {
+		if _, err := mail.ParseAddress(c.MailFrom); err != nil {
+			v.add(fmt.Sprintf("MAIL_FROM must be an email address, got %q", c.MailFrom))
+		}
+	}
+	if c.MailTransport == "smtp" {
+		v.require(c.SMTPHost, "SMTP_HOST is required when MAIL_TRANSPORT is smtp")
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			v.add(fmt.Sprintf("SMTP_PORT must be between 1 and 65535, got %d", c.SMTPPort))
+		}
+		v.oneOf(c.SMTPSecurity, "SMTP_SECURITY", "starttls", "tls", "none")
+		if c.SMTPUsername != "" {
+			v.require(c.SMTPPassword, "SMTP_PASSWORD is required when SMTP_USERNAME is set")
+		}
+	}
+	if c.MailTransport == "file" {
+		v.require(c.MailDir, "MAIL_DIR is required when MAIL_TRANSPORT is file")
+	}
+
+	if c.DigestEnabled {
+		v.positive(c.DigestInterval, "DIGEST_INTERVAL")
+		if c.DigestSize < 1 || c.DigestSize > 20 {
+			v.add(fmt.Sprintf("DIGEST_SIZE must be between 1 and 20, got %d", c.DigestSize))
+		}
+	}
+
+	if c.NotificationsEnabled {
+		v.positive(c.NotificationsInterval, "NOTIFICATIONS_INTERVAL")
+	}
+
+	v.oneOf(c.TMDBCache, "TMDB_CACHE", "memory", "mongo", "none", "off")
+	if c.TMDBCache == "memory" && c.TMDBCacheSize <= 0 {
+		v.add("TMDB_CACHE_SIZE must be positive")
+	}
+	v.oneOf(c.RateLimitStore, "RATE_LIMIT_STORE", "memory", "mongo")
+	v.oneOf(c.LogLevel, "LOG_LEVEL", "debug", "info", "warn", "error")
+	v.oneOf(c.LogFormat, "LOG_FORMAT", "json", "text")
+
+	v.positive(c.ReadTimeout, "SERVER_READ_TIMEOUT")
+	v.positive(c.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT")
+	v.positive(c.WriteTimeout, "SERVER_WRITE_TIMEOUT")
+	v.positive(c.IdleTimeout, "SERVER_IDLE_TIMEOUT")
+	v.positive(c.ShutdownTimeout, "SHUTDOWN_TIMEOUT")
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) add(problem string) {
+	v.problems = append(v.problems, problem)
+}
+
+func (v *validator) require(value, problem string) {
+	if value == "" {
+		v.add(problem)
+	}
+}
+
+func (v *validator) url(value, key string, required bool) {
+	if value == "" {
+		if required {
+			v.add(key + " is required")
+		}
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(fmt.Sprintf("%s must be an absolute http(s) URL, got %q", key, value))
+	}
+}
+
+func (v *validator) oauthProvider(prefix, clientID, clientSecret, redirectURL string) {
+	v.url(redirectURL, prefix+"_REDIRECT_URL", false)
+	if clientID != "" {
+		v.require(clientSecret, prefix+"_CLIENT_SECRET is required when "+prefix+"_CLIENT_ID is set")
+	}
+}
+
+func (v *validator) oneOf(value, key string, allowed ...string) {
+	for _, a := range allowed {
+		if value == a {
+			return
+		}
+	}
+	v.add(fmt.Sprintf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+}
+
+func (v *validator) positive(value time.Duration, key string) {
+	if value <= 0 {
+		v.add(key + " must be positive")
+	}
+}
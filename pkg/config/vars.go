@@ -1,7 +1,11 @@
 package config
 
+import "time"
+
 const (
 	// Environment variable keys
+	EnvConfigFile        = "CONFIG_FILE"
+	EnvMongoURI          = "MONGO_URI"
 	EnvTMDBAccessToken   = "TMDB_ACCESS_TOKEN"
 	EnvJWTSecret         = "JWT_SECRET"
 	EnvPort              = "PORT"
@@ -17,18 +21,77 @@ const (
 	EnvGoogleClientID    = "GOOGLE_CLIENT_ID"
 	EnvGoogleClientSecret= "GOOGLE_CLIENT_SECRET"
 	EnvGoogleRedirectURL = "GOOGLE_REDIRECT_URL"
+	EnvGitHubClientID     = "GITHUB_CLIENT_ID"
+	EnvGitHubClientSecret = "GITHUB_CLIENT_SECRET"
+	EnvGitHubRedirectURL  = "GITHUB_REDIRECT_URL"
+	EnvYandexClientID     = "YANDEX_CLIENT_ID"
+	EnvYandexClientSecret = "YANDEX_CLIENT_SECRET"
+	EnvYandexRedirectURL  = "YANDEX_REDIRECT_URL"
+	EnvVKClientID         = "VK_CLIENT_ID"
+	EnvVKClientSecret     = "VK_CLIENT_SECRET"
+	EnvVKRedirectURL      = "VK_REDIRECT_URL"
 	EnvFrontendURL       = "FRONTEND_URL"
     EnvVibixHost  = "VIBIX_HOST"
     EnvVibixToken = "VIBIX_TOKEN"
+	EnvMailTransport       = "MAIL_TRANSPORT"
+	EnvMailFrom            = "MAIL_FROM"
+	EnvMailFromName        = "MAIL_FROM_NAME"
+	EnvMailDir             = "MAIL_DIR"
+	EnvSMTPHost            = "SMTP_HOST"
+	EnvSMTPPort            = "SMTP_PORT"
+	EnvSMTPSecurity        = "SMTP_SECURITY"
+	EnvSMTPUsername        = "SMTP_USERNAME"
+	EnvSMTPPassword        = "SMTP_PASSWORD"
+	EnvDigestEnabled       = "DIGEST_ENABLED"
+	EnvDigestInterval      = "DIGEST_INTERVAL"
+	EnvDigestSize          = "DIGEST_SIZE"
+	EnvDigestSecret        = "DIGEST_SECRET"
+	EnvNotificationsEnabled  = "NOTIFICATIONS_ENABLED"
+	EnvNotificationsInterval = "NOTIFICATIONS_INTERVAL"
+	EnvTMDBBaseURL       = "TMDB_BASE_URL"
+	EnvTMDBCache         = "TMDB_CACHE"
+	EnvTMDBCacheSize     = "TMDB_CACHE_SIZE"
+	EnvRateLimitEnabled    = "RATE_LIMIT_ENABLED"
+	EnvRateLimitStore      = "RATE_LIMIT_STORE"
+	EnvRateLimits          = "RATE_LIMITS"
+	EnvRateLimitTrustProxy = "RATE_LIMIT_TRUST_PROXY"
+	EnvLogLevel            = "LOG_LEVEL"
+	EnvLogFormat           = "LOG_FORMAT"
+	EnvMetricsToken        = "METRICS_TOKEN"
+	EnvCronSecret          = "CRON_SECRET"
+	EnvServerReadTimeout       = "SERVER_READ_TIMEOUT"
+	EnvServerReadHeaderTimeout = "SERVER_READ_HEADER_TIMEOUT"
+	EnvServerWriteTimeout      = "SERVER_WRITE_TIMEOUT"
+	EnvServerIdleTimeout       = "SERVER_IDLE_TIMEOUT"
+	EnvShutdownTimeout         = "SHUTDOWN_TIMEOUT"
     
 	// Default values
 	DefaultJWTSecret   = "your-secret-key"
-	DefaultPort        = "3000"
+	DefaultPort        = 3000
 	DefaultBaseURL     = "http://localhost:3000"
 	DefaultNodeEnv     = "development"
 	DefaultRedAPIBase  = "http://redapi.cfhttp.top"
 	DefaultMongoDBName = "database"
     DefaultVibixHost = "https://vibix.org"  
+	DefaultMailTransport = "smtp"
+	DefaultMailFromName  = "Neo Movies"
+	DefaultMailDir       = "mail"
+	DefaultSMTPHost      = "smtp.gmail.com"
+	DefaultSMTPPort      = 587
+	DefaultSMTPSecurity  = "starttls"
+	DefaultDigestInterval = 7 * 24 * time.Hour
+	DefaultDigestSize     = 10
+	DefaultNotificationsInterval = 6 * time.Hour
+	DefaultTMDBBaseURL   = "https://api.themoviedb.org/3"
+	DefaultTMDBCache     = "memory"
+	DefaultTMDBCacheSize = 2000
+	DefaultRateLimitStore = "memory"
+	DefaultLogLevel       = "info"
+	DefaultServerReadTimeout       = 15 * time.Second
+	DefaultServerReadHeaderTimeout = 5 * time.Second
+	DefaultServerWriteTimeout      = 60 * time.Second
+	DefaultServerIdleTimeout       = 120 * time.Second
+	DefaultShutdownTimeout         = 20 * time.Second
 
 	// Static constants
 	TMDBImageBaseURL = "https://image.tmdb.org/t/p"
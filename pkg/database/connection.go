@@ -6,6 +6,8 @@ import (
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/metrics"
 )
 
 var client *mongo.Client
@@ -15,7 +17,12 @@ func Connect(uri, dbName string) (*mongo.Database, error) {
 	defer cancel()
 
 	var err error
-	client, err = mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	opts := options.Client().
+		ApplyURI(uri).
+		SetMonitor(metrics.MongoCommandMonitor()).
+		SetPoolMonitor(metrics.MongoPoolMonitor())
+
+	client, err = mongo.Connect(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
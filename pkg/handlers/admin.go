@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+// adminUsersQuery — фильтры списка пользователей
+type adminUsersQuery struct {
+	Query  string `query:"q" validate:"max=200"`
+	Banned string `query:"banned" validate:"omitempty,oneof=true false"`
+}
+
+// adminEmailsQuery — фильтры очереди писем
+type adminEmailsQuery struct {
+	Status string `query:"status" validate:"omitempty,oneof=pending sending sent dead"`
+	To     string `query:"to" validate:"omitempty,email"`
+}
+
+// emailPreviewQuery — язык предпросмотра шаблона
+type emailPreviewQuery struct {
+	Locale string `query:"locale" validate:"omitempty,oneof=ru en uk"`
+}
+
+// AdminHandler serves the /admin routes. Access is checked by
+// middleware.RequireAdmin.
+type AdminHandler struct {
+	adminService *services.AdminService
+	emailService *services.EmailService
+}
+
+func NewAdminHandler(adminService *services.AdminService, emailService *services.EmailService) *AdminHandler {
+	return &AdminHandler{adminService: adminService, emailService: emailService}
+}
+
+func (h *AdminHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	var q adminUsersQuery
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	page, err := getPageRequest(r, services.DefaultPageLimit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	filter := models.AdminUserFilter{Query: q.Query}
+	if q.Banned != "" {
+		banned := q.Banned == "true"
+		filter.Banned = &banned
+	}
+
+	users, err := h.adminService.ListUsers(r.Context(), filter, page)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: users})
+}
+
+func (h *AdminHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.adminService.GetUser(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user})
+}
+
+func (h *AdminHandler) BanUser(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.BanUserRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	user, err := h.adminService.BanUser(r.Context(), actorID, mux.Vars(r)["id"], req.Reason)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user, Message: "User banned"})
+}
+
+func (h *AdminHandler) UnbanUser(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	user, err := h.adminService.UnbanUser(r.Context(), actorID, mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user, Message: "User unbanned"})
+}
+
+func (h *AdminHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	user, err := h.adminService.VerifyEmail(r.Context(), actorID, mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user, Message: "Email verified"})
+}
+
+func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.adminService.Stats(r.Context())
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: stats})
+}
+
+func (h *AdminHandler) PurgeCache(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	if err := h.adminService.PurgeCache(r.Context(), actorID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Cache purged"})
+}
+
+func (h *AdminHandler) ListEmails(w http.ResponseWriter, r *http.Request) {
+	var q adminEmailsQuery
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	page, err := getPageRequest(r, services.DefaultPageLimit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	emails, err := h.adminService.ListEmails(r.Context(), models.OutboxFilter{Status: q.Status, To: q.To}, page)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: emails})
+}
+
+func (h *AdminHandler) GetEmail(w http.ResponseWriter, r *http.Request) {
+	email, err := h.adminService.GetEmail(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: email})
+}
+
+func (h *AdminHandler) RetryEmail(w http.ResponseWriter, r *http.Request) {
+	actorID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	email, err := h.adminService.RetryEmail(r.Context(), actorID, mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: email, Message: "Email requeued"})
+}
+
+func (h *AdminHandler) ListEmailTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: h.emailService.Templates()})
+}
+
+func (h *AdminHandler) PreviewEmailTemplate(w http.ResponseWriter, r *http.Request) {
+	var q emailPreviewQuery
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	preview, err := h.emailService.Preview(mux.Vars(r)["name"], q.Locale)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: preview})
+}
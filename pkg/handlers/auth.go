@@ -3,14 +3,16 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"time"
 	"strings"
+	"time"
 
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/middleware"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
+	"neomovies-api/pkg/validation"
 )
 
 type AuthHandler struct {
@@ -23,14 +25,23 @@ func NewAuthHandler(authService *services.AuthService) *AuthHandler {
 
 func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	var req models.RegisterRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if strings.EqualFold(strings.TrimSpace(req.Password), strings.TrimSpace(req.Email)) {
+		apperr.Write(w, r, validation.Fields(validation.FieldError{
+			Field:   "password",
+			Rule:    "password",
+			Message: "must not match the email",
+		}))
 		return
 	}
 
 	response, err := h.authService.Register(req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusConflict)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -41,18 +52,14 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	response, err := h.authService.Login(req)
+	response, err := h.authService.Login(r.Context(), req)
 	if err != nil {
-		statusCode := http.StatusBadRequest
-		if err.Error() == "Account not activated. Please verify your email." {
-			statusCode = http.StatusForbidden
-		}
-		http.Error(w, err.Error(), statusCode)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -60,82 +67,233 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: response, Message: "Login successful"})
 }
 
-func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
-	state := generateState()
-	http.SetCookie(w, &http.Cookie{Name: "oauth_state", Value: state, HttpOnly: true, Path: "/", Expires: time.Now().Add(10 * time.Minute)})
-	url, err := h.authService.GetGoogleLoginURL(state)
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	response, err := h.authService.RefreshTokens(r.Context(), req.RefreshToken)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: response, Message: "Token refreshed"})
+}
+
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	sessionID := middleware.GetSessionIDFromContext(r.Context())
+	tokenID := middleware.GetTokenIDFromContext(r.Context())
+
+	if err := h.authService.Logout(r.Context(), userID, sessionID, tokenID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Logged out successfully"})
+}
+
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	if err := h.authService.LogoutAll(r.Context(), userID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Logged out from all devices"})
+}
+
+// setOAuthStateCookie привязывает state к браузеру, начавшему вход или
+// привязку, чтобы коллбек нельзя было подсунуть чужому браузеру
+func setOAuthStateCookie(w http.ResponseWriter, state string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "oauth_state",
+		Value:    state,
+		HttpOnly: true,
+		Path:     "/",
+		Expires:  time.Now().Add(10 * time.Minute),
+		// Lax: cookie уходит при возврате с сайта провайдера
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// OAuthLogin перенаправляет на страницу согласия провайдера
+func (h *AuthHandler) OAuthLogin(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
+	url, state, err := h.authService.StartOAuth(r.Context(), provider, "")
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.Write(w, r, err)
 		return
 	}
+	setOAuthStateCookie(w, state)
 	http.Redirect(w, r, url, http.StatusFound)
 }
 
-func (h *AuthHandler) GoogleCallback(w http.ResponseWriter, r *http.Request) {
+// OAuthCallback завершает вход или привязку провайдера. Браузер получает
+// редирект на фронтенд, клиенты с Accept: application/json — JSON
+func (h *AuthHandler) OAuthCallback(w http.ResponseWriter, r *http.Request) {
+	provider := mux.Vars(r)["provider"]
 	q := r.URL.Query()
 	state := q.Get("state")
-	code := q.Get("code") 
+	code := q.Get("code")
 	preferJSON := q.Get("response") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json")
-	cookie, _ := r.Cookie("oauth_state")
-	if cookie == nil || cookie.Value != state || code == "" {
-		if preferJSON {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(models.APIResponse{Success: false, Message: "invalid oauth state"})
+
+	linkUserID, verifier, err := h.authService.ConsumeOAuthState(r.Context(), provider, state)
+	if err == nil {
+		// И вход, и привязка завершаются только в браузере, который их начал:
+		// иначе ссылку на чужую привязку можно подсунуть жертве
+		cookie, _ := r.Cookie("oauth_state")
+		if cookie == nil || cookie.Value != state {
+			err = services.ErrInvalidOAuthState
+		}
+	}
+	if err != nil {
+		h.oauthFailed(w, r, provider, "", preferJSON, "invalid_state", err)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: "oauth_state", Value: "", Path: "/", MaxAge: -1})
+
+	// Пользователь отказался или провайдер вернул ошибку
+	if q.Get("error") != "" || code == "" {
+		h.oauthFailed(w, r, provider, linkUserID, preferJSON, "auth_failed", services.ErrInvalidOAuthState)
+		return
+	}
+
+	result, err := h.authService.CompleteOAuth(r.Context(), provider, code, verifier, linkUserID)
+	if err != nil {
+		h.oauthFailed(w, r, provider, linkUserID, preferJSON, "auth_failed", err)
+		return
+	}
+
+	if result.Linked {
+		if redirectURL, ok := h.authService.OAuthLinkRedirect(provider, ""); ok && !preferJSON {
+			http.Redirect(w, r, redirectURL, http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: result.User, Message: "Provider linked"})
+		return
+	}
+
+	if !preferJSON {
+		redirectURL, ok, err := h.authService.OAuthLoginRedirect(r.Context(), provider, result.User)
+		if err != nil {
+			h.oauthFailed(w, r, provider, "", preferJSON, "auth_failed", err)
 			return
 		}
-		redirectURL, ok := h.authService.BuildFrontendRedirect("", "invalid_state")
 		if ok {
 			http.Redirect(w, r, redirectURL, http.StatusFound)
 			return
 		}
-		http.Error(w, "invalid oauth state", http.StatusBadRequest)
+	}
+
+	auth, err := h.authService.OAuthTokens(r.Context(), result.User)
+	if err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: auth, Message: "Login successful"})
+}
 
-	resp, err := h.authService.HandleGoogleCallback(r.Context(), code)
+// OAuthExchange обменивает одноразовый код из редиректа на фронтенд на токены
+func (h *AuthHandler) OAuthExchange(w http.ResponseWriter, r *http.Request) {
+	var req models.OAuthExchangeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	response, err := h.authService.ExchangeOAuthCode(r.Context(), req.Code)
 	if err != nil {
-		if preferJSON {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			json.NewEncoder(w).Encode(models.APIResponse{Success: false, Message: err.Error()})
-			return
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: response, Message: "Login successful"})
+}
+
+// oauthFailed отправляет браузер на фронтенд с кодом ошибки, а JSON-клиентам
+// и при ненастроенном FRONTEND_URL отдает саму ошибку
+func (h *AuthHandler) oauthFailed(w http.ResponseWriter, r *http.Request, provider, linkUserID string, preferJSON bool, reason string, err error) {
+	if !preferJSON {
+		redirectURL, ok := h.authService.OAuthLoginErrorRedirect(provider, reason)
+		if linkUserID != "" {
+			redirectURL, ok = h.authService.OAuthLinkRedirect(provider, reason)
 		}
-		redirectURL, ok := h.authService.BuildFrontendRedirect("", "auth_failed")
 		if ok {
 			http.Redirect(w, r, redirectURL, http.StatusFound)
 			return
 		}
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+	apperr.Write(w, r, err)
+}
+
+// LinkProvider возвращает URL согласия провайдера для привязки к текущему
+// аккаунту. State хранится на сервере вместе с ID пользователя и, как при
+// входе, кладется в cookie: запрос нужно делать с credentials
+func (h *AuthHandler) LinkProvider(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
-	if preferJSON {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: resp, Message: "Login successful"})
+	url, state, err := h.authService.StartOAuth(r.Context(), mux.Vars(r)["provider"], userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	setOAuthStateCookie(w, state)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: map[string]string{"url": url}})
+}
+
+func (h *AuthHandler) UnlinkProvider(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
-	redirectURL, ok := h.authService.BuildFrontendRedirect(resp.Token, "")
-	if ok {
-		http.Redirect(w, r, redirectURL, http.StatusFound)
+	user, err := h.authService.UnlinkProvider(r.Context(), userID, mux.Vars(r)["provider"])
+	if err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: resp, Message: "Login successful"})
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user, Message: "Provider unlinked"})
 }
 
 func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
 	user, err := h.authService.GetUserByID(userID)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		apperr.Write(w, r, services.ErrUserNotFound)
 		return
 	}
 
@@ -146,24 +304,19 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	var req models.UpdateProfileRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	delete(updates, "password")
-	delete(updates, "email")
-	delete(updates, "_id")
-	delete(updates, "created_at")
-
-	user, err := h.authService.UpdateUser(userID, bson.M(updates))
+	user, err := h.authService.UpdateProfile(r.Context(), userID, req)
 	if err != nil {
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -171,15 +324,84 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user, Message: "Profile updated successfully"})
 }
 
+func (h *AuthHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.ChangeEmailRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if err := h.authService.RequestEmailChange(r.Context(), userID, req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Confirmation code sent to the new email"})
+}
+
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.ConfirmEmailChangeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	user, err := h.authService.ConfirmEmailChange(r.Context(), userID, req)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: user, Message: "Email changed successfully"})
+}
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	// Текущая сессия остается активной, остальные завершаются
+	sessionID := middleware.GetSessionIDFromContext(r.Context())
+	if err := h.authService.ChangePassword(r.Context(), userID, sessionID, req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Password changed successfully"})
+}
+
 func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
 	if err := h.authService.DeleteAccount(r.Context(), userID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -189,14 +411,14 @@ func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 	var req models.VerifyEmailRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	response, err := h.authService.VerifyEmail(req)
+	response, err := h.authService.VerifyEmail(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -206,14 +428,14 @@ func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
 
 func (h *AuthHandler) ResendVerificationCode(w http.ResponseWriter, r *http.Request) {
 	var req models.ResendCodeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	response, err := h.authService.ResendVerificationCode(req)
+	response, err := h.authService.ResendVerificationCode(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -221,5 +443,34 @@ func (h *AuthHandler) ResendVerificationCode(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(response)
 }
 
-// helpers
-func generateState() string { return uuidNew() }
\ No newline at end of file
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if err := h.authService.RequestPasswordReset(r.Context(), req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "If the email is registered, a reset link has been sent"})
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	if err := h.authService.ResetPassword(r.Context(), req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Password has been reset"})
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
-	"github.com/google/uuid"
+	"neomovies-api/pkg/apperr"
 )
 
-func uuidNew() string { return uuid.New().String() }
\ No newline at end of file
+// errNoUserID возвращается защищенными обработчиками, если JWT middleware не
+// положил ID пользователя в контекст
+var errNoUserID = apperr.Unauthorized("User ID not found in context")
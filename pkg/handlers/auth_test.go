@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/oauth2"
+
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/oauth"
+	"neomovies-api/pkg/services"
+)
+
+// consentProvider только строит URL согласия: до обмена кода тесты не доходят
+type consentProvider struct{}
+
+func (consentProvider) Name() string { return "google" }
+
+func (consentProvider) AuthCodeURL(state, verifier string) string {
+	return "https://provider.test/auth?state=" + state
+}
+
+func (consentProvider) Exchange(context.Context, string, string) (*oauth2.Token, error) {
+	return nil, errors.New("not expected")
+}
+
+func (consentProvider) UserInfo(context.Context, *oauth2.Token) (*oauth.Identity, error) {
+	return nil, errors.New("not expected")
+}
+
+func newTestAuthHandler(mt *mtest.T) *AuthHandler {
+	sessions := services.NewSessionService(mt.DB, "secret")
+	return NewAuthHandler(services.NewAuthService(mt.DB, "secret", nil, sessions, services.NewAuditService(mt.DB),
+		"http://api.test", "http://app.test", oauth.NewRegistry(consentProvider{})))
+}
+
+func TestLinkProviderSetsStateCookie(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run("link", func(mt *mtest.T) {
+		mt.AddMockResponses(mtest.CreateSuccessResponse())
+		h := newTestAuthHandler(mt)
+
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/google/link", nil)
+		r = mux.SetURLVars(r, map[string]string{"provider": "google"})
+		r = r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, "user-1"))
+		w := httptest.NewRecorder()
+		h.LinkProvider(w, r)
+
+		if w.Code != http.StatusOK {
+			mt.Fatalf("status = %d, body = %s", w.Code, w.Body)
+		}
+		cookies := w.Result().Cookies()
+		if len(cookies) != 1 || cookies[0].Name != "oauth_state" || cookies[0].Value == "" || !cookies[0].HttpOnly {
+			mt.Fatalf("cookies = %v", cookies)
+		}
+	})
+}
+
+func TestOAuthCallbackChecksStateCookie(t *testing.T) {
+	tests := []struct {
+		name   string
+		link   bool
+		cookie string
+		// Куда ведет редирект на фронтенд
+		path string
+	}{
+		// Ссылку на привязку, начатую злоумышленником, нельзя подсунуть
+		// жертве; непроверенному state не доверяется и ID пользователя
+		{"link without cookie", true, "", "/login"},
+		{"link with another cookie", true, "other-state", "/login"},
+		{"login without cookie", false, "", "/login"},
+		{"link", true, "state-1", "/profile"},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			pending := bson.D{
+				{Key: "_id", Value: "hash"},
+				{Key: "provider", Value: "google"},
+				{Key: "verifier", Value: "verifier-1"},
+				{Key: "expiresAt", Value: time.Now().Add(time.Minute)},
+			}
+			if tt.link {
+				pending = append(pending, bson.E{Key: "userId", Value: "user-1"})
+			}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: pending}))
+			h := newTestAuthHandler(mt)
+
+			// Пользователь отказался у провайдера: до обмена кода дело не доходит
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/auth/google/callback?state=state-1&error=access_denied", nil)
+			r = mux.SetURLVars(r, map[string]string{"provider": "google"})
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: "oauth_state", Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			h.OAuthCallback(w, r)
+
+			if w.Code != http.StatusFound {
+				mt.Fatalf("status = %d, body = %s", w.Code, w.Body)
+			}
+			location, _ := url.Parse(w.Header().Get("Location"))
+			if location.Path != tt.path {
+				mt.Errorf("redirect = %s", location)
+			}
+			want := "auth_failed"
+			if tt.cookie != "state-1" {
+				want = "invalid_state"
+			}
+			if got := location.Query().Get("error"); got != want {
+				mt.Errorf("error = %q, want %q", got, want)
+			}
+		})
+	}
+}
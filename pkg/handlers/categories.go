@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
 )
@@ -31,7 +32,7 @@ func (h *CategoriesHandler) GetCategories(w http.ResponseWriter, r *http.Request
 	// Получаем все жанры
 	genresResponse, err := h.tmdbService.GetAllGenres()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -57,14 +58,17 @@ func (h *CategoriesHandler) GetMediaByCategory(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	categoryID, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid category ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid category ID"))
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	if language == "" {
-		language = "ru-RU"
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	if q.Language == "" {
+		q.Language = "ru-RU"
 	}
 
 	mediaType := r.URL.Query().Get("type")
@@ -73,7 +77,7 @@ func (h *CategoriesHandler) GetMediaByCategory(w http.ResponseWriter, r *http.Re
 	}
 
 	if mediaType != "movie" && mediaType != "tv" {
-		http.Error(w, "Media type must be 'movie' or 'tv'", http.StatusBadRequest)
+		apperr.Write(w, r, services.ErrInvalidMediaType)
 		return
 	}
 
@@ -82,14 +86,14 @@ func (h *CategoriesHandler) GetMediaByCategory(w http.ResponseWriter, r *http.Re
 
 	if mediaType == "movie" {
 		// Используем discover API для получения фильмов по жанру
-		data, err2 = h.tmdbService.DiscoverMoviesByGenre(categoryID, page, language)
+		data, err2 = h.tmdbService.DiscoverMoviesByGenre(categoryID, q.Page, q.Language)
 	} else {
 		// Используем discover API для получения сериалов по жанру
-		data, err2 = h.tmdbService.DiscoverTVByGenre(categoryID, page, language)
+		data, err2 = h.tmdbService.DiscoverTVByGenre(categoryID, q.Page, q.Language)
 	}
 
 	if err2 != nil {
-		http.Error(w, err2.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err2)
 		return
 	}
 
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+var (
+	errCronDisabled     = apperr.NotFound("not found")
+	errCronUnauthorized = apperr.Unauthorized("invalid cron secret").WithCode("invalid_cron_secret")
+)
+
+// CronHandler runs periodic jobs on request of an external scheduler, for
+// deployments without a long-running process (Vercel Cron sends
+// Authorization: Bearer CRON_SECRET). Without a secret the routes are off.
+type CronHandler struct {
+	outbox *services.OutboxService
+	secret string
+}
+
+func NewCronHandler(outbox *services.OutboxService, secret string) *CronHandler {
+	return &CronHandler{outbox: outbox, secret: secret}
+}
+
+// ProcessOutbox повторяет отправку писем, у которых подошло время попытки
+func (h *CronHandler) ProcessOutbox(w http.ResponseWriter, r *http.Request) {
+	if h.secret == "" {
+		apperr.Write(w, r, errCronDisabled)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.secret)) != 1 {
+		apperr.Write(w, r, errCronUnauthorized)
+		return
+	}
+
+	processed, err := h.outbox.ProcessDue(r.Context())
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: map[string]int{"processed": processed}})
+}
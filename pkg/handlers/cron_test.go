@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/services"
+)
+
+type nopMailer struct{}
+
+func (nopMailer) Send(context.Context, *mail.Message) error { return nil }
+
+func TestCronProcessOutbox(t *testing.T) {
+	tests := []struct {
+		name          string
+		secret        string
+		authorization string
+		status        int
+	}{
+		{"disabled", "", "Bearer ", http.StatusNotFound},
+		{"no token", "cron-secret", "", http.StatusUnauthorized},
+		{"wrong token", "cron-secret", "Bearer other", http.StatusUnauthorized},
+		{"authorized", "cron-secret", "Bearer cron-secret", http.StatusOK},
+	}
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			// Писем к отправке нет
+			mt.AddMockResponses(mtest.CreateSuccessResponse(bson.E{Key: "value", Value: nil}))
+			h := NewCronHandler(services.NewOutboxService(mt.DB, nopMailer{}), tt.secret)
+
+			r := httptest.NewRequest(http.MethodGet, "/cron/outbox", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			h.ProcessOutbox(w, r)
+
+			if w.Code != tt.status {
+				mt.Fatalf("status = %d, body = %s", w.Code, w.Body)
+			}
+			polled := false
+			for _, e := range mt.GetAllStartedEvents() {
+				polled = polled || e.CommandName == "findAndModify"
+			}
+			if polled != (tt.status == http.StatusOK) {
+				mt.Errorf("outbox polled = %v", polled)
+			}
+		})
+	}
+}
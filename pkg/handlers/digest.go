@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+type DigestHandler struct {
+	digestService *services.DigestService
+}
+
+func NewDigestHandler(digestService *services.DigestService) *DigestHandler {
+	return &DigestHandler{digestService: digestService}
+}
+
+func (h *DigestHandler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	subscription, err := h.digestService.Subscription(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: subscription})
+}
+
+func (h *DigestHandler) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.DigestSubscriptionRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	subscription, err := h.digestService.SetSubscribed(r.Context(), userID, *req.Subscribed)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: subscription})
+}
+
+func (h *DigestHandler) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	token, err := digestToken(w, r)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	resubscribeToken, err := h.digestService.Unsubscribe(r.Context(), token)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Message: "Unsubscribed",
+		Data:    models.DigestUnsubscribeResponse{ResubscribeToken: resubscribeToken},
+	})
+}
+
+func (h *DigestHandler) Resubscribe(w http.ResponseWriter, r *http.Request) {
+	token, err := digestToken(w, r)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	if err := h.digestService.Resubscribe(r.Context(), token); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Subscribed"})
+}
+
+// digestToken берет токен из параметра token или из JSON-тела. Почтовые
+// клиенты при отписке в один клик (RFC 8058) шлют POST на URL из
+// List-Unsubscribe с телом формы, поэтому параметр проверяется первым
+func digestToken(w http.ResponseWriter, r *http.Request) (string, error) {
+	if token := r.URL.Query().Get("token"); token != "" {
+		return token, nil
+	}
+	var req models.DigestTokenRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		return "", err
+	}
+	return req.Token, nil
+}
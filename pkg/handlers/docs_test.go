@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/openapi"
+)
+
+func TestGetOpenAPISpec(t *testing.T) {
+	spec := openapi.NewSpec(openapi.Info{Title: "test"}, models.APIResponse{}, models.ErrorResponse{})
+	h := NewDocsHandler(spec)
+
+	// До Build документа нет: ответ в общем формате ошибок
+	rec := httptest.NewRecorder()
+	h.GetOpenAPISpec(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("body is not JSON: %s", rec.Body.String())
+	}
+	if rec.Code != http.StatusServiceUnavailable || resp.Code != apperr.CodeUnavailable {
+		t.Fatalf("status = %d, response = %+v", rec.Code, resp)
+	}
+
+	if _, err := spec.Build(mux.NewRouter()); err != nil {
+		t.Fatal(err)
+	}
+	rec = httptest.NewRecorder()
+	h.GetOpenAPISpec(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	var doc openapi.Document
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || doc.OpenAPI != openapi.Version {
+		t.Fatalf("status = %d, document = %+v, err = %v", rec.Code, doc, err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/middleware"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
@@ -24,13 +25,19 @@ func NewFavoritesHandler(favoritesService *services.FavoritesService) *Favorites
 func (h *FavoritesHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
-	favorites, err := h.favoritesService.GetFavorites(userID)
+	page, err := getPageRequest(r, services.DefaultPageLimit)
 	if err != nil {
-		http.Error(w, "Failed to get favorites: "+err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	favorites, err := h.favoritesService.GetFavorites(r.Context(), userID, page)
+	if err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -45,7 +52,7 @@ func (h *FavoritesHandler) GetFavorites(w http.ResponseWriter, r *http.Request)
 func (h *FavoritesHandler) AddToFavorites(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
@@ -54,7 +61,7 @@ func (h *FavoritesHandler) AddToFavorites(w http.ResponseWriter, r *http.Request
 	mediaType := r.URL.Query().Get("type")
 	
 	if mediaID == "" {
-		http.Error(w, "Media ID is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media ID is required"))
 		return
 	}
 	
@@ -63,13 +70,13 @@ func (h *FavoritesHandler) AddToFavorites(w http.ResponseWriter, r *http.Request
 	}
 	
 	if mediaType != "movie" && mediaType != "tv" {
-		http.Error(w, "Media type must be 'movie' or 'tv'", http.StatusBadRequest)
+		apperr.Write(w, r, services.ErrInvalidMediaType)
 		return
 	}
 
 	err := h.favoritesService.AddToFavorites(userID, mediaID, mediaType)
 	if err != nil {
-		http.Error(w, "Failed to add to favorites: "+err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -83,7 +90,7 @@ func (h *FavoritesHandler) AddToFavorites(w http.ResponseWriter, r *http.Request
 func (h *FavoritesHandler) RemoveFromFavorites(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
@@ -92,7 +99,7 @@ func (h *FavoritesHandler) RemoveFromFavorites(w http.ResponseWriter, r *http.Re
 	mediaType := r.URL.Query().Get("type")
 	
 	if mediaID == "" {
-		http.Error(w, "Media ID is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media ID is required"))
 		return
 	}
 	
@@ -101,13 +108,13 @@ func (h *FavoritesHandler) RemoveFromFavorites(w http.ResponseWriter, r *http.Re
 	}
 	
 	if mediaType != "movie" && mediaType != "tv" {
-		http.Error(w, "Media type must be 'movie' or 'tv'", http.StatusBadRequest)
+		apperr.Write(w, r, services.ErrInvalidMediaType)
 		return
 	}
 
 	err := h.favoritesService.RemoveFromFavorites(userID, mediaID, mediaType)
 	if err != nil {
-		http.Error(w, "Failed to remove from favorites: "+err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -121,7 +128,7 @@ func (h *FavoritesHandler) RemoveFromFavorites(w http.ResponseWriter, r *http.Re
 func (h *FavoritesHandler) CheckIsFavorite(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
@@ -130,7 +137,7 @@ func (h *FavoritesHandler) CheckIsFavorite(w http.ResponseWriter, r *http.Reques
 	mediaType := r.URL.Query().Get("type")
 	
 	if mediaID == "" {
-		http.Error(w, "Media ID is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media ID is required"))
 		return
 	}
 	
@@ -139,13 +146,13 @@ func (h *FavoritesHandler) CheckIsFavorite(w http.ResponseWriter, r *http.Reques
 	}
 	
 	if mediaType != "movie" && mediaType != "tv" {
-		http.Error(w, "Media type must be 'movie' or 'tv'", http.StatusBadRequest)
+		apperr.Write(w, r, services.ErrInvalidMediaType)
 		return
 	}
 
 	isFavorite, err := h.favoritesService.IsFavorite(userID, mediaID, mediaType)
 	if err != nil {
-		http.Error(w, "Failed to check favorite status: "+err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+type FollowsHandler struct {
+	followsService *services.FollowsService
+}
+
+func NewFollowsHandler(followsService *services.FollowsService) *FollowsHandler {
+	return &FollowsHandler{followsService: followsService}
+}
+
+func (h *FollowsHandler) GetFollows(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	page, err := getPageRequest(r, services.DefaultPageLimit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	follows, err := h.followsService.List(r.Context(), userID, page)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: follows})
+}
+
+func (h *FollowsHandler) Follow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	vars := mux.Vars(r)
+	follow, err := h.followsService.Follow(r.Context(), userID, vars["mediaType"], vars["mediaId"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: follow, Message: "Following"})
+}
+
+func (h *FollowsHandler) Unfollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := h.followsService.Unfollow(r.Context(), userID, vars["mediaType"], vars["mediaId"]); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Unfollowed"})
+}
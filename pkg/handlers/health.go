@@ -1,19 +1,95 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"time"
 
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/database"
+	"neomovies-api/pkg/health"
 	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
 )
 
+var startTime = time.Now()
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+// NewHealthHandler создает обработчик проверок состояния. MongoDB и TMDB
+// критичны для работы API, RedAPI — нет: без него отваливается только
+// поиск торрентов.
+func NewHealthHandler(tmdbService *services.TMDBService, torrentService *services.TorrentService) *HealthHandler {
+	checker := health.NewChecker(5*time.Second,
+		health.Check{Name: "mongodb", Critical: true, Timeout: 2 * time.Second, Probe: pingMongo},
+		health.Check{Name: "tmdb", Critical: true, Timeout: 3 * time.Second, Probe: tmdbService.Ping},
+		health.Check{Name: "redapi", Critical: false, Timeout: 3 * time.Second, Probe: torrentService.Ping},
+	)
+	return &HealthHandler{checker: checker}
+}
+
+func pingMongo(ctx context.Context) error {
+	client := database.GetClient()
+	if client == nil {
+		return errors.New("not connected")
+	}
+	return client.Ping(ctx, readpref.Primary())
+}
+
+// Live сообщает, что процесс жив. Зависимости не проверяются
+func (h *HealthHandler) Live(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": health.StatusOK,
+		"build":  buildInfo(),
+		"uptime": time.Since(startTime).Round(time.Second).String(),
+	})
+}
+
+// Ready проверяет зависимости и возвращает 503, если недоступна критичная
+func (h *HealthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+
+	report = report.Public()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    report.Status,
+		"checkedAt": report.CheckedAt,
+		"checks":    report.Checks,
+		"build":     buildInfo(),
+	})
+}
+
+// ReadyDetails возвращает тот же отчет вместе с ошибками проверок.
+// Ошибки содержат адреса и сообщения драйверов, поэтому маршрут только для админов
+func (h *HealthHandler) ReadyDetails(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: report})
+}
+
 func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	health := map[string]interface{}{
 		"status":    "OK",
 		"timestamp": time.Now().UTC(),
 		"service":   "neomovies-api",
-		"version":   "2.0.0",
+		"version":   config.Version,
+		"commit":    config.Commit,
 		"uptime":    time.Since(startTime),
 	}
 
@@ -26,4 +102,11 @@ func HealthCheck(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-var startTime = time.Now()
\ No newline at end of file
+func buildInfo() map[string]string {
+	return map[string]string{
+		"service":   "neomovies-api",
+		"version":   config.Version,
+		"commit":    config.Commit,
+		"buildTime": config.BuildTime,
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neomovies-api/pkg/health"
+)
+
+func TestReadyHidesProbeErrors(t *testing.T) {
+	h := &HealthHandler{checker: health.NewChecker(0,
+		health.Check{Name: "mongodb", Critical: true, Probe: func(context.Context) error {
+			return errors.New("server selection error: mongo.internal:27017")
+		}},
+		health.Check{Name: "redapi", Probe: func(context.Context) error { return nil }},
+	)}
+
+	rec := httptest.NewRecorder()
+	h.Ready(rec, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "mongo.internal") || strings.Contains(body, `"error"`) {
+		t.Errorf("public response leaks the probe error: %s", body)
+	}
+	var resp struct {
+		Status string                        `json:"status"`
+		Checks map[string]health.CheckResult `json:"checks"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != health.StatusFail || resp.Checks["mongodb"].Status != health.StatusFail || resp.Checks["redapi"].Status != health.StatusOK {
+		t.Errorf("response = %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ReadyDetails(rec, httptest.NewRequest(http.MethodGet, "/api/v1/admin/health", nil))
+	if !strings.Contains(rec.Body.String(), "mongo.internal") {
+		t.Errorf("admin report has no probe error: %s", rec.Body)
+	}
+}
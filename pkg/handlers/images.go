@@ -7,14 +7,21 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/metrics"
 )
 
-type ImagesHandler struct{}
+type ImagesHandler struct {
+	client *http.Client
+}
 
-func NewImagesHandler() *ImagesHandler { return &ImagesHandler{} }
+func NewImagesHandler() *ImagesHandler {
+	return &ImagesHandler{client: metrics.NewClient("tmdb_images", 30*time.Second)}
+}
 
 func (h *ImagesHandler) GetImage(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -22,7 +29,7 @@ func (h *ImagesHandler) GetImage(w http.ResponseWriter, r *http.Request) {
 	imagePath := vars["path"]
 
 	if size == "" || imagePath == "" {
-		http.Error(w, "Size and path are required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Size and path are required"))
 		return
 	}
 
@@ -38,7 +45,7 @@ func (h *ImagesHandler) GetImage(w http.ResponseWriter, r *http.Request) {
 
 	imageURL := fmt.Sprintf("%s/%s/%s", config.TMDBImageBaseURL, size, imagePath)
 
-	resp, err := http.Get(imageURL)
+	resp, err := h.client.Get(imageURL)
 	if err != nil {
 		h.servePlaceholder(w, r)
 		return
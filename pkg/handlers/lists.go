@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+type ListsHandler struct {
+	listsService *services.ListsService
+}
+
+func NewListsHandler(listsService *services.ListsService) *ListsHandler {
+	return &ListsHandler{
+		listsService: listsService,
+	}
+}
+
+func (h *ListsHandler) GetLists(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	lists, err := h.listsService.GetUserLists(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    lists,
+	})
+}
+
+func (h *ListsHandler) CreateList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.CreateListRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	list, err := h.listsService.CreateList(r.Context(), userID, req)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+		Message: "List created successfully",
+	})
+}
+
+func (h *ListsHandler) GetList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	list, err := h.listsService.GetList(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+	})
+}
+
+func (h *ListsHandler) GetPublicList(w http.ResponseWriter, r *http.Request) {
+	list, err := h.listsService.GetPublicList(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	// Владельца публичного списка не раскрываем
+	list.UserID = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+	})
+}
+
+func (h *ListsHandler) UpdateList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.UpdateListRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	list, err := h.listsService.UpdateList(r.Context(), userID, mux.Vars(r)["id"], req)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+		Message: "List updated successfully",
+	})
+}
+
+func (h *ListsHandler) DeleteList(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	if err := h.listsService.DeleteList(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Message: "List deleted successfully",
+	})
+}
+
+func (h *ListsHandler) AddItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.ListItemRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	list, err := h.listsService.AddItem(r.Context(), userID, mux.Vars(r)["id"], req)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+		Message: "Item added to list",
+	})
+}
+
+func (h *ListsHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	vars := mux.Vars(r)
+	list, err := h.listsService.RemoveItem(r.Context(), userID, vars["id"], vars["mediaType"], vars["mediaId"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+		Message: "Item removed from list",
+	})
+}
+
+func (h *ListsHandler) ReorderItems(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.ReorderListRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	list, err := h.listsService.ReorderItems(r.Context(), userID, mux.Vars(r)["id"], req.Items)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    list,
+		Message: "List reordered",
+	})
+}
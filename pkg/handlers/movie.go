@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
 )
@@ -22,20 +23,15 @@ func NewMovieHandler(movieService *services.MovieService) *MovieHandler {
 }
 
 func (h *MovieHandler) Search(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+	q := searchQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	region := r.URL.Query().Get("region")
-	year := getIntQuery(r, "year", 0)
-
-	movies, err := h.movieService.Search(query, page, language, region, year)
+	movies, err := h.movieService.Search(q.Query, q.Page, q.Language, q.Region, q.Year)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -50,7 +46,7 @@ func (h *MovieHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid movie ID"))
 		return
 	}
 
@@ -58,7 +54,7 @@ func (h *MovieHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	movie, err := h.movieService.GetByID(id, language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -70,13 +66,15 @@ func (h *MovieHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MovieHandler) Popular(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	region := r.URL.Query().Get("region")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	movies, err := h.movieService.GetPopular(page, language, region)
+	movies, err := h.movieService.GetPopular(q.Page, q.Language, q.Region)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -88,13 +86,15 @@ func (h *MovieHandler) Popular(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MovieHandler) TopRated(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	region := r.URL.Query().Get("region")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	movies, err := h.movieService.GetTopRated(page, language, region)
+	movies, err := h.movieService.GetTopRated(q.Page, q.Language, q.Region)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -106,13 +106,15 @@ func (h *MovieHandler) TopRated(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MovieHandler) Upcoming(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	region := r.URL.Query().Get("region")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	movies, err := h.movieService.GetUpcoming(page, language, region)
+	movies, err := h.movieService.GetUpcoming(q.Page, q.Language, q.Region)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -124,13 +126,15 @@ func (h *MovieHandler) Upcoming(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *MovieHandler) NowPlaying(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	region := r.URL.Query().Get("region")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	movies, err := h.movieService.GetNowPlaying(page, language, region)
+	movies, err := h.movieService.GetNowPlaying(q.Page, q.Language, q.Region)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -145,16 +149,19 @@ func (h *MovieHandler) GetRecommendations(w http.ResponseWriter, r *http.Request
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid movie ID"))
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	movies, err := h.movieService.GetRecommendations(id, page, language)
+	movies, err := h.movieService.GetRecommendations(id, q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -169,16 +176,19 @@ func (h *MovieHandler) GetSimilar(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid movie ID"))
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	movies, err := h.movieService.GetSimilar(id, page, language)
+	movies, err := h.movieService.GetSimilar(id, q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -195,13 +205,13 @@ func (h *MovieHandler) GetExternalIDs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid movie ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid movie ID"))
 		return
 	}
 
 	externalIDs, err := h.movieService.GetExternalIDs(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -211,17 +221,3 @@ func (h *MovieHandler) GetExternalIDs(w http.ResponseWriter, r *http.Request) {
 		Data:    externalIDs,
 	})
 }
-
-func getIntQuery(r *http.Request, key string, defaultValue int) int {
-	str := r.URL.Query().Get(key)
-	if str == "" {
-		return defaultValue
-	}
-	
-	value, err := strconv.Atoi(str)
-	if err != nil {
-		return defaultValue
-	}
-	
-	return value
-}
\ No newline at end of file
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+// notificationsQuery — фильтр списка уведомлений
+type notificationsQuery struct {
+	Unread bool `query:"unread"`
+}
+
+type NotificationsHandler struct {
+	notificationsService *services.NotificationsService
+}
+
+func NewNotificationsHandler(notificationsService *services.NotificationsService) *NotificationsHandler {
+	return &NotificationsHandler{notificationsService: notificationsService}
+}
+
+func (h *NotificationsHandler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var q notificationsQuery
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+	page, err := getPageRequest(r, services.DefaultPageLimit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	notifications, err := h.notificationsService.List(r.Context(), userID, q.Unread, page)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: notifications})
+}
+
+func (h *NotificationsHandler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	count, err := h.notificationsService.UnreadCount(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: map[string]int64{"unread": count}})
+}
+
+func (h *NotificationsHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	notification, err := h.notificationsService.MarkRead(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: notification})
+}
+
+func (h *NotificationsHandler) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	count, err := h.notificationsService.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: map[string]int64{"updated": count}})
+}
+
+func (h *NotificationsHandler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	if err := h.notificationsService.Delete(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Message: "Notification deleted"})
+}
+
+func (h *NotificationsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	settings, err := h.notificationsService.Settings(r.Context(), userID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: settings})
+}
+
+func (h *NotificationsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.NotificationSettingsRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	settings, err := h.notificationsService.UpdateSettings(r.Context(), userID, *req.Email)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{Success: true, Data: settings})
+}
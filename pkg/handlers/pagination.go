@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+
+	"neomovies-api/pkg/models"
+)
+
+// pageQuery — параметры курсорной пагинации. Допустимые поля сортировки
+// зависят от коллекции и проверяются сервисом
+type pageQuery struct {
+	Cursor    string `query:"cursor" validate:"max=512"`
+	Limit     int    `query:"limit" validate:"min=1,max=100"`
+	SortBy    string `query:"sort"`
+	Order     string `query:"order" validate:"omitempty,oneof=asc desc"`
+	MediaType string `query:"mediaType" validate:"omitempty,oneof=movie tv"`
+}
+
+// getPageRequest читает параметры пагинации: cursor, limit, sort, order, mediaType
+func getPageRequest(r *http.Request, defaultLimit int) (models.PageRequest, error) {
+	q := pageQuery{Limit: defaultLimit}
+	if err := decodeQuery(r, &q); err != nil {
+		return models.PageRequest{}, err
+	}
+	return models.PageRequest{
+		Cursor:    q.Cursor,
+		Limit:     q.Limit,
+		SortBy:    q.SortBy,
+		Order:     q.Order,
+		MediaType: q.MediaType,
+	}, nil
+}
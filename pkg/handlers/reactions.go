@@ -6,6 +6,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/middleware"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
@@ -25,13 +26,13 @@ func (h *ReactionsHandler) GetReactionCounts(w http.ResponseWriter, r *http.Requ
 	mediaID := vars["mediaId"]
 
 	if mediaType == "" || mediaID == "" {
-		http.Error(w, "Media type and ID are required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media type and ID are required"))
 		return
 	}
 
 	counts, err := h.reactionsService.GetReactionCounts(mediaType, mediaID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -42,7 +43,7 @@ func (h *ReactionsHandler) GetReactionCounts(w http.ResponseWriter, r *http.Requ
 func (h *ReactionsHandler) GetMyReaction(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
@@ -51,13 +52,13 @@ func (h *ReactionsHandler) GetMyReaction(w http.ResponseWriter, r *http.Request)
 	mediaID := vars["mediaId"]
 
 	if mediaType == "" || mediaID == "" {
-		http.Error(w, "Media type and ID are required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media type and ID are required"))
 		return
 	}
 
 	reactionType, err := h.reactionsService.GetMyReaction(userID, mediaType, mediaID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -72,7 +73,7 @@ func (h *ReactionsHandler) GetMyReaction(w http.ResponseWriter, r *http.Request)
 func (h *ReactionsHandler) SetReaction(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
@@ -81,22 +82,20 @@ func (h *ReactionsHandler) SetReaction(w http.ResponseWriter, r *http.Request) {
 	mediaID := vars["mediaId"]
 
 	if mediaType == "" || mediaID == "" {
-		http.Error(w, "Media type and ID are required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media type and ID are required"))
 		return
 	}
 
-	var request struct{ Type string `json:"type"` }
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
-		return
+	var request struct {
+		Type string `json:"type" validate:"required"`
 	}
-	if request.Type == "" {
-		http.Error(w, "Reaction type is required", http.StatusBadRequest)
+	if err := decodeJSON(w, r, &request); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
 	if err := h.reactionsService.SetReaction(userID, mediaType, mediaID, request.Type); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -107,7 +106,7 @@ func (h *ReactionsHandler) SetReaction(w http.ResponseWriter, r *http.Request) {
 func (h *ReactionsHandler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
@@ -116,12 +115,12 @@ func (h *ReactionsHandler) RemoveReaction(w http.ResponseWriter, r *http.Request
 	mediaID := vars["mediaId"]
 
 	if mediaType == "" || mediaID == "" {
-		http.Error(w, "Media type and ID are required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Media type and ID are required"))
 		return
 	}
 
 	if err := h.reactionsService.RemoveReaction(userID, mediaType, mediaID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -132,15 +131,19 @@ func (h *ReactionsHandler) RemoveReaction(w http.ResponseWriter, r *http.Request
 func (h *ReactionsHandler) GetMyReactions(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "User ID not found in context", http.StatusInternalServerError)
+		apperr.Write(w, r, errNoUserID)
 		return
 	}
 
-	limit := getIntQuery(r, "limit", 50)
+	page, err := getPageRequest(r, 50)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	reactions, err := h.reactionsService.GetUserReactions(userID, limit)
+	reactions, err := h.reactionsService.GetUserReactions(r.Context(), userID, page)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
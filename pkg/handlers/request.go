@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/validation"
+)
+
+// maxBodyBytes ограничивает размер JSON-тела запроса
+const maxBodyBytes = 1 << 20
+
+// tmdbQuery — общие параметры списков, проксируемых из TMDB. TMDB отдает
+// не больше 500 страниц
+type tmdbQuery struct {
+	Page     int    `query:"page" validate:"min=1,max=500"`
+	Language string `query:"language" validate:"max=16"`
+	Region   string `query:"region" validate:"omitempty,len=2"`
+}
+
+// searchQuery — параметры поиска по названию
+type searchQuery struct {
+	Query            string `query:"query" validate:"required,max=200"`
+	Page             int    `query:"page" validate:"min=1,max=500"`
+	Language         string `query:"language" validate:"max=16"`
+	Region           string `query:"region" validate:"omitempty,len=2"`
+	Year             int    `query:"year" validate:"min=0,max=2100"`
+	FirstAirDateYear int    `query:"first_air_date_year" validate:"min=0,max=2100"`
+}
+
+// limitQuery — размер выдачи для списков без курсора
+type limitQuery struct {
+	Limit int `query:"limit" validate:"min=1,max=100"`
+}
+
+// decodeJSON читает тело запроса в dst и проверяет теги validate.
+// Несовпадение типа поля сообщается как ошибка этого поля
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err := dec.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		var maxErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &typeErr) && typeErr.Field != "":
+			return validation.Fields(validation.FieldError{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Param:   typeErr.Type.String(),
+				Message: "must be of type " + jsonTypeName(typeErr.Type),
+			})
+		case errors.As(err, &maxErr):
+			return apperr.New(http.StatusRequestEntityTooLarge, "body_too_large", "Request body is too large")
+		case errors.Is(err, io.EOF):
+			return apperr.ErrInvalidBody
+		default:
+			return apperr.ErrInvalidBody.WithCause(err)
+		}
+	}
+	return validation.Struct(dst)
+}
+
+// decodeQuery заполняет поля dst с тегом query из параметров URL и проверяет
+// теги validate. Значения по умолчанию задаются в dst до вызова
+func decodeQuery(r *http.Request, dst interface{}) error {
+	query := r.URL.Query()
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+
+	var fields []validation.FieldError
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("query")
+		raw := strings.TrimSpace(query.Get(name))
+		if name == "" || raw == "" {
+			continue
+		}
+
+		field := v.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				fields = append(fields, validation.FieldError{Field: name, Rule: "type", Param: "int", Message: "must be an integer"})
+				continue
+			}
+			field.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				fields = append(fields, validation.FieldError{Field: name, Rule: "type", Param: "bool", Message: "must be true or false"})
+				continue
+			}
+			field.SetBool(b)
+		}
+	}
+	if len(fields) > 0 {
+		return validation.Fields(fields...)
+	}
+	return validation.Struct(dst)
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
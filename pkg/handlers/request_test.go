@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/validation"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   string
+		field  string
+	}{
+		{"valid", `{"mediaId":"550","mediaType":"movie"}`, 0, "", ""},
+		{"empty body", ``, http.StatusBadRequest, "invalid_body", ""},
+		{"malformed", `{"mediaId":`, http.StatusBadRequest, "invalid_body", ""},
+		{"wrong type", `{"mediaId":550,"mediaType":"movie"}`, http.StatusBadRequest, apperr.CodeValidation, "mediaId"},
+		{"failed rule", `{"mediaId":"550","mediaType":"book"}`, http.StatusBadRequest, apperr.CodeValidation, "mediaType"},
+		{"too large", `{"mediaId":"` + strings.Repeat("1", maxBodyBytes) + `"}`, http.StatusRequestEntityTooLarge, "body_too_large", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			var req models.ListItemRequest
+			err := decodeJSON(httptest.NewRecorder(), r, &req)
+			if tt.status == 0 {
+				if err != nil || req.MediaID != "550" {
+					t.Fatalf("req = %+v, err = %v", req, err)
+				}
+				return
+			}
+			e := apperr.From(err)
+			if e.Status != tt.status || e.Code != tt.code {
+				t.Fatalf("err = %d %s, want %d %s", e.Status, e.Code, tt.status, tt.code)
+			}
+			if tt.field != "" {
+				if fields, _ := e.Details.([]validation.FieldError); len(fields) != 1 || fields[0].Field != tt.field {
+					t.Errorf("details = %+v", e.Details)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		want   tmdbQuery
+		fields []string
+	}{
+		// Значения по умолчанию сохраняются
+		{"defaults", "", tmdbQuery{Page: 1, Language: "ru-RU"}, nil},
+		{"values", "page=3&language=en-US&region=US", tmdbQuery{Page: 3, Language: "en-US", Region: "US"}, nil},
+		{"not a number", "page=two", tmdbQuery{}, []string{"page"}},
+		{"out of range", "page=501&region=USA", tmdbQuery{}, []string{"page", "region"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := tmdbQuery{Page: 1, Language: "ru-RU"}
+			err := decodeQuery(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil), &q)
+			if tt.fields == nil {
+				if err != nil || q != tt.want {
+					t.Fatalf("query = %+v, err = %v", q, err)
+				}
+				return
+			}
+			fields, _ := apperr.From(err).Details.([]validation.FieldError)
+			var got []string
+			for _, f := range fields {
+				got = append(got, f.Field)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.fields, ",") {
+				t.Errorf("invalid fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+}
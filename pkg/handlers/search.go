@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
 )
@@ -19,21 +20,18 @@ func NewSearchHandler(tmdbService *services.TMDBService) *SearchHandler {
 }
 
 func (h *SearchHandler) MultiSearch(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+	q := searchQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
-
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	if language == "" {
-		language = "ru-RU"
+	if q.Language == "" {
+		q.Language = "ru-RU"
 	}
 
-	results, err := h.tmdbService.SearchMulti(query, page, language)
+	results, err := h.tmdbService.SearchMulti(q.Query, q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -8,6 +8,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
 )
@@ -30,7 +31,7 @@ func (h *TorrentsHandler) SearchTorrents(w http.ResponseWriter, r *http.Request)
 	imdbID := vars["imdbId"]
 
 	if imdbID == "" {
-		http.Error(w, "IMDB ID is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("IMDB ID is required"))
 		return
 	}
 
@@ -103,7 +104,7 @@ func (h *TorrentsHandler) SearchTorrents(w http.ResponseWriter, r *http.Request)
 	// Поиск торрентов
 	results, err := h.torrentService.SearchTorrentsByIMDbID(h.tmdbService, imdbID, mediaType, options)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -145,10 +146,7 @@ func (h *TorrentsHandler) SearchTorrents(w http.ResponseWriter, r *http.Request)
 	}
 
 	if len(results.Results) == 0 {
-		response["error"] = "No torrents found for this IMDB ID"
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusNotFound)
-		json.NewEncoder(w).Encode(response)
+		apperr.Write(w, r, apperr.NotFound("No torrents found for this IMDB ID").WithCode("torrents_not_found"))
 		return
 	}
 
@@ -166,13 +164,13 @@ func (h *TorrentsHandler) SearchMovies(w http.ResponseWriter, r *http.Request) {
 	year := r.URL.Query().Get("year")
 
 	if title == "" && originalTitle == "" {
-		http.Error(w, "Title or original title is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Title or original title is required"))
 		return
 	}
 
 	results, err := h.torrentService.SearchMovies(title, originalTitle, year)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -199,7 +197,7 @@ func (h *TorrentsHandler) SearchSeries(w http.ResponseWriter, r *http.Request) {
 	year := r.URL.Query().Get("year")
 
 	if title == "" && originalTitle == "" {
-		http.Error(w, "Title or original title is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Title or original title is required"))
 		return
 	}
 
@@ -212,7 +210,7 @@ func (h *TorrentsHandler) SearchSeries(w http.ResponseWriter, r *http.Request) {
 
 	results, err := h.torrentService.SearchSeries(title, originalTitle, year, season)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -243,13 +241,13 @@ func (h *TorrentsHandler) SearchAnime(w http.ResponseWriter, r *http.Request) {
 	year := r.URL.Query().Get("year")
 
 	if title == "" && originalTitle == "" {
-		http.Error(w, "Title or original title is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Title or original title is required"))
 		return
 	}
 
 	results, err := h.torrentService.SearchAnime(title, originalTitle, year)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -276,13 +274,13 @@ func (h *TorrentsHandler) GetAvailableSeasons(w http.ResponseWriter, r *http.Req
 	year := r.URL.Query().Get("year")
 
 	if title == "" && originalTitle == "" {
-		http.Error(w, "Title or original title is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Title or original title is required"))
 		return
 	}
 
 	seasons, err := h.torrentService.GetAvailableSeasons(title, originalTitle, year)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -305,7 +303,7 @@ func (h *TorrentsHandler) GetAvailableSeasons(w http.ResponseWriter, r *http.Req
 func (h *TorrentsHandler) SearchByQuery(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("query")
 	if query == "" {
-		http.Error(w, "Query is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Query is required"))
 		return
 	}
 
@@ -340,7 +338,7 @@ func (h *TorrentsHandler) SearchByQuery(w http.ResponseWriter, r *http.Request)
 
 	results, err := h.torrentService.SearchTorrents(params)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
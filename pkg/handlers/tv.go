@@ -7,6 +7,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
 )
@@ -22,19 +23,15 @@ func NewTVHandler(tvService *services.TVService) *TVHandler {
 }
 
 func (h *TVHandler) Search(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+	q := searchQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
-	year := getIntQuery(r, "first_air_date_year", 0)
-
-	tvShows, err := h.tvService.Search(query, page, language, year)
+	tvShows, err := h.tvService.Search(q.Query, q.Page, q.Language, q.FirstAirDateYear)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -49,7 +46,7 @@ func (h *TVHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid TV show ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid TV show ID"))
 		return
 	}
 
@@ -57,7 +54,7 @@ func (h *TVHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 
 	tvShow, err := h.tvService.GetByID(id, language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -69,12 +66,15 @@ func (h *TVHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TVHandler) Popular(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	tvShows, err := h.tvService.GetPopular(page, language)
+	tvShows, err := h.tvService.GetPopular(q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -86,12 +86,15 @@ func (h *TVHandler) Popular(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TVHandler) TopRated(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	tvShows, err := h.tvService.GetTopRated(page, language)
+	tvShows, err := h.tvService.GetTopRated(q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -103,12 +106,15 @@ func (h *TVHandler) TopRated(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TVHandler) OnTheAir(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	tvShows, err := h.tvService.GetOnTheAir(page, language)
+	tvShows, err := h.tvService.GetOnTheAir(q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -120,12 +126,15 @@ func (h *TVHandler) OnTheAir(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *TVHandler) AiringToday(w http.ResponseWriter, r *http.Request) {
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	tvShows, err := h.tvService.GetAiringToday(page, language)
+	tvShows, err := h.tvService.GetAiringToday(q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -140,16 +149,19 @@ func (h *TVHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid TV show ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid TV show ID"))
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	tvShows, err := h.tvService.GetRecommendations(id, page, language)
+	tvShows, err := h.tvService.GetRecommendations(id, q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -164,16 +176,19 @@ func (h *TVHandler) GetSimilar(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid TV show ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid TV show ID"))
 		return
 	}
 
-	page := getIntQuery(r, "page", 1)
-	language := r.URL.Query().Get("language")
+	q := tmdbQuery{Page: 1}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
 
-	tvShows, err := h.tvService.GetSimilar(id, page, language)
+	tvShows, err := h.tvService.GetSimilar(id, q.Page, q.Language)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -188,13 +203,13 @@ func (h *TVHandler) GetExternalIDs(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		http.Error(w, "Invalid TV show ID", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid TV show ID"))
 		return
 	}
 
 	externalIDs, err := h.tvService.GetExternalIDs(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/services"
+)
+
+type WatchHistoryHandler struct {
+	watchHistoryService *services.WatchHistoryService
+}
+
+func NewWatchHistoryHandler(watchHistoryService *services.WatchHistoryService) *WatchHistoryHandler {
+	return &WatchHistoryHandler{
+		watchHistoryService: watchHistoryService,
+	}
+}
+
+func (h *WatchHistoryHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	q := limitQuery{Limit: 50}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	history, err := h.watchHistoryService.GetHistory(r.Context(), userID, q.Limit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    history,
+	})
+}
+
+func (h *WatchHistoryHandler) RecordProgress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	var req models.WatchProgressRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	progress, err := h.watchHistoryService.RecordProgress(r.Context(), userID, req)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Progress saved",
+	})
+}
+
+func (h *WatchHistoryHandler) ContinueWatching(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	q := limitQuery{Limit: 20}
+	if err := decodeQuery(r, &q); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	items, err := h.watchHistoryService.GetContinueWatching(r.Context(), userID, q.Limit)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    items,
+	})
+}
+
+func (h *WatchHistoryHandler) MarkEpisodeWatched(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	tvID := mux.Vars(r)["id"]
+	if tvID == "" {
+		apperr.Write(w, r, apperr.Validation("TV show ID is required"))
+		return
+	}
+
+	var req models.EpisodeWatchedRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	progress, err := h.watchHistoryService.MarkEpisodeWatched(r.Context(), userID, tvID, req.SeasonNumber, req.EpisodeNumber)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    progress,
+		Message: "Episode marked as watched",
+	})
+}
+
+func (h *WatchHistoryHandler) GetNextEpisode(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	tvID := mux.Vars(r)["id"]
+	if tvID == "" {
+		apperr.Write(w, r, apperr.Validation("TV show ID is required"))
+		return
+	}
+
+	next, err := h.watchHistoryService.GetNextEpisode(r.Context(), userID, tvID)
+	if err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Data:    next,
+	})
+}
+
+func (h *WatchHistoryHandler) RemoveFromHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		apperr.Write(w, r, errNoUserID)
+		return
+	}
+
+	vars := mux.Vars(r)
+	mediaType := vars["mediaType"]
+	mediaID := vars["id"]
+
+	if mediaType != "movie" && mediaType != "tv" {
+		apperr.Write(w, r, services.ErrInvalidMediaType)
+		return
+	}
+
+	if err := h.watchHistoryService.RemoveFromHistory(r.Context(), userID, mediaType, mediaID); err != nil {
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.APIResponse{
+		Success: true,
+		Message: "Removed from watch history",
+	})
+}
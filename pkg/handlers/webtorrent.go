@@ -8,6 +8,7 @@ import (
 	"strconv"
 	"strings"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/models"
 	"neomovies-api/pkg/services"
 )
@@ -71,7 +72,7 @@ func (h *WebTorrentHandler) OpenPlayer(w http.ResponseWriter, r *http.Request) {
 
 	// Проверяем, что это действительно magnet ссылка
 	if !isValidMagnetLink(decodedMagnet) {
-		http.Error(w, "Invalid magnet link format", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Invalid magnet link format").WithCode("invalid_magnet"))
 		return
 	}
 
@@ -602,7 +603,7 @@ func (h *WebTorrentHandler) OpenPlayer(w http.ResponseWriter, r *http.Request) {
 	// Создаем template и выполняем его
 	t, err := template.New("player").Parse(tmpl)
 	if err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -617,7 +618,7 @@ func (h *WebTorrentHandler) OpenPlayer(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	err = t.Execute(w, data)
 	if err != nil {
-		http.Error(w, "Template execution error", http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 }
@@ -626,18 +627,14 @@ func (h *WebTorrentHandler) OpenPlayer(w http.ResponseWriter, r *http.Request) {
 func (h *WebTorrentHandler) GetMetadata(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("query")
 	if query == "" {
-		http.Error(w, "Query parameter is required", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.Validation("Query parameter is required"))
 		return
 	}
 
 	// Пытаемся определить тип контента и найти его
 	metadata, err := h.searchAndBuildMetadata(query)
 	if err != nil {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(models.APIResponse{
-			Success: false,
-			Message: "Media not found: " + err.Error(),
-		})
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -726,6 +723,9 @@ func (h *WebTorrentHandler) searchAndBuildMetadata(query string) (*MediaMetadata
 		}
 	}
 
+	if err == nil {
+		err = apperr.NotFound("Media not found").WithCode("media_not_found")
+	}
 	return nil, err
 }
 
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"neomovies-api/pkg/logger"
+)
+
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusFail     = "fail"
+)
+
+// Check probes a single dependency. A failing critical check makes the
+// service not ready; a failing non-critical one only degrades it.
+type Check struct {
+	Name     string
+	Critical bool
+	Timeout  time.Duration
+	Probe    func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of one check. Error carries the raw probe
+// error with hostnames and driver messages, so it is only shown to admins.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of all checks.
+type Report struct {
+	Status    string                 `json:"status"`
+	CheckedAt time.Time              `json:"checkedAt"`
+	Checks    map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether all critical checks passed.
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Public returns a copy of the report without probe errors, safe to serve
+// on unauthenticated endpoints.
+func (r Report) Public() Report {
+	checks := make(map[string]CheckResult, len(r.Checks))
+	for name, result := range r.Checks {
+		result.Error = ""
+		checks[name] = result
+	}
+	r.Checks = checks
+	return r
+}
+
+// Checker runs checks concurrently and caches the report for a short time
+// so that frequent probes do not hammer upstream APIs.
+type Checker struct {
+	checks   []Check
+	cacheTTL time.Duration
+
+	mu   sync.Mutex
+	last *Report
+}
+
+// DefaultTimeout is used for checks that do not set their own.
+const DefaultTimeout = 3 * time.Second
+
+func NewChecker(cacheTTL time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, cacheTTL: cacheTTL}
+}
+
+// Run executes every check, or returns the cached report if it is fresh.
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.last != nil && time.Since(c.last.CheckedAt) < c.cacheTTL {
+		return *c.last
+	}
+
+	report := Report{
+		Status:    StatusOK,
+		CheckedAt: time.Now().UTC(),
+		Checks:    make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var wg sync.WaitGroup
+	var resultsMu sync.Mutex
+	for _, check := range c.checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+
+			resultsMu.Lock()
+			report.Checks[check.Name] = result
+			resultsMu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+
+	for name, result := range report.Checks {
+		if result.Status == StatusOK {
+			continue
+		}
+		// Причина сбоя видна только в логах и в админском отчете
+		logger.FromContext(ctx).Warn("health check failed", "check", name, "critical", result.Critical, "error", result.Error)
+		if result.Critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	c.last = &report
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	// Отмена клиентского запроса не должна попадать в закэшированный отчет
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Probe(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		Critical:  check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
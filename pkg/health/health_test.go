@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func probe(err error) func(context.Context) error {
+	return func(context.Context) error { return err }
+}
+
+func TestCheckerStatus(t *testing.T) {
+	down := errors.New("dial tcp 10.0.0.5:27017: connection refused")
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+		ready  bool
+	}{
+		{"all ok", []Check{{Name: "a", Critical: true, Probe: probe(nil)}, {Name: "b", Probe: probe(nil)}}, StatusOK, true},
+		{"optional failed", []Check{{Name: "a", Critical: true, Probe: probe(nil)}, {Name: "b", Probe: probe(down)}}, StatusDegraded, true},
+		{"critical failed", []Check{{Name: "a", Critical: true, Probe: probe(down)}, {Name: "b", Probe: probe(down)}}, StatusFail, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewChecker(0, tt.checks...).Run(context.Background())
+			if report.Status != tt.want || report.Ready() != tt.ready {
+				t.Fatalf("status = %s, ready = %v", report.Status, report.Ready())
+			}
+			if len(report.Checks) != len(tt.checks) {
+				t.Fatalf("checks = %v", report.Checks)
+			}
+		})
+	}
+}
+
+func TestCheckerTimeout(t *testing.T) {
+	slow := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	report := NewChecker(0, Check{Name: "slow", Critical: true, Timeout: 10 * time.Millisecond, Probe: slow}).Run(context.Background())
+	if result := report.Checks["slow"]; result.Status != StatusFail || result.Error == "" {
+		t.Fatalf("result = %+v", result)
+	}
+}
+
+func TestCheckerIgnoresClientCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := NewChecker(0, Check{Name: "a", Critical: true, Probe: func(ctx context.Context) error { return ctx.Err() }}).Run(ctx)
+	if report.Status != StatusOK {
+		t.Fatalf("canceled client request failed the check: %+v", report)
+	}
+}
+
+func TestCheckerCachesReport(t *testing.T) {
+	var calls atomic.Int32
+	counting := func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}
+	checker := NewChecker(time.Minute, Check{Name: "a", Probe: counting})
+
+	checker.Run(context.Background())
+	checker.Run(context.Background())
+	if got := calls.Load(); got != 1 {
+		t.Errorf("probe called %d times, want 1", got)
+	}
+}
+
+func TestReportPublicHidesErrors(t *testing.T) {
+	report := NewChecker(0,
+		Check{Name: "mongodb", Critical: true, Probe: probe(errors.New("server selection error: mongo.internal:27017"))},
+	).Run(context.Background())
+
+	public := report.Public()
+	if result := public.Checks["mongodb"]; result.Error != "" || result.Status != StatusFail {
+		t.Errorf("public result = %+v", result)
+	}
+	// Исходный отчет не меняется
+	if report.Checks["mongodb"].Error == "" {
+		t.Error("Public modified the original report")
+	}
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// New creates a structured logger. format is "json" or "text"; level is
+// one of debug, info, warn, error. Values of sensitive attributes are
+// redacted by the handler.
+func New(w io.Writer, format, level string) *slog.Logger {
+	opts := &slog.HandlerOptions{
+		Level:       parseLevel(level),
+		ReplaceAttr: redactAttr,
+	}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(handler)
+}
+
+// Setup creates a logger writing to stdout and installs it as the default
+// logger, so that the standard log package goes through it as well.
+func Setup(format, level string) *slog.Logger {
+	l := New(os.Stdout, format, level)
+	slog.SetDefault(l)
+	return l
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type contextKey struct{}
+
+// RequestInfo holds per-request fields that are filled in while the request
+// passes through the middleware stack and read by the access log.
+type RequestInfo struct {
+	ID        string
+	Route     string
+	UserID    string
+	RemoteIP  string
+	UserAgent string
+}
+
+// WithRequestInfo attaches info to ctx.
+func WithRequestInfo(ctx context.Context, info *RequestInfo) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// RequestInfoFromContext returns the request info attached to ctx, if any.
+func RequestInfoFromContext(ctx context.Context) *RequestInfo {
+	info, _ := ctx.Value(contextKey{}).(*RequestInfo)
+	return info
+}
+
+// RequestID returns the ID of the current request or an empty string.
+func RequestID(ctx context.Context) string {
+	if info := RequestInfoFromContext(ctx); info != nil {
+		return info.ID
+	}
+	return ""
+}
+
+// SetUserID records the authenticated user of the current request.
+func SetUserID(ctx context.Context, userID string) {
+	if info := RequestInfoFromContext(ctx); info != nil {
+		info.UserID = userID
+	}
+}
+
+// SetRoute records the matched route template of the current request.
+func SetRoute(ctx context.Context, route string) {
+	if info := RequestInfoFromContext(ctx); info != nil {
+		info.Route = route
+	}
+}
+
+// FromContext returns the default logger annotated with the request ID and
+// user ID of the current request.
+func FromContext(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	info := RequestInfoFromContext(ctx)
+	if info == nil {
+		return l
+	}
+	if info.ID != "" {
+		l = l.With("request_id", info.ID)
+	}
+	if info.UserID != "" {
+		l = l.With("user_id", info.UserID)
+	}
+	return l
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestNewFormatAndLevel(t *testing.T) {
+	var buf bytes.Buffer
+	log := New(&buf, "text", "warn")
+
+	log.Info("hidden")
+	log.Warn("shown")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "msg=shown") {
+		t.Fatalf("output = %q", out)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]slog.Level{
+		"debug":   slog.LevelDebug,
+		"WARNING": slog.LevelWarn,
+		"error":   slog.LevelError,
+		"":        slog.LevelInfo,
+		"verbose": slog.LevelInfo,
+	}
+	for in, want := range tests {
+		if got := parseLevel(in); got != want {
+			t.Errorf("parseLevel(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(New(&buf, "json", "info"))
+	defer slog.SetDefault(prev)
+
+	info := &RequestInfo{ID: "req-1"}
+	ctx := WithRequestInfo(context.Background(), info)
+	SetUserID(ctx, "user-1")
+	SetRoute(ctx, "/api/v1/movies/{id}")
+
+	FromContext(ctx).Info("hello")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry["request_id"] != "req-1" || entry["user_id"] != "user-1" {
+		t.Errorf("entry = %v", entry)
+	}
+	if RequestID(ctx) != "req-1" || info.Route != "/api/v1/movies/{id}" {
+		t.Errorf("info = %+v", info)
+	}
+
+	// Без информации о запросе возвращается обычный логгер
+	if FromContext(context.Background()) != slog.Default() || RequestID(context.Background()) != "" {
+		t.Error("unexpected request info in an empty context")
+	}
+}
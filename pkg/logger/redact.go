@@ -0,0 +1,88 @@
+package logger
+
+import (
+	"errors"
+	"log/slog"
+	"net/url"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute and query parameter names whose values must
+// never reach the logs.
+var sensitiveKeys = map[string]bool{
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"refreshtoken":  true,
+	"api_key":       true,
+	"apikey":        true,
+	"key":           true,
+	"password":      true,
+	"secret":        true,
+	"authorization": true,
+	"code":          true,
+	"state":         true,
+}
+
+func isSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// RedactURL masks values of sensitive query parameters and userinfo
+// passwords, e.g. https://api.alloha.tv/?token=secret&imdb=tt1 becomes
+// https://api.alloha.tv/?imdb=tt1&token=%5BREDACTED%5D.
+func RedactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	changed := false
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+			changed = true
+		}
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			if isSensitive(key) {
+				query.Set(key, redacted)
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = query.Encode()
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return u.String()
+}
+
+// RedactError hides secrets in the URL carried by net/http client errors,
+// which otherwise print the full request URL including its query.
+func RedactError(err error) error {
+	var urlErr *url.Error
+	if err == nil || !errors.As(err, &urlErr) {
+		return err
+	}
+	return &url.Error{Op: urlErr.Op, URL: RedactURL(urlErr.URL), Err: urlErr.Err}
+}
+
+// redactAttr is a slog ReplaceAttr hook: sensitive attributes are masked
+// and URL-looking string values get their query redacted.
+func redactAttr(groups []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) {
+		return slog.String(a.Key, redacted)
+	}
+	if a.Value.Kind() == slog.KindString && a.Key != slog.MessageKey {
+		if s := a.Value.String(); strings.Contains(s, "?") {
+			return slog.String(a.Key, RedactURL(s))
+		}
+	}
+	return a
+}
@@ -0,0 +1,155 @@
+// Package mail builds MIME messages and delivers them through a Mailer:
+// an SMTP server (STARTTLS, implicit TLS or plain), a directory of .eml
+// files or the log. The SMTP host and port are configurable, so delivery
+// can be pointed at a local fake SMTP server.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Message is one email. At least one of Text and HTML must be set; with
+// both the message is sent as multipart/alternative.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are extra headers such as List-Unsubscribe.
+	Headers map[string]string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// ErrNoRecipients is returned for a message without recipients.
+var ErrNoRecipients = errors.New("mail: no recipients")
+
+// Build renders msg as an RFC 5322 message from from. Headers are written
+// in a fixed order, non-ASCII headers are encoded per RFC 2047 and bodies
+// are quoted-printable.
+func Build(from mail.Address, msg *Message, now time.Time) ([]byte, error) {
+	if len(msg.To) == 0 {
+		return nil, ErrNoRecipients
+	}
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, errors.New("mail: empty body")
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		// Перевод строки в значении позволил бы дописать свои заголовки
+		value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	to := make([]string, len(msg.To))
+	for i, addr := range msg.To {
+		to[i] = (&mail.Address{Address: addr}).String()
+	}
+
+	header("From", from.String())
+	header("To", strings.Join(to, ", "))
+	header("Subject", mime.BEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	keys := make([]string, 0, len(msg.Headers))
+	for key := range msg.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		header(textproto.CanonicalMIMEHeaderKey(key), mime.BEncoding.Encode("UTF-8", msg.Headers[key]))
+	}
+	header("MIME-Version", "1.0")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		header("Content-Type", contentType+"; charset=UTF-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	// Клиенты показывают последнюю понятную им часть, поэтому HTML идет вторым
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		w, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=UTF-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+// ParseAddress parses the sender, e.g. "Neo Movies <noreply@example.com>"
+// or a bare address with name as the display name.
+func ParseAddress(address, name string) (mail.Address, error) {
+	addr, err := mail.ParseAddress(address)
+	if err != nil {
+		return mail.Address{}, fmt.Errorf("mail: invalid sender %q: %w", address, err)
+	}
+	if addr.Name == "" {
+		addr.Name = name
+	}
+	return *addr, nil
+}
+
+func writeQuotedPrintable(w interface{ Write([]byte) (int, error) }, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if i := strings.LastIndexByte(from, '@'); i >= 0 {
+		domain = from[i+1:]
+	}
+	return "<" + randomHex(16) + "@" + domain + ">"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,65 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer writes every message to an .eml file in Dir instead of
+// sending it. Meant for local development; the files open in any mail
+// client.
+type FileMailer struct {
+	dir  string
+	from mail.Address
+	log  *slog.Logger
+}
+
+func NewFileMailer(dir string, from mail.Address, log *slog.Logger) *FileMailer {
+	if from.Address == "" {
+		from.Address = "noreply@localhost"
+	}
+	return &FileMailer{dir: dir, from: from, log: log}
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg *Message) error {
+	now := time.Now()
+	data, err := Build(m.from, msg, now)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000"), randomHex(4))
+	path := filepath.Join(m.dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("mail: %w", err)
+	}
+	m.log.InfoContext(ctx, "email written", "to", strings.Join(msg.To, ","), "subject", msg.Subject, "path", path)
+	return nil
+}
+
+// LogMailer only logs recipients and subject. The body may contain codes
+// and tokens, so it is never logged.
+type LogMailer struct {
+	log *slog.Logger
+}
+
+func NewLogMailer(log *slog.Logger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg *Message) error {
+	if len(msg.To) == 0 {
+		return ErrNoRecipients
+	}
+	m.log.InfoContext(ctx, "email not sent (MAIL_TRANSPORT=log)", "to", strings.Join(msg.To, ","), "subject", msg.Subject)
+	return nil
+}
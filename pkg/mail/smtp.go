@@ -0,0 +1,142 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// Connection security of an SMTP server.
+const (
+	SecurityStartTLS = "starttls"
+	SecurityTLS      = "tls"
+	SecurityNone     = "none"
+)
+
+// SMTPConfig configures an SMTP server.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Security is starttls (usually port 587), tls (implicit TLS, usually
+	// port 465) or none.
+	Security string
+	Username string
+	Password string
+	From     mail.Address
+	// Timeout bounds a whole delivery; zero means 30 seconds.
+	Timeout time.Duration
+	// TLSConfig overrides the default TLS settings, e.g. to trust a test CA.
+	TLSConfig *tls.Config
+}
+
+// SMTPMailer delivers every message over a new SMTP connection.
+type SMTPMailer struct {
+	cfg SMTPConfig
+}
+
+func NewSMTP(cfg SMTPConfig) *SMTPMailer {
+	if cfg.Security == "" {
+		cfg.Security = SecurityStartTLS
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &SMTPMailer{cfg: cfg}
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if m.cfg.From.Address == "" {
+		return errors.New("mail: sender address not configured")
+	}
+	data, err := Build(m.cfg.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	client, err := m.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := m.deliver(client, msg.To, data); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func (m *SMTPMailer) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("mail: dial %s: %w", addr, err)
+	}
+	// net/smtp не принимает контекст, поэтому срок берем из него
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if m.cfg.Security == SecurityTLS {
+		conn = tls.Client(conn, m.tlsConfig())
+	}
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("mail: connect %s: %w", addr, err)
+	}
+
+	if m.cfg.Security == SecurityStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, errors.New("mail: server does not support STARTTLS")
+		}
+		if err := client.StartTLS(m.tlsConfig()); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("mail: starttls: %w", err)
+		}
+	}
+	return client, nil
+}
+
+func (m *SMTPMailer) deliver(client *smtp.Client, to []string, data []byte) error {
+	if m.cfg.Username != "" {
+		// PlainAuth сам откажет, если соединение не защищено и сервер не локальный
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("mail: auth: %w", err)
+		}
+	}
+	if err := client.Mail(m.cfg.From.Address); err != nil {
+		return fmt.Errorf("mail: MAIL FROM: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("mail: RCPT TO: %w", err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: DATA: %w", err)
+	}
+	return w.Close()
+}
+
+func (m *SMTPMailer) tlsConfig() *tls.Config {
+	if m.cfg.TLSConfig != nil {
+		return m.cfg.TLSConfig
+	}
+	return &tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}
+}
@@ -0,0 +1,459 @@
+package mail
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFrom = mail.Address{Name: "Neo Movies", Address: "noreply@neomovies.test"}
+
+// testCert выпускает самоподписанный сертификат для 127.0.0.1
+func testCert(t *testing.T) (tls.Certificate, *x509.CertPool) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "smtp.test"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, pool
+}
+
+// fakeSMTP — минимальный SMTP-сервер на одно соединение
+type fakeSMTP struct {
+	implicitTLS bool
+	startTLS    bool
+	rejectRcpt  string
+
+	tls *tls.Config
+}
+
+// session — то, что сервер получил от клиента
+type session struct {
+	auth    string
+	authTLS bool
+	from    string
+	rcpt    []string
+	data    []byte
+	quit    bool
+}
+
+func (s *fakeSMTP) start(t *testing.T) (int, <-chan session) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	if s.implicitTLS {
+		ln = tls.NewListener(ln, s.tls)
+	}
+
+	done := make(chan session, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			close(done)
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		done <- s.serve(conn)
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, done
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) session {
+	var got session
+	_, secure := conn.(*tls.Conn)
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 smtp.test ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return got
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			ext := []string{"smtp.test", "AUTH PLAIN"}
+			if s.startTLS && !secure {
+				ext = append(ext, "STARTTLS")
+			}
+			for i, e := range ext {
+				sep := "-"
+				if i == len(ext)-1 {
+					sep = " "
+				}
+				tp.PrintfLine("250%s%s", sep, e)
+			}
+		case "STARTTLS":
+			tp.PrintfLine("220 ready")
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return got
+			}
+			conn, secure = tlsConn, true
+			tp = textproto.NewConn(conn)
+		case "AUTH":
+			got.auth, got.authTLS = strings.TrimPrefix(arg, "PLAIN "), secure
+			tp.PrintfLine("235 accepted")
+		case "MAIL":
+			got.from = arg
+			tp.PrintfLine("250 ok")
+		case "RCPT":
+			if s.rejectRcpt != "" && strings.Contains(arg, s.rejectRcpt) {
+				tp.PrintfLine("550 no such user")
+				continue
+			}
+			got.rcpt = append(got.rcpt, arg)
+			tp.PrintfLine("250 ok")
+		case "DATA":
+			tp.PrintfLine("354 go ahead")
+			got.data, _ = io.ReadAll(tp.DotReader())
+			tp.PrintfLine("250 queued")
+		case "QUIT":
+			got.quit = true
+			tp.PrintfLine("221 bye")
+			return got
+		default:
+			tp.PrintfLine("502 not implemented")
+		}
+	}
+}
+
+func testMessage() *Message {
+	return &Message{
+		To:      []string{"user@example.com"},
+		Subject: "Подтверждение email",
+		Text:    "Ваш код: 123456",
+		HTML:    "<p>Ваш код: <b>123456</b></p>",
+		Headers: map[string]string{"List-Unsubscribe": "<https://neomovies.test/unsubscribe>"},
+	}
+}
+
+func TestSMTPSend(t *testing.T) {
+	cert, pool := testCert(t)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	tests := []struct {
+		name     string
+		server   fakeSMTP
+		security string
+		username string
+		authTLS  bool
+	}{
+		{"no tls", fakeSMTP{}, SecurityNone, "", false},
+		// PlainAuth разрешает открытый текст только для localhost
+		{"no tls with auth", fakeSMTP{}, SecurityNone, "user", false},
+		{"starttls", fakeSMTP{startTLS: true}, SecurityStartTLS, "user", true},
+		{"implicit tls", fakeSMTP{implicitTLS: true}, SecurityTLS, "user", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.server
+			server.tls = serverTLS
+			port, done := server.start(t)
+
+			m := NewSMTP(SMTPConfig{
+				Host:      "127.0.0.1",
+				Port:      port,
+				Security:  tt.security,
+				Username:  tt.username,
+				Password:  "app-password",
+				From:      testFrom,
+				Timeout:   5 * time.Second,
+				TLSConfig: &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"},
+			})
+			if err := m.Send(context.Background(), testMessage()); err != nil {
+				t.Fatal(err)
+			}
+
+			got := <-done
+			if got.from != "FROM:<noreply@neomovies.test>" || strings.Join(got.rcpt, ",") != "TO:<user@example.com>" {
+				t.Errorf("envelope = %q -> %q", got.from, got.rcpt)
+			}
+			if !got.quit {
+				t.Error("connection closed without QUIT")
+			}
+			if msg, err := mail.ReadMessage(bytes.NewReader(got.data)); err != nil || msg.Header.Get("To") != "<user@example.com>" {
+				t.Errorf("delivered message is broken: %v", err)
+			}
+
+			if tt.username == "" {
+				if got.auth != "" {
+					t.Error("authenticated without credentials")
+				}
+				return
+			}
+			creds, _ := base64.StdEncoding.DecodeString(got.auth)
+			if string(creds) != "\x00user\x00app-password" {
+				t.Errorf("AUTH PLAIN = %q", creds)
+			}
+			if got.authTLS != tt.authTLS {
+				t.Errorf("auth over TLS = %v, want %v", got.authTLS, tt.authTLS)
+			}
+		})
+	}
+}
+
+func TestSMTPSendErrors(t *testing.T) {
+	cert, pool := testCert(t)
+	serverTLS := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	tests := []struct {
+		name      string
+		server    fakeSMTP
+		security  string
+		to        []string
+		untrusted bool
+		want      string
+	}{
+		{"rejected recipient", fakeSMTP{rejectRcpt: "ghost@"}, SecurityNone, []string{"user@example.com", "ghost@example.com"}, false, "RCPT TO: 550"},
+		// Без STARTTLS пароль ушел бы открытым текстом
+		{"no starttls", fakeSMTP{}, SecurityStartTLS, []string{"user@example.com"}, false, "does not support STARTTLS"},
+		{"untrusted certificate", fakeSMTP{startTLS: true}, SecurityStartTLS, []string{"user@example.com"}, true, "starttls"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := tt.server
+			server.tls = serverTLS
+			port, done := server.start(t)
+
+			clientTLS := &tls.Config{RootCAs: pool, ServerName: "127.0.0.1"}
+			if tt.untrusted {
+				clientTLS = &tls.Config{RootCAs: x509.NewCertPool(), ServerName: "127.0.0.1"}
+			}
+			m := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: port, Security: tt.security, From: testFrom, Timeout: 5 * time.Second, TLSConfig: clientTLS})
+
+			msg := testMessage()
+			msg.To = tt.to
+			err := m.Send(context.Background(), msg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+			if got := <-done; got.data != nil {
+				t.Error("message was delivered")
+			}
+		})
+	}
+
+	t.Run("dial", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+
+		m := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: port, Security: SecurityNone, From: testFrom})
+		if err := m.Send(context.Background(), testMessage()); err == nil || !strings.Contains(err.Error(), "dial 127.0.0.1:"+strconv.Itoa(port)) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	t.Run("no sender", func(t *testing.T) {
+		if err := NewSMTP(SMTPConfig{}).Send(context.Background(), testMessage()); err == nil {
+			t.Fatal("sent without a sender address")
+		}
+	})
+}
+
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	data, err := Build(testFrom, testMessage(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Кириллица в заголовке кодируется по RFC 2047
+	raw := msg.Header.Get("Subject")
+	if !strings.HasPrefix(raw, "=?UTF-8?b?") {
+		t.Errorf("raw subject = %q", raw)
+	}
+	if subject, err := new(mime.WordDecoder).DecodeHeader(raw); err != nil || subject != "Подтверждение email" {
+		t.Errorf("subject = %q, err = %v", subject, err)
+	}
+	if got := msg.Header.Get("From"); got != `"Neo Movies" <noreply@neomovies.test>` {
+		t.Errorf("From = %q", got)
+	}
+	if got := msg.Header.Get("Date"); got != "Wed, 01 May 2024 12:00:00 +0000" {
+		t.Errorf("Date = %q", got)
+	}
+	if got := msg.Header.Get("Message-Id"); !strings.HasSuffix(got, "@neomovies.test>") {
+		t.Errorf("Message-ID = %q", got)
+	}
+	if got := msg.Header.Get("List-Unsubscribe"); got != "<https://neomovies.test/unsubscribe>" {
+		t.Errorf("List-Unsubscribe = %q", got)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q", msg.Header.Get("Content-Type"))
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	want := []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", "Ваш код: 123456"},
+		{"text/html; charset=UTF-8", "<p>Ваш код: <b>123456</b></p>"},
+	}
+	for _, w := range want {
+		// multipart.Reader сам снимает quoted-printable, поэтому читаем сырую часть
+		part, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if part.Header.Get("Content-Type") != w.contentType || part.Header.Get("Content-Transfer-Encoding") != "quoted-printable" {
+			t.Errorf("part header = %v", part.Header)
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		if string(body) != w.body {
+			t.Errorf("%s body = %q", w.contentType, body)
+		}
+	}
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("extra parts: %v", err)
+	}
+}
+
+func TestBuildSinglePart(t *testing.T) {
+	data, err := Build(testFrom, &Message{To: []string{"a@example.com", "b@example.com"}, Subject: "Hi", HTML: "<p>Привет</p>"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := mail.ReadMessage(bytes.NewReader(data))
+	if got := msg.Header.Get("Content-Type"); got != "text/html; charset=UTF-8" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if got := msg.Header.Get("To"); got != "<a@example.com>, <b@example.com>" {
+		t.Errorf("To = %q", got)
+	}
+	body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	if string(body) != "<p>Привет</p>" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestBuildRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"no recipients", Message{Subject: "Hi", Text: "x"}},
+		{"empty body", Message{To: []string{"a@example.com"}, Subject: "Hi"}},
+	}
+	for _, tt := range tests {
+		if _, err := Build(testFrom, &tt.msg, time.Now()); err == nil {
+			t.Errorf("%s: built", tt.name)
+		}
+	}
+
+	// Перевод строки в теме не добавляет заголовков
+	data, err := Build(testFrom, &Message{To: []string{"a@example.com"}, Subject: "Hi\r\nBcc: evil@example.com", Text: "x"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := mail.ReadMessage(bytes.NewReader(data))
+	if msg.Header.Get("Bcc") != "" {
+		t.Error("header injected through the subject")
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	var logs bytes.Buffer
+	m := NewFileMailer(dir, mail.Address{}, slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if err := m.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("files = %v", files)
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	msg, err := mail.ReadMessage(bufio.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Без настроенного отправителя используется заглушка
+	if got := msg.Header.Get("From"); got != "<noreply@localhost>" {
+		t.Errorf("From = %q", got)
+	}
+	if !strings.Contains(logs.String(), files[0]) {
+		t.Errorf("log = %s", logs.String())
+	}
+
+	if err := m.Send(context.Background(), &Message{Subject: "Hi", Text: "x"}); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestLogMailer(t *testing.T) {
+	var logs bytes.Buffer
+	m := NewLogMailer(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	if err := m.Send(context.Background(), testMessage()); err != nil {
+		t.Fatal(err)
+	}
+	out := logs.String()
+	if !strings.Contains(out, "user@example.com") || !strings.Contains(out, "Подтверждение email") {
+		t.Errorf("log = %s", out)
+	}
+	// Тело с кодом не логируется
+	if strings.Contains(out, "123456") {
+		t.Errorf("body is logged: %s", out)
+	}
+
+	if err := m.Send(context.Background(), &Message{}); !errors.Is(err, ErrNoRecipients) {
+		t.Errorf("err = %v", err)
+	}
+}
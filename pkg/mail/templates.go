@@ -0,0 +1,161 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// DefaultLocale is used when the recipient has no language preference or
+// prefers a language we have no templates for.
+const DefaultLocale = "ru"
+
+// Locales lists the languages every template is translated into.
+var Locales = []string{"ru", "en", "uk"}
+
+// NormalizeLocale maps a user language preference ("en-US", "uk_UA", "ua")
+// to one of Locales, falling back to DefaultLocale.
+func NormalizeLocale(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if lang == "ua" {
+		lang = "uk"
+	}
+	for _, locale := range Locales {
+		if lang == locale {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// Rendered is a template rendered for one recipient.
+type Rendered struct {
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+type localized struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Templates holds the parsed email templates. Each template lives in
+// templates/<locale>/<name>.html and <name>.txt: the HTML file defines
+// "content" which is wrapped into the shared layout, the text file defines
+// "subject" and its body is the plain-text alternative.
+type Templates struct {
+	names     []string
+	templates map[string]map[string]localized
+}
+
+// LoadTemplates parses the embedded templates. Template names are taken from
+// the default locale, and every other locale must provide all of them.
+func LoadTemplates() (*Templates, error) {
+	return loadTemplates(templateFS)
+}
+
+func loadTemplates(fsys fs.FS) (*Templates, error) {
+	entries, err := fs.ReadDir(fsys, path.Join("templates", DefaultLocale))
+	if err != nil {
+		return nil, fmt.Errorf("read templates: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if name, ok := strings.CutSuffix(entry.Name(), ".txt"); ok && !entry.IsDir() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no email templates in templates/%s", DefaultLocale)
+	}
+
+	t := &Templates{names: names, templates: make(map[string]map[string]localized, len(names))}
+	for _, name := range names {
+		t.templates[name] = make(map[string]localized, len(Locales))
+		for _, locale := range Locales {
+			l, err := parseLocalized(fsys, name, locale)
+			if err != nil {
+				return nil, fmt.Errorf("template %s/%s: %w", locale, name, err)
+			}
+			t.templates[name][locale] = l
+		}
+	}
+	return t, nil
+}
+
+func parseLocalized(fsys fs.FS, name, locale string) (localized, error) {
+	// lang нужен layout'у для атрибута <html lang>
+	funcs := htmltemplate.FuncMap{"lang": func() string { return locale }}
+	h, err := htmltemplate.New(name).Funcs(funcs).ParseFS(fsys,
+		"templates/layout.html", path.Join("templates", locale, name+".html"))
+	if err != nil {
+		return localized{}, err
+	}
+	if h.Lookup("content") == nil {
+		return localized{}, fmt.Errorf("html template does not define \"content\"")
+	}
+
+	body, err := fs.ReadFile(fsys, path.Join("templates", locale, name+".txt"))
+	if err != nil {
+		return localized{}, err
+	}
+	txt, err := texttemplate.New(name).Parse(string(body))
+	if err != nil {
+		return localized{}, err
+	}
+	if txt.Lookup("subject") == nil {
+		return localized{}, fmt.Errorf("text template does not define \"subject\"")
+	}
+	return localized{html: h, text: txt}, nil
+}
+
+// Names returns the template names in alphabetical order.
+func (t *Templates) Names() []string {
+	return append([]string(nil), t.names...)
+}
+
+// Has reports whether a template with the given name exists.
+func (t *Templates) Has(name string) bool {
+	_, ok := t.templates[name]
+	return ok
+}
+
+// Render renders the named template in the given locale. The locale is
+// normalized first, so any user preference is accepted.
+func (t *Templates) Render(name, locale string, data any) (*Rendered, error) {
+	byLocale, ok := t.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+	l := byLocale[NormalizeLocale(locale)]
+
+	var subject, text, html bytes.Buffer
+	if err := l.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("render %s subject: %w", name, err)
+	}
+	if err := l.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("render %s text: %w", name, err)
+	}
+	if err := l.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return nil, fmt.Errorf("render %s html: %w", name, err)
+	}
+	return &Rendered{
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,181 @@
+package mail
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{"en", "en"},
+		{"en-US", "en"},
+		{" UK_ua ", "uk"},
+		// Частая ошибка: код страны вместо кода языка
+		{"ua", "uk"},
+		{"de", DefaultLocale},
+		{"", DefaultLocale},
+	}
+	for _, tt := range tests {
+		if got := NormalizeLocale(tt.lang); got != tt.want {
+			t.Errorf("NormalizeLocale(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+// sampleData заполняет поля всех шаблонов; map вместо структур из services,
+// чтобы пропущенное поле было видно как "<no value>"
+func sampleData() map[string]any {
+	return map[string]any{
+		"Code":           "123456",
+		"ResetURL":       "https://neomovies.test/reset-password?token=t",
+		"Name":           "Alex",
+		"UnsubscribeURL": "https://neomovies.test/unsubscribe?token=t",
+		"Titles": []map[string]any{
+			{"Title": "Inception", "Year": "2010", "Rating": 8.4},
+		},
+		"Type":          "new_episode",
+		"Title":         "The Last of Us",
+		"SeasonNumber":  2,
+		"EpisodeNumber": 3,
+		"AirDate":       "2025-04-27",
+	}
+}
+
+func TestTemplatesRenderEveryLocale(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "email_change,notification,password_reset,recommendations,verification"
+	if got := strings.Join(templates.Names(), ","); got != want {
+		t.Fatalf("names = %s, want %s", got, want)
+	}
+
+	for _, name := range templates.Names() {
+		subjects := map[string]string{}
+		for _, locale := range Locales {
+			t.Run(locale+"/"+name, func(t *testing.T) {
+				r, err := templates.Render(name, locale, sampleData())
+				if err != nil {
+					t.Fatal(err)
+				}
+				for part, body := range map[string]string{"subject": r.Subject, "text": r.Text, "html": r.HTML} {
+					if strings.TrimSpace(body) == "" || strings.Contains(body, "<no value>") {
+						t.Errorf("%s = %q", part, body)
+					}
+				}
+				if strings.Contains(r.Subject, "\n") {
+					t.Errorf("multi-line subject %q", r.Subject)
+				}
+				if !strings.Contains(r.HTML, `<html lang="`+locale+`">`) {
+					t.Error("layout lang attribute is wrong")
+				}
+				subjects[locale] = r.Subject
+			})
+		}
+		// Перевод не должен совпадать с английским оригиналом
+		if subjects["ru"] == subjects["en"] || subjects["uk"] == subjects["en"] {
+			t.Errorf("%s is not translated: %v", name, subjects)
+		}
+	}
+}
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := sampleData()
+	data["Title"] = `<script>alert(1)</script>`
+	r, err := templates.Render("notification", "en-GB", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// В HTML пользовательский ввод экранируется, в тексте остается как есть
+	if strings.Contains(r.HTML, "<script>") || !strings.Contains(r.HTML, "&lt;script&gt;") {
+		t.Errorf("html = %s", r.HTML)
+	}
+	if !strings.Contains(r.Text, "<script>") || !strings.HasSuffix(r.Text, "\n") {
+		t.Errorf("text = %q", r.Text)
+	}
+
+	// Неизвестный язык — шаблон по умолчанию
+	fallback, err := templates.Render("verification", "de", sampleData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ru, _ := templates.Render("verification", DefaultLocale, sampleData())
+	if fallback.Subject != ru.Subject {
+		t.Errorf("subject = %q, want %q", fallback.Subject, ru.Subject)
+	}
+
+	if _, err := templates.Render("welcome", "en", nil); err == nil {
+		t.Error("unknown template rendered")
+	}
+	if !templates.Has("verification") || templates.Has("welcome") {
+		t.Error("Has is wrong")
+	}
+}
+
+func TestLoadTemplatesValidates(t *testing.T) {
+	layout := &fstest.MapFile{Data: []byte(`{{define "layout"}}<html lang="{{lang}}">{{template "content" .}}</html>{{end}}`)}
+	complete := func() fstest.MapFS {
+		fsys := fstest.MapFS{"templates/layout.html": layout}
+		for _, locale := range Locales {
+			fsys["templates/"+locale+"/hello.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}<p>{{.Name}}</p>{{end}}`)}
+			fsys["templates/"+locale+"/hello.txt"] = &fstest.MapFile{Data: []byte(`{{define "subject"}}Hi{{end}}{{.Name}}`)}
+		}
+		return fsys
+	}
+
+	tests := []struct {
+		name   string
+		modify func(fstest.MapFS)
+		want   string
+	}{
+		{"valid", func(fstest.MapFS) {}, ""},
+		// Перевод на каждый язык обязателен
+		{"missing translation", func(fsys fstest.MapFS) { delete(fsys, "templates/uk/hello.txt") }, "template uk/hello"},
+		{"no subject", func(fsys fstest.MapFS) {
+			fsys["templates/en/hello.txt"] = &fstest.MapFile{Data: []byte(`{{.Name}}`)}
+		}, `does not define "subject"`},
+		{"no content", func(fsys fstest.MapFS) {
+			fsys["templates/en/hello.html"] = &fstest.MapFile{Data: []byte(`<p>{{.Name}}</p>`)}
+		}, `does not define "content"`},
+		{"syntax error", func(fsys fstest.MapFS) {
+			fsys["templates/ru/hello.txt"] = &fstest.MapFile{Data: []byte(`{{define "subject"}}Hi{{end}}{{.Name`)}
+		}, "template ru/hello"},
+		{"no templates", func(fsys fstest.MapFS) {
+			for _, locale := range Locales {
+				delete(fsys, "templates/"+locale+"/hello.txt")
+				delete(fsys, "templates/"+locale+"/hello.html")
+			}
+			fsys["templates/ru/readme.md"] = &fstest.MapFile{}
+		}, "no email templates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := complete()
+			tt.modify(fsys)
+			templates, err := loadTemplates(fsys)
+			if tt.want == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				r, err := templates.Render("hello", "uk", map[string]string{"Name": "Neo"})
+				if err != nil || r.Subject != "Hi" || r.Text != "Neo\n" || r.HTML != `<html lang="uk"><p>Neo</p></html>` {
+					t.Errorf("rendered = %+v, err = %v", r, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"neomovies-api/pkg/cache"
+)
+
+// cacheCollector exposes the counters of a cache.Cache, read at scrape time.
+type cacheCollector struct {
+	stats func() cache.Stats
+
+	hits    *prometheus.Desc
+	misses  *prometheus.Desc
+	sets    *prometheus.Desc
+	entries *prometheus.Desc
+}
+
+// RegisterCache exposes hit/miss/set counters and the entry count of c
+// under the given cache name. Registering the same name twice is a no-op.
+func RegisterCache(name string, c cache.Cache) {
+	labels := prometheus.Labels{"cache": name}
+	collector := &cacheCollector{
+		stats:   c.Stats,
+		hits:    prometheus.NewDesc(namespace+"_cache_hits_total", "Cache lookups served from the cache.", nil, labels),
+		misses:  prometheus.NewDesc(namespace+"_cache_misses_total", "Cache lookups that missed.", nil, labels),
+		sets:    prometheus.NewDesc(namespace+"_cache_sets_total", "Entries written to the cache.", nil, labels),
+		entries: prometheus.NewDesc(namespace+"_cache_entries", "Entries currently stored in the cache.", nil, labels),
+	}
+	if err := Registry.Register(collector); err != nil {
+		if _, ok := err.(prometheus.AlreadyRegisteredError); !ok {
+			panic(err)
+		}
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.sets
+	ch <- c.entries
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	s := c.stats()
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(s.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(s.Misses))
+	ch <- prometheus.MustNewConstMetric(c.sets, prometheus.CounterValue, float64(s.Sets))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(s.Entries))
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.mongodb.org/mongo-driver/event"
+
+	"neomovies-api/pkg/cache"
+)
+
+func TestObserveHTTPRequest(t *testing.T) {
+	before := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404"))
+	ObserveHTTPRequest("GET", "", http.StatusNotFound, time.Millisecond)
+	if got := testutil.ToFloat64(httpRequests.WithLabelValues("GET", "unmatched", "404")); got != before+1 {
+		t.Errorf("unmatched requests = %v, want %v", got, before+1)
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	handler := Handler("scrape-token")
+
+	tests := []struct {
+		header string
+		status int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer scrape-token", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("Authorization %q: status = %d, want %d", tt.header, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestHandlerExposesMetrics(t *testing.T) {
+	ObserveHTTPRequest("GET", "/api/v1/health", http.StatusOK, time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	Handler("").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	for _, name := range []string{"neomovies_http_requests_total", "neomovies_http_request_duration_seconds", "go_goroutines"} {
+		if !strings.Contains(body, name) {
+			t.Errorf("%s is not exposed", name)
+		}
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportLabels(t *testing.T) {
+	ok := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTeapot, Body: http.NoBody}, nil
+	})
+	failing := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	tests := []struct {
+		url      string
+		base     http.RoundTripper
+		provider string
+		status   string
+	}{
+		{"https://api.themoviedb.org/3/movie/550", ok, "tmdb", "418"},
+		{"https://cdn.vibix.org/x", ok, "vibix", "418"},
+		{"https://redapi.example/search", ok, "redapi", "418"},
+		{"https://api.themoviedb.org/3/movie/550", failing, "tmdb", "error"},
+	}
+	for _, tt := range tests {
+		transport := NewTransport("redapi", tt.base)
+		counter := upstreamRequests.WithLabelValues(tt.provider, tt.status)
+		before := testutil.ToFloat64(counter)
+
+		req := httptest.NewRequest(http.MethodGet, tt.url, nil)
+		transport.RoundTrip(req)
+
+		if got := testutil.ToFloat64(counter); got != before+1 {
+			t.Errorf("%s: upstream_requests_total{provider=%q,status=%q} = %v, want %v", tt.url, tt.provider, tt.status, got, before+1)
+		}
+	}
+
+	if got := NewTransport("", nil).provider("unknown.example"); got != "other" {
+		t.Errorf("provider = %q, want other", got)
+	}
+}
+
+func TestMongoCommandMonitor(t *testing.T) {
+	monitor := MongoCommandMonitor()
+	counter := mongoCommands.WithLabelValues("find", "failure")
+	before := testutil.ToFloat64(counter)
+
+	monitor.Failed(context.Background(), &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", DurationNanos: int64(time.Millisecond)},
+	})
+
+	if got := testutil.ToFloat64(counter); got != before+1 {
+		t.Errorf("mongo_commands_total = %v, want %v", got, before+1)
+	}
+}
+
+func TestRegisterCache(t *testing.T) {
+	c := cache.NewMemory(10)
+	c.Set(context.Background(), "k", []byte("v"), time.Minute)
+	c.Get(context.Background(), "k")
+
+	RegisterCache("test", c)
+	// Повторная регистрация не паникует
+	RegisterCache("test", c)
+
+	expected := `
+# HELP neomovies_cache_hits_total Cache lookups served from the cache.
+# TYPE neomovies_cache_hits_total counter
+neomovies_cache_hits_total{cache="test"} 1
+`
+	if err := testutil.GatherAndCompare(Registry, strings.NewReader(expected), "neomovies_cache_hits_total"); err != nil {
+		t.Error(err)
+	}
+}
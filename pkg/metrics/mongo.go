@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.mongodb.org/mongo-driver/event"
+)
+
+var (
+	mongoCommands = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "mongo_commands_total",
+		Help:      "MongoDB commands by command name and outcome.",
+	}, []string{"command", "status"})
+
+	mongoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_command_duration_seconds",
+		Help:      "Latency of MongoDB commands.",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"command"})
+
+	mongoPoolInUse = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "mongo_pool_connections_in_use",
+		Help:      "MongoDB connections currently checked out of the pool.",
+	})
+)
+
+// MongoCommandMonitor records count and latency of every MongoDB command.
+func MongoCommandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, e *event.CommandSucceededEvent) {
+			observeMongoCommand(e.CommandName, "success", time.Duration(e.DurationNanos))
+		},
+		Failed: func(_ context.Context, e *event.CommandFailedEvent) {
+			observeMongoCommand(e.CommandName, "failure", time.Duration(e.DurationNanos))
+		},
+	}
+}
+
+// MongoPoolMonitor tracks how many pooled connections are in use.
+func MongoPoolMonitor() *event.PoolMonitor {
+	return &event.PoolMonitor{
+		Event: func(e *event.PoolEvent) {
+			switch e.Type {
+			case event.GetSucceeded:
+				mongoPoolInUse.Inc()
+			case event.ConnectionReturned:
+				mongoPoolInUse.Dec()
+			}
+		},
+	}
+}
+
+func observeMongoCommand(command, status string, duration time.Duration) {
+	mongoCommands.WithLabelValues(command, status).Inc()
+	mongoDuration.WithLabelValues(command).Observe(duration.Seconds())
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// knownHosts maps upstream hosts to provider labels. Requests to other
+// hosts are labelled with the provider of the client that made them.
+var knownHosts = map[string]string{
+	"api.themoviedb.org":    "tmdb",
+	"image.tmdb.org":        "tmdb_images",
+	"api.alloha.tv":         "alloha",
+	"cub.rip":               "cubrip",
+	"vibix.org":             "vibix",
+	"oauth2.googleapis.com": "google",
+	"www.googleapis.com":    "google",
+}
+
+// Transport is an http.RoundTripper that records count, latency and status
+// of every outgoing request.
+type Transport struct {
+	Provider string
+	Base     http.RoundTripper
+}
+
+// NewTransport wraps base (http.DefaultTransport when nil) for the given provider.
+func NewTransport(provider string, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Provider: provider, Base: base}
+}
+
+// NewClient returns an instrumented http.Client with the given timeout.
+func NewClient(provider string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: NewTransport(provider, nil),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	provider := t.provider(req.URL.Hostname())
+	start := time.Now()
+
+	resp, err := t.Base.RoundTrip(req)
+
+	upstreamDuration.WithLabelValues(provider).Observe(time.Since(start).Seconds())
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	upstreamRequests.WithLabelValues(provider, status).Inc()
+
+	return resp, err
+}
+
+func (t *Transport) provider(host string) string {
+	host = strings.ToLower(host)
+	if provider, ok := knownHosts[host]; ok {
+		return provider
+	}
+	for known, provider := range knownHosts {
+		if strings.HasSuffix(host, "."+known) {
+			return provider
+		}
+	}
+	if t.Provider != "" {
+		return t.Provider
+	}
+	return "other"
+}
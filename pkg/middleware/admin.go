@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"neomovies-api/pkg/apperr"
+)
+
+var errAdminRequired = apperr.Forbidden("Admin privileges required").WithCode("admin_required")
+
+// AdminChecker reports whether a user has admin privileges.
+type AdminChecker interface {
+	IsAdmin(ctx context.Context, userID string) (bool, error)
+}
+
+// RequireAdmin lets through only admins. It must run after JWTAuth, which
+// puts the user ID into the request context.
+func RequireAdmin(admins AdminChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := GetUserIDFromContext(r.Context())
+			if !ok || userID == "" {
+				apperr.Write(w, r, apperr.Unauthorized("User ID not found in context"))
+				return
+			}
+
+			isAdmin, err := admins.IsAdmin(r.Context(), userID)
+			if err != nil {
+				apperr.Write(w, r, err)
+				return
+			}
+			if !isAdmin {
+				apperr.Write(w, r, errAdminRequired)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
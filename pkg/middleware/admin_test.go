@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type adminFunc func(ctx context.Context, userID string) (bool, error)
+
+func (f adminFunc) IsAdmin(ctx context.Context, userID string) (bool, error) {
+	return f(ctx, userID)
+}
+
+func TestRequireAdmin(t *testing.T) {
+	admins := adminFunc(func(_ context.Context, userID string) (bool, error) {
+		switch userID {
+		case "admin":
+			return true, nil
+		case "broken":
+			return false, errors.New("connection refused")
+		}
+		return false, nil
+	})
+	handler := RequireAdmin(admins)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name   string
+		userID string
+		status int
+	}{
+		// Без JWTAuth в контексте нет пользователя
+		{"no user", "", http.StatusUnauthorized},
+		{"regular user", "user-1", http.StatusForbidden},
+		{"lookup fails", "broken", http.StatusInternalServerError},
+		{"admin", "admin", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/admin/stats", nil)
+			if tt.userID != "" {
+				r = r.WithContext(context.WithValue(r.Context(), UserIDKey, tt.userID))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
@@ -6,24 +6,38 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/logger"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey    contextKey = "userID"
+	TokenIDKey   contextKey = "tokenID"
+	SessionIDKey contextKey = "sessionID"
+)
+
+var errInvalidToken = apperr.Unauthorized("Invalid token").WithCode("invalid_token")
+
+// RevocationChecker reports whether an access token (by jti) has been revoked.
+type RevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
 
-func JWTAuth(secret string) func(http.Handler) http.Handler {
+func JWTAuth(secret string, revocations RevocationChecker) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				apperr.Write(w, r, apperr.Unauthorized("Authorization header required").WithCode("missing_token"))
 				return
 			}
 
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenString == authHeader {
-				http.Error(w, "Bearer token required", http.StatusUnauthorized)
+				apperr.Write(w, r, apperr.Unauthorized("Bearer token required").WithCode("missing_token"))
 				return
 			}
 
@@ -35,23 +49,47 @@ func JWTAuth(secret string) func(http.Handler) http.Handler {
 			})
 
 			if err != nil || !token.Valid {
-				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				apperr.Write(w, r, errInvalidToken)
 				return
 			}
 
 			claims, ok := token.Claims.(jwt.MapClaims)
 			if !ok {
-				http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+				apperr.Write(w, r, errInvalidToken)
 				return
 			}
 
 			userID, ok := claims["user_id"].(string)
 			if !ok {
-				http.Error(w, "Invalid user ID in token", http.StatusUnauthorized)
+				apperr.Write(w, r, errInvalidToken)
 				return
 			}
 
+			// Без jti токен нельзя отозвать, такие токены не принимаются
+			jti, _ := claims["jti"].(string)
+			if jti == "" {
+				apperr.Write(w, r, errInvalidToken)
+				return
+			}
+			sessionID, _ := claims["sid"].(string)
+
+			if revocations != nil {
+				revoked, err := revocations.IsTokenRevoked(r.Context(), jti)
+				if err != nil {
+					apperr.Write(w, r, err)
+					return
+				}
+				if revoked {
+					apperr.Write(w, r, apperr.Unauthorized("Token has been revoked").WithCode("token_revoked"))
+					return
+				}
+			}
+
+			logger.SetUserID(r.Context(), userID)
+
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
+			ctx = context.WithValue(ctx, TokenIDKey, jti)
+			ctx = context.WithValue(ctx, SessionIDKey, sessionID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -60,4 +98,16 @@ func JWTAuth(secret string) func(http.Handler) http.Handler {
 func GetUserIDFromContext(ctx context.Context) (string, bool) {
 	userID, ok := ctx.Value(UserIDKey).(string)
 	return userID, ok
-}
\ No newline at end of file
+}
+
+// GetTokenIDFromContext returns the jti of the access token used for the request.
+func GetTokenIDFromContext(ctx context.Context) string {
+	tokenID, _ := ctx.Value(TokenIDKey).(string)
+	return tokenID
+}
+
+// GetSessionIDFromContext returns the session the access token belongs to.
+func GetSessionIDFromContext(ctx context.Context) string {
+	sessionID, _ := ctx.Value(SessionIDKey).(string)
+	return sessionID
+}
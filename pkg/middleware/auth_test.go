@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type revokedSet map[string]bool
+
+func (s revokedSet) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	return s[jti], nil
+}
+
+func signToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func validClaims(jti string) jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"user_id": "user-1",
+		"sid":     "session-1",
+		"exp":     time.Now().Add(time.Minute).Unix(),
+	}
+	if jti != "" {
+		claims["jti"] = jti
+	}
+	return claims
+}
+
+func TestJWTAuth(t *testing.T) {
+	revoked := revokedSet{"revoked": true}
+	handler := JWTAuth("secret", revoked)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := GetUserIDFromContext(r.Context())
+		json.NewEncoder(w).Encode(map[string]string{
+			"user":    userID,
+			"jti":     GetTokenIDFromContext(r.Context()),
+			"session": GetSessionIDFromContext(r.Context()),
+		})
+	}))
+
+	expired := validClaims("expired")
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+		code   string
+	}{
+		{"valid", "Bearer " + signToken(t, "secret", validClaims("jti-1")), http.StatusOK, ""},
+		{"missing header", "", http.StatusUnauthorized, "missing_token"},
+		{"not bearer", "Token abc", http.StatusUnauthorized, "missing_token"},
+		{"wrong secret", "Bearer " + signToken(t, "other", validClaims("jti-1")), http.StatusUnauthorized, "invalid_token"},
+		{"expired", "Bearer " + signToken(t, "secret", expired), http.StatusUnauthorized, "invalid_token"},
+		{"without jti", "Bearer " + signToken(t, "secret", validClaims("")), http.StatusUnauthorized, "invalid_token"},
+		{"revoked", "Bearer " + signToken(t, "secret", validClaims("revoked")), http.StatusUnauthorized, "token_revoked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/profile", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if tt.code != "" && body["code"] != tt.code {
+				t.Errorf("code = %v, want %s", body["code"], tt.code)
+			}
+			if tt.status == http.StatusOK && (body["user"] != "user-1" || body["jti"] != "jti-1" || body["session"] != "session-1") {
+				t.Errorf("context = %v", body)
+			}
+		})
+	}
+}
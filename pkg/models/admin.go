@@ -0,0 +1,53 @@
+package models
+
+// AdminUserFilter narrows the admin user listing. Query matches a substring
+// of the email or name, case-insensitively.
+type AdminUserFilter struct {
+	Query  string
+	Banned *bool
+}
+
+type BanUserRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// AdminStats is an overview of users and their activity.
+type AdminStats struct {
+	Users     UserStats     `json:"users"`
+	Favorites FavoriteStats `json:"favorites"`
+	Reactions ReactionStats `json:"reactions"`
+	Emails    EmailStats    `json:"emails"`
+}
+
+type UserStats struct {
+	Total    int64 `json:"total"`
+	Verified int64 `json:"verified"`
+	Banned   int64 `json:"banned"`
+	Admins   int64 `json:"admins"`
+}
+
+type FavoriteStats struct {
+	Total       int64            `json:"total"`
+	ByMediaType map[string]int64 `json:"byMediaType"`
+	Top         []MediaCount     `json:"top"`
+}
+
+type ReactionStats struct {
+	Total  int64            `json:"total"`
+	ByType map[string]int64 `json:"byType"`
+	Top    []MediaCount     `json:"top"`
+}
+
+// EmailStats counts email_outbox entries by status. Sent and dead emails
+// are removed after a while, so these are recent numbers.
+type EmailStats struct {
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"byStatus"`
+}
+
+// MediaCount is the number of favorites or reactions of one title.
+type MediaCount struct {
+	MediaID   string `json:"mediaId" bson:"mediaId"`
+	MediaType string `json:"mediaType" bson:"mediaType"`
+	Count     int64  `json:"count" bson:"count"`
+}
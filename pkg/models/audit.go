@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Действия, которые пишутся в журнал аудита
+const (
+	AuditProfileUpdated       = "profile.updated"
+	AuditEmailChangeRequested = "email.change_requested"
+	AuditEmailChanged         = "email.changed"
+	AuditPasswordChanged      = "password.changed"
+	AuditPasswordReset        = "password.reset"
+	AuditOAuthLinked          = "oauth.linked"
+	AuditOAuthUnlinked        = "oauth.unlinked"
+)
+
+// AuditEntry is a security-relevant change to a user account. ActorID is
+// the user who made the change; it equals UserID for self-service changes.
+type AuditEntry struct {
+	ID        primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	UserID    string                 `json:"userId" bson:"userId"`
+	ActorID   string                 `json:"actorId" bson:"actorId"`
+	Action    string                 `json:"action" bson:"action"`
+	Changes   map[string]AuditChange `json:"changes,omitempty" bson:"changes,omitempty"`
+	RequestID string                 `json:"requestId,omitempty" bson:"requestId,omitempty"`
+	RemoteIP  string                 `json:"remoteIp,omitempty" bson:"remoteIp,omitempty"`
+	UserAgent string                 `json:"userAgent,omitempty" bson:"userAgent,omitempty"`
+	CreatedAt time.Time              `json:"createdAt" bson:"createdAt"`
+}
+
+// AuditChange holds the old and new value of a field. Secrets such as
+// passwords are never recorded, only the fact that they changed.
+type AuditChange struct {
+	From interface{} `json:"from,omitempty" bson:"from,omitempty"`
+	To   interface{} `json:"to,omitempty" bson:"to,omitempty"`
+}
+
+// Действия администраторов
+const (
+	AuditAdminUserBanned    = "admin.user_banned"
+	AuditAdminUserUnbanned  = "admin.user_unbanned"
+	AuditAdminEmailVerified = "admin.email_verified"
+	AuditAdminCachePurged   = "admin.cache_purged"
+	AuditAdminEmailRetried  = "admin.email_retried"
+)
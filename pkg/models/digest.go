@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// DigestSubscription is the user's recommendation digest setting.
+type DigestSubscription struct {
+	Subscribed bool       `json:"subscribed"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+}
+
+type DigestSubscriptionRequest struct {
+	Subscribed *bool `json:"subscribed" validate:"required"`
+}
+
+// DigestTokenRequest carries the signed token from a digest email link.
+type DigestTokenRequest struct {
+	Token string `json:"token" validate:"required,max=256"`
+}
+
+// DigestUnsubscribeResponse carries the token that undoes the unsubscribe.
+type DigestUnsubscribeResponse struct {
+	ResubscribeToken string `json:"resubscribeToken"`
+}
+
+// DigestItem is a title already recommended to the user. Items expire, so
+// a title may be recommended again after a long time.
+type DigestItem struct {
+	UserID    string    `json:"userId" bson:"userId"`
+	MediaType string    `json:"mediaType" bson:"mediaType"`
+	MediaID   string    `json:"mediaId" bson:"mediaId"`
+	SentAt    time.Time `json:"sentAt" bson:"sentAt"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserList is a named, ordered collection of movies and TV shows.
+type UserList struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      string             `json:"userId" bson:"userId"`
+	Name        string             `json:"name" bson:"name"`
+	Description string             `json:"description" bson:"description"`
+	Slug        string             `json:"slug" bson:"slug"`
+	IsPublic    bool               `json:"isPublic" bson:"isPublic"`
+	Items       []ListItem         `json:"items" bson:"items"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type ListItem struct {
+	MediaID    string    `json:"mediaId" bson:"mediaId"`
+	MediaType  string    `json:"mediaType" bson:"mediaType"` // "movie" or "tv"
+	Title      string    `json:"title" bson:"title"`
+	PosterPath string    `json:"posterPath" bson:"posterPath"`
+	AddedAt    time.Time `json:"addedAt" bson:"addedAt"`
+}
+
+type CreateListRequest struct {
+	Name        string `json:"name" validate:"required,notblank,max=100"`
+	Description string `json:"description" validate:"max=1000"`
+	IsPublic    bool   `json:"isPublic"`
+}
+
+type UpdateListRequest struct {
+	Name        *string `json:"name,omitempty" validate:"omitempty,notblank,max=100"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+	IsPublic    *bool   `json:"isPublic,omitempty"`
+}
+
+type ListItemRequest struct {
+	MediaID   string `json:"mediaId" validate:"required"`
+	MediaType string `json:"mediaType" validate:"required,oneof=movie tv"`
+}
+
+// ReorderListRequest lists every item of the list in the desired order.
+type ReorderListRequest struct {
+	Items []ListItemRequest `json:"items" validate:"required,max=500,dive"`
+}
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Movie struct {
 	ID               int                    `json:"id"`
 	Title            string                 `json:"title"`
@@ -214,6 +216,16 @@ type APIResponse struct {
 	Message string      `json:"message,omitempty"`
 }
 
+// ErrorResponse — единый формат ошибок API. Code стабилен и предназначен
+// для клиентов, Error — человекочитаемое сообщение
+type ErrorResponse struct {
+	Success   bool        `json:"success"`
+	Error     string      `json:"error" validate:"required"`
+	Code      string      `json:"code" validate:"required"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
+}
+
 // Модели для торрентов
 type TorrentResult struct {
 	Title       string    `json:"title"`
@@ -301,11 +313,14 @@ type PlayerResponse struct {
 
 // Модели для реакций
 type Reaction struct {
-	ID       string `json:"id" bson:"_id,omitempty"`
-	UserID   string `json:"userId" bson:"userId"`
-	MediaID  string `json:"mediaId" bson:"mediaId"`
-	Type     string `json:"type" bson:"type"`
-	Created  string `json:"created" bson:"created"`
+	ID        string    `json:"id" bson:"_id,omitempty"`
+	UserID    string    `json:"userId" bson:"userId"`
+	MediaID   string    `json:"mediaId" bson:"mediaId"`
+	MediaType string    `json:"mediaType" bson:"mediaType"`
+	Type      string    `json:"type" bson:"type"`
+	Created   string    `json:"created,omitempty" bson:"created,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
 type ReactionCounts struct {
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Notification types.
+const (
+	NotificationNewEpisode = "new_episode"
+	NotificationRelease    = "release"
+)
+
+// Follow is a movie or TV show the user wants to hear about. The Last*
+// fields hold the latest aired episode the user has been told about and
+// Released whether the movie is out, so the scheduler only notifies about
+// what changed since.
+type Follow struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID      string             `json:"userId" bson:"userId"`
+	MediaID     string             `json:"mediaId" bson:"mediaId"`
+	MediaType   string             `json:"mediaType" bson:"mediaType"` // "movie" or "tv"
+	Title       string             `json:"title" bson:"title"`
+	PosterPath  string             `json:"posterPath" bson:"posterPath"`
+	LastSeason  int                `json:"lastSeason,omitempty" bson:"lastSeason"`
+	LastEpisode int                `json:"lastEpisode,omitempty" bson:"lastEpisode"`
+	ReleaseDate string             `json:"releaseDate,omitempty" bson:"releaseDate,omitempty"`
+	Released    bool               `json:"released,omitempty" bson:"released"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// Notification is an in-app message about a followed title. Key is unique
+// per user, so the same event never produces two notifications.
+type Notification struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID        string             `json:"-" bson:"userId"`
+	Key           string             `json:"-" bson:"key"`
+	Type          string             `json:"type" bson:"type"`
+	MediaID       string             `json:"mediaId" bson:"mediaId"`
+	MediaType     string             `json:"mediaType" bson:"mediaType"`
+	Title         string             `json:"title" bson:"title"`
+	PosterPath    string             `json:"posterPath,omitempty" bson:"posterPath,omitempty"`
+	SeasonNumber  int                `json:"seasonNumber,omitempty" bson:"seasonNumber,omitempty"`
+	EpisodeNumber int                `json:"episodeNumber,omitempty" bson:"episodeNumber,omitempty"`
+	AirDate       string             `json:"airDate,omitempty" bson:"airDate,omitempty"`
+	Read          bool               `json:"read" bson:"read"`
+	ReadAt        *time.Time         `json:"readAt,omitempty" bson:"readAt,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// NotificationSettings are the user's delivery options. In-app
+// notifications are always created.
+type NotificationSettings struct {
+	Email bool `json:"email"`
+}
+
+type NotificationSettingsRequest struct {
+	Email *bool `json:"email" validate:"required"`
+}
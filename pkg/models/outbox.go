@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Outbox statuses. A failed delivery goes back to pending with a later
+// NextAttemptAt until it runs out of attempts and becomes dead.
+const (
+	OutboxPending = "pending"
+	OutboxSending = "sending"
+	OutboxSent    = "sent"
+	OutboxDead    = "dead"
+)
+
+// OutboxEmail is a queued email. The body is kept only until delivery, and
+// for an email with a DeliverBefore deadline also removed when it is
+// dead-lettered: it may contain verification codes and reset links. Other
+// dead emails keep the body so that they can be retried.
+type OutboxEmail struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Key           string             `json:"key" bson:"key"`
+	Kind          string             `json:"kind" bson:"kind"`
+	To            []string           `json:"to" bson:"to"`
+	Subject       string             `json:"subject" bson:"subject"`
+	Text          string             `json:"-" bson:"text,omitempty"`
+	HTML          string             `json:"-" bson:"html,omitempty"`
+	Headers       map[string]string  `json:"-" bson:"headers,omitempty"`
+	Status        string             `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	LastError     string             `json:"lastError,omitempty" bson:"lastError,omitempty"`
+	NextAttemptAt time.Time          `json:"nextAttemptAt" bson:"nextAttemptAt"`
+	DeliverBefore *time.Time         `json:"deliverBefore,omitempty" bson:"deliverBefore,omitempty"`
+	LockedUntil   *time.Time         `json:"-" bson:"lockedUntil,omitempty"`
+	SentAt        *time.Time         `json:"sentAt,omitempty" bson:"sentAt,omitempty"`
+	PurgeAt       *time.Time         `json:"-" bson:"purgeAt,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// OutboxFilter narrows the admin outbox listing.
+type OutboxFilter struct {
+	Status string
+	To     string
+}
@@ -0,0 +1,21 @@
+package models
+
+// PageRequest describes cursor-based pagination, sorting and filtering
+// parameters of a list endpoint.
+type PageRequest struct {
+	Cursor    string
+	Limit     int
+	SortBy    string // "createdAt" or "title"
+	Order     string // "asc" or "desc"
+	MediaType string // "movie", "tv" or empty for all
+}
+
+// PaginatedResponse is the shared envelope of paginated list endpoints.
+type PaginatedResponse struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"nextCursor,omitempty"`
+	HasMore    bool        `json:"hasMore"`
+	Limit      int         `json:"limit"`
+	SortBy     string      `json:"sortBy"`
+	Order      string      `json:"order"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session is a refresh-token backed login on a single device.
+type Session struct {
+	ID                primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID            string             `json:"userId" bson:"userId"`
+	RefreshTokenHash  string             `json:"-" bson:"refreshTokenHash"`
+	PreviousTokenHash string             `json:"-" bson:"previousTokenHash,omitempty"`
+	AccessTokenID     string             `json:"-" bson:"accessTokenId"`
+	CreatedAt         time.Time          `json:"createdAt" bson:"createdAt"`
+	LastUsedAt        time.Time          `json:"lastUsedAt" bson:"lastUsedAt"`
+	ExpiresAt         time.Time          `json:"expiresAt" bson:"expiresAt"`
+	RevokedAt         *time.Time         `json:"revokedAt,omitempty" bson:"revokedAt,omitempty"`
+}
+
+// RevokedToken is an access token jti that must be rejected until it expires.
+type RevokedToken struct {
+	ID        string    `bson:"_id"`
+	UserID    string    `bson:"userId"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refreshToken" validate:"required"`
+}
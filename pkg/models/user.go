@@ -16,12 +16,35 @@ type User struct {
 	Verified           bool               `json:"verified" bson:"verified"`
 	VerificationCode   string             `json:"-" bson:"verificationCode,omitempty"`
 	VerificationExpires time.Time         `json:"-" bson:"verificationExpires,omitempty"`
+	VerificationAttempts int              `json:"-" bson:"verificationAttempts,omitempty"`
+	ResetPasswordToken   string           `json:"-" bson:"resetPasswordToken,omitempty"`
+	ResetPasswordExpires time.Time        `json:"-" bson:"resetPasswordExpires,omitempty"`
+	PendingEmail        string            `json:"-" bson:"pendingEmail,omitempty"`
+	PendingEmailCode    string            `json:"-" bson:"pendingEmailCode,omitempty"`
+	PendingEmailExpires time.Time         `json:"-" bson:"pendingEmailExpires,omitempty"`
+	PendingEmailAttempts int              `json:"-" bson:"pendingEmailAttempts,omitempty"`
+	Preferences        UserPreferences    `json:"preferences" bson:"preferences"`
 	IsAdmin            bool               `json:"isAdmin" bson:"isAdmin"`
+	Banned             bool               `json:"banned,omitempty" bson:"banned,omitempty"`
+	BannedAt           *time.Time         `json:"bannedAt,omitempty" bson:"bannedAt,omitempty"`
+	BanReason          string             `json:"banReason,omitempty" bson:"banReason,omitempty"`
 	AdminVerified      bool               `json:"adminVerified" bson:"adminVerified"`
 	CreatedAt          time.Time          `json:"created_at" bson:"createdAt"`
 	UpdatedAt          time.Time          `json:"updated_at" bson:"updatedAt"`
 	Provider           string             `json:"provider,omitempty" bson:"provider,omitempty"`
-	GoogleID           string             `json:"googleId,omitempty" bson:"googleId,omitempty"`
+	Identities         []LinkedIdentity   `json:"identities,omitempty" bson:"identities,omitempty"`
+	DigestSubscribed   bool               `json:"digestSubscribed" bson:"digestSubscribed,omitempty"`
+	DigestSentAt       *time.Time         `json:"-" bson:"digestSentAt,omitempty"`
+	NotifyByEmail      bool               `json:"notifyByEmail" bson:"notifyByEmail,omitempty"`
+}
+
+// LinkedIdentity is an OAuth account (Google, GitHub, ...) the user can log
+// in with.
+type LinkedIdentity struct {
+	Provider string    `json:"provider" bson:"provider"`
+	Subject  string    `json:"-" bson:"subject"`
+	Email    string    `json:"email,omitempty" bson:"email,omitempty"`
+	LinkedAt time.Time `json:"linkedAt" bson:"linkedAt"`
 }
 
 type LoginRequest struct {
@@ -30,14 +53,18 @@ type LoginRequest struct {
 }
 
 type RegisterRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
-	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,password"`
+	Name     string `json:"name" validate:"required,notblank,max=100"`
+	// Language выбирает язык писем; сохраняется в preferences.language
+	Language string `json:"language,omitempty" validate:"omitempty,max=16"`
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	User  User   `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+	User         User   `json:"user"`
 }
 
 type VerifyEmailRequest struct {
@@ -47,4 +74,47 @@ type VerifyEmailRequest struct {
 
 type ResendCodeRequest struct {
 	Email string `json:"email" validate:"required,email"`
-}
\ No newline at end of file
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type OAuthExchangeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// UserPreferences are defaults the client applies to TMDB requests.
+// Language also selects the locale of emails sent to the user.
+type UserPreferences struct {
+	Language string `json:"language,omitempty" bson:"language,omitempty" validate:"omitempty,max=16"`
+	Region   string `json:"region,omitempty" bson:"region,omitempty" validate:"omitempty,len=2"`
+}
+
+// UpdateProfileRequest lists the only fields a user may change on their own
+// profile. Omitted fields are left as is, an empty avatar removes it, and
+// preferences are replaced as a whole.
+type UpdateProfileRequest struct {
+	Name        *string          `json:"name,omitempty" validate:"omitempty,notblank,max=100"`
+	Avatar      *string          `json:"avatar,omitempty" validate:"omitnil,max=2048,http_url|eq="`
+	Preferences *UserPreferences `json:"preferences,omitempty"`
+}
+
+type ChangeEmailRequest struct {
+	NewEmail string `json:"newEmail" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword" validate:"required"`
+	NewPassword     string `json:"newPassword" validate:"required,password"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WatchProgress is the playback state of a movie or of a single TV episode.
+type WatchProgress struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID        string             `json:"userId" bson:"userId"`
+	MediaID       string             `json:"mediaId" bson:"mediaId"`
+	MediaType     string             `json:"mediaType" bson:"mediaType"` // "movie" or "tv"
+	SeasonNumber  int                `json:"seasonNumber,omitempty" bson:"seasonNumber"`
+	EpisodeNumber int                `json:"episodeNumber,omitempty" bson:"episodeNumber"`
+	Title         string             `json:"title" bson:"title"`
+	PosterPath    string             `json:"posterPath" bson:"posterPath"`
+	Position      int                `json:"position" bson:"position"` // seconds
+	Duration      int                `json:"duration" bson:"duration"` // seconds
+	Completed     bool               `json:"completed" bson:"completed"`
+	CreatedAt     time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+type WatchProgressRequest struct {
+	MediaID       string `json:"mediaId" validate:"required"`
+	MediaType     string `json:"mediaType" validate:"required,oneof=movie tv"`
+	SeasonNumber  int    `json:"seasonNumber" validate:"required_if=MediaType tv,min=0"`
+	EpisodeNumber int    `json:"episodeNumber" validate:"required_if=MediaType tv,min=0"`
+	Position      int    `json:"position" validate:"min=0"`
+	Duration      int    `json:"duration" validate:"min=0"`
+	Completed     bool   `json:"completed"`
+}
+
+type EpisodeWatchedRequest struct {
+	SeasonNumber  int `json:"seasonNumber" validate:"required,min=1"`
+	EpisodeNumber int `json:"episodeNumber" validate:"required,min=1"`
+}
+
+// NextEpisode is the episode a user should watch next in a series.
+type NextEpisode struct {
+	MediaID       string   `json:"mediaId"`
+	SeasonNumber  int      `json:"seasonNumber"`
+	EpisodeNumber int      `json:"episodeNumber"`
+	Episode       *Episode `json:"episode,omitempty"`
+	Finished      bool     `json:"finished"`
+}
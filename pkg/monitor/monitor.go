@@ -1,91 +1,139 @@
 package monitor
 
 import (
-	"fmt"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
 	"net/http"
-	"strings"
 	"time"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/logger"
+	"neomovies-api/pkg/metrics"
 )
 
-// RequestMonitor создает middleware для мониторинга запросов в стиле htop
-func RequestMonitor() func(http.Handler) http.Handler {
+// RequestIDHeader is the header used to pass request IDs in and out.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestLogger создает middleware, которое присваивает запросу ID, пишет
+// структурированную строку access-лога после его завершения и обновляет
+// HTTP-метрики. Оборачивает весь обработчик, чтобы в лог попадали и
+// запросы без маршрута.
+func RequestLogger(log *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
-			
+
+			info := &logger.RequestInfo{ID: requestID(r), RemoteIP: remoteIP(r), UserAgent: r.UserAgent()}
+			w.Header().Set(RequestIDHeader, info.ID)
+
 			// Создаем wrapper для ResponseWriter чтобы получить статус код
 			ww := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
-			
-			// Выполняем запрос
-			next.ServeHTTP(ww, r)
-			
-			// Вычисляем время выполнения
+
+			next.ServeHTTP(ww, r.WithContext(logger.WithRequestInfo(r.Context(), info)))
+
 			duration := time.Since(start)
-			
-			// Форматируем URL (обрезаем если слишком длинный)
-			url := r.URL.Path
-			if r.URL.RawQuery != "" {
-				url += "?" + r.URL.RawQuery
-			}
-			if len(url) > 60 {
-				url = url[:57] + "..."
+			metrics.ObserveHTTPRequest(r.Method, info.Route, ww.statusCode, duration)
+
+			level := slog.LevelInfo
+			switch {
+			case ww.statusCode >= 500:
+				level = slog.LevelError
+			case ww.statusCode >= 400:
+				level = slog.LevelWarn
 			}
-			
-			// Определяем цвет статуса
-			statusColor := getStatusColor(ww.statusCode)
-			methodColor := getMethodColor(r.Method)
-			
-			// Выводим информацию о запросе
-			fmt.Printf("\033[2K\r%s%-6s\033[0m %s%-3d\033[0m │ %-60s │ %6.2fms\n",
-				methodColor, r.Method,
-				statusColor, ww.statusCode,
-				url,
-				float64(duration.Nanoseconds())/1000000,
+
+			log.LogAttrs(r.Context(), level, "request",
+				slog.String("request_id", info.ID),
+				slog.String("method", r.Method),
+				slog.String("path", logger.RedactURL(r.URL.RequestURI())),
+				slog.String("route", info.Route),
+				slog.Int("status", ww.statusCode),
+				slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+				slog.Int("bytes", ww.bytes),
+				slog.String("user_id", info.UserID),
+				slog.String("remote_ip", info.RemoteIP),
+				slog.String("user_agent", info.UserAgent),
 			)
 		})
 	}
 }
 
+// CaptureRoute — mux middleware, сохраняющее шаблон маршрута
+// (например /api/v1/movies/{id}) для access-лога.
+func CaptureRoute() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if route := mux.CurrentRoute(r); route != nil {
+				if template, err := route.GetPathTemplate(); err == nil {
+					logger.SetRoute(r.Context(), template)
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// requestID берет ID из входящего заголовка или генерирует новый
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); isValidRequestID(id) {
+		return id
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// isValidRequestID не пропускает в логи произвольный мусор из заголовка
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode  int
+	bytes       int
+	wroteHeader bool
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
+	if !rw.wroteHeader {
+		rw.statusCode = code
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(code)
 }
 
-// getStatusColor возвращает ANSI цвет для статус кода
-func getStatusColor(status int) string {
-	switch {
-	case status >= 200 && status < 300:
-		return "\033[32m" // Зеленый
-	case status >= 300 && status < 400:
-		return "\033[33m" // Желтый
-	case status >= 400 && status < 500:
-		return "\033[31m" // Красный
-	case status >= 500:
-		return "\033[35m" // Фиолетовый
-	default:
-		return "\033[37m" // Белый
-	}
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
 }
 
-// getMethodColor возвращает ANSI цвет для HTTP метода
-func getMethodColor(method string) string {
-	switch strings.ToUpper(method) {
-	case "GET":
-		return "\033[34m" // Синий
-	case "POST":
-		return "\033[32m" // Зеленый
-	case "PUT":
-		return "\033[33m" // Желтый
-	case "DELETE":
-		return "\033[31m" // Красный
-	case "PATCH":
-		return "\033[36m" // Циан
-	default:
-		return "\033[37m" // Белый
+// Flush нужен для потоковых ответов через обертку
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,107 @@
+package monitor
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"neomovies-api/pkg/logger"
+)
+
+func newTestRouter(buf *bytes.Buffer) http.Handler {
+	router := mux.NewRouter()
+	router.Use(CaptureRoute())
+	router.HandleFunc("/api/v1/movies/{id}", func(w http.ResponseWriter, r *http.Request) {
+		logger.SetUserID(r.Context(), "user-1")
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("short and stout"))
+	})
+	return RequestLogger(logger.New(buf, "json", "debug"))(router)
+}
+
+func accessLog(t *testing.T, buf *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("access log %q: %v", buf, err)
+	}
+	return entry
+}
+
+func TestRequestLoggerAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	handler := newTestRouter(&buf)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/movies/550?token=secret", nil)
+	req.RemoteAddr = "203.0.113.7:4321"
+	req.Header.Set("User-Agent", "test-agent")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	entry := accessLog(t, &buf)
+	want := map[string]interface{}{
+		"msg":        "request",
+		"level":      "WARN",
+		"method":     "GET",
+		"route":      "/api/v1/movies/{id}",
+		"status":     float64(http.StatusTeapot),
+		"bytes":      float64(len("short and stout")),
+		"user_id":    "user-1",
+		"remote_ip":  "203.0.113.7",
+		"user_agent": "test-agent",
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("%s = %v, want %v", key, entry[key], value)
+		}
+	}
+	if path := entry["path"].(string); strings.Contains(path, "secret") {
+		t.Errorf("path leaks the token: %s", path)
+	}
+	if entry["request_id"] != rec.Header().Get(RequestIDHeader) {
+		t.Errorf("request_id %v does not match the response header", entry["request_id"])
+	}
+}
+
+func TestRequestID(t *testing.T) {
+	generated := regexp.MustCompile(`^[0-9a-f]{32}$`)
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"generated", "", false},
+		{"propagated", "abc-123_DEF.4", true},
+		{"invalid characters", "bad id\n", false},
+		{"too long", strings.Repeat("a", 129), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			req := httptest.NewRequest(http.MethodGet, "/unknown", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			newTestRouter(&buf).ServeHTTP(rec, req)
+
+			got := rec.Header().Get(RequestIDHeader)
+			if tt.keep && got != tt.incoming {
+				t.Errorf("request ID = %q, want %q", got, tt.incoming)
+			}
+			if !tt.keep && !generated.MatchString(got) {
+				t.Errorf("request ID = %q, want a generated one", got)
+			}
+			// Запросы без маршрута тоже попадают в лог
+			if entry := accessLog(t, &buf); entry["status"] != float64(http.StatusNotFound) || entry["route"] != "" {
+				t.Errorf("entry = %v", entry)
+			}
+		})
+	}
+}
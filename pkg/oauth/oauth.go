@@ -0,0 +1,189 @@
+// Package oauth implements social login providers (Google, GitHub, Yandex,
+// VK) behind a single Provider interface. Every endpoint can be overridden
+// in Config, so a provider can be pointed at a local fake OAuth server.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// Supported lists the provider names accepted in /auth/{provider} routes.
+var Supported = []string{"google", "github", "yandex", "vk"}
+
+// Identity is the user profile reported by a provider.
+type Identity struct {
+	Provider string
+	// Subject is the stable user ID at the provider.
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
+
+// Provider is one OAuth 2.0 authorization code flow.
+type Provider interface {
+	Name() string
+	// AuthCodeURL returns the consent page URL carrying state and the PKCE
+	// challenge derived from verifier.
+	AuthCodeURL(state, verifier string) string
+	// Exchange trades the authorization code and its PKCE verifier for a
+	// token.
+	Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error)
+	// UserInfo fetches and maps the profile of the token owner.
+	UserInfo(ctx context.Context, token *oauth2.Token) (*Identity, error)
+}
+
+// Config configures a provider. Empty URLs fall back to the provider's
+// public endpoints.
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+
+	// HTTPClient is used for the token exchange and userinfo requests.
+	HTTPClient *http.Client
+}
+
+// Registry holds the configured providers by name.
+type Registry struct {
+	providers map[string]Provider
+}
+
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider, len(providers))}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+// Get returns the provider registered under name.
+func (r *Registry) Get(name string) (Provider, bool) {
+	if r == nil {
+		return nil, false
+	}
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+// Names returns the configured provider names in alphabetical order.
+func (r *Registry) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsSupported reports whether name is a known provider, configured or not.
+func IsSupported(name string) bool {
+	for _, s := range Supported {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// base implements the parts of Provider shared by all providers.
+type base struct {
+	name        string
+	config      *oauth2.Config
+	userInfoURL string
+	client      *http.Client
+}
+
+func newBase(name string, cfg Config, endpoint oauth2.Endpoint, userInfoURL string, scopes ...string) base {
+	if cfg.AuthURL != "" {
+		endpoint.AuthURL = cfg.AuthURL
+	}
+	if cfg.TokenURL != "" {
+		endpoint.TokenURL = cfg.TokenURL
+	}
+	if cfg.UserInfoURL != "" {
+		userInfoURL = cfg.UserInfoURL
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return base{
+		name: name,
+		config: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       scopes,
+			Endpoint:     endpoint,
+		},
+		userInfoURL: userInfoURL,
+		client:      client,
+	}
+}
+
+func (b base) Name() string { return b.name }
+
+func (b base) AuthCodeURL(state, verifier string) string {
+	return b.config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+}
+
+func (b base) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	return b.config.Exchange(context.WithValue(ctx, oauth2.HTTPClient, b.client), code, oauth2.VerifierOption(verifier))
+}
+
+// getJSON requests rawURL and decodes the JSON response into dst. authorize
+// adds the token to the request; by default it is sent as a bearer token.
+func (b base) getJSON(ctx context.Context, rawURL string, token *oauth2.Token, dst interface{}, authorize func(*http.Request)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if authorize != nil {
+		authorize(req)
+	} else {
+		token.SetAuthHeader(req)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// URL может содержать токен (VK), поэтому в ошибку он не попадает
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("%s userinfo request failed: %w", b.name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s userinfo request failed: status %d", b.name, resp.StatusCode)
+	}
+	if err := json.Unmarshal(body, dst); err != nil {
+		return fmt.Errorf("failed to parse %s userinfo: %w", b.name, err)
+	}
+	return nil
+}
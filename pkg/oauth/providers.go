@@ -0,0 +1,190 @@
+package oauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+// NewGoogle returns the Google OpenID Connect provider.
+func NewGoogle(cfg Config) Provider {
+	return google{newBase("google", cfg, endpoints.Google, "https://openidconnect.googleapis.com/v1/userinfo", "openid", "email", "profile")}
+}
+
+type google struct{ base }
+
+func (p google) UserInfo(ctx context.Context, token *oauth2.Token) (*Identity, error) {
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+		Picture       string `json:"picture"`
+	}
+	if err := p.getJSON(ctx, p.userInfoURL, token, &info, nil); err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Provider:      p.name,
+		Subject:       info.Sub,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		AvatarURL:     info.Picture,
+	}, nil
+}
+
+// NewGitHub returns the GitHub provider. The profile email may be hidden,
+// so the primary verified address is read from /user/emails.
+func NewGitHub(cfg Config) Provider {
+	return github{newBase("github", cfg, endpoints.GitHub, "https://api.github.com/user", "read:user", "user:email")}
+}
+
+type github struct{ base }
+
+func (p github) UserInfo(ctx context.Context, token *oauth2.Token) (*Identity, error) {
+	var info struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := p.getJSON(ctx, p.userInfoURL, token, &info, nil); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, p.userInfoURL+"/emails", token, &emails, nil); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider:  p.name,
+		Subject:   strconv.FormatInt(info.ID, 10),
+		Name:      info.Name,
+		AvatarURL: info.AvatarURL,
+	}
+	if identity.Name == "" {
+		identity.Name = info.Login
+	}
+	for _, e := range emails {
+		if e.Primary {
+			identity.Email, identity.EmailVerified = e.Email, e.Verified
+			break
+		}
+	}
+	return identity, nil
+}
+
+// NewYandex returns the Yandex ID provider.
+func NewYandex(cfg Config) Provider {
+	return yandex{newBase("yandex", cfg, endpoints.Yandex, "https://login.yandex.ru/info?format=json", "login:email", "login:info", "login:avatar")}
+}
+
+type yandex struct{ base }
+
+func (p yandex) UserInfo(ctx context.Context, token *oauth2.Token) (*Identity, error) {
+	var info struct {
+		ID              string `json:"id"`
+		DefaultEmail    string `json:"default_email"`
+		RealName        string `json:"real_name"`
+		DisplayName     string `json:"display_name"`
+		DefaultAvatarID string `json:"default_avatar_id"`
+		IsAvatarEmpty   bool   `json:"is_avatar_empty"`
+	}
+	// Яндекс ожидает схему OAuth вместо Bearer
+	authorize := func(req *http.Request) { req.Header.Set("Authorization", "OAuth "+token.AccessToken) }
+	if err := p.getJSON(ctx, p.userInfoURL, token, &info, authorize); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{
+		Provider: p.name,
+		Subject:  info.ID,
+		Email:    info.DefaultEmail,
+		// Адреса Яндекс ID принадлежат владельцу аккаунта
+		EmailVerified: info.DefaultEmail != "",
+		Name:          info.RealName,
+	}
+	if identity.Name == "" {
+		identity.Name = info.DisplayName
+	}
+	if !info.IsAvatarEmpty && info.DefaultAvatarID != "" {
+		identity.AvatarURL = "https://avatars.yandex.net/get-yapic/" + info.DefaultAvatarID + "/islands-200"
+	}
+	return identity, nil
+}
+
+// vkAPIVersion is the VK API version requested from users.get.
+const vkAPIVersion = "5.199"
+
+// NewVK returns the VK provider. VK returns the email together with the
+// token rather than from users.get.
+func NewVK(cfg Config) Provider {
+	endpoint := oauth2.Endpoint{
+		AuthURL:   "https://oauth.vk.com/authorize",
+		TokenURL:  "https://oauth.vk.com/access_token",
+		AuthStyle: oauth2.AuthStyleInParams,
+	}
+	return vk{newBase("vk", cfg, endpoint, "https://api.vk.com/method/users.get", "email")}
+}
+
+type vk struct{ base }
+
+func (p vk) UserInfo(ctx context.Context, token *oauth2.Token) (*Identity, error) {
+	query := url.Values{
+		"fields":       {"photo_200"},
+		"access_token": {token.AccessToken},
+		"v":            {vkAPIVersion},
+	}
+	sep := "?"
+	if strings.Contains(p.userInfoURL, "?") {
+		sep = "&"
+	}
+
+	var info struct {
+		Response []struct {
+			ID        int64  `json:"id"`
+			FirstName string `json:"first_name"`
+			LastName  string `json:"last_name"`
+			Photo200  string `json:"photo_200"`
+		} `json:"response"`
+		Error *struct {
+			Code    int    `json:"error_code"`
+			Message string `json:"error_msg"`
+		} `json:"error"`
+	}
+	// Токен передается в параметрах запроса, заголовок VK не принимает
+	authorize := func(*http.Request) {}
+	if err := p.getJSON(ctx, p.userInfoURL+sep+query.Encode(), token, &info, authorize); err != nil {
+		return nil, err
+	}
+	if info.Error != nil {
+		return nil, fmt.Errorf("vk users.get failed: %d %s", info.Error.Code, info.Error.Message)
+	}
+	if len(info.Response) == 0 {
+		return nil, fmt.Errorf("vk users.get returned no users")
+	}
+
+	user := info.Response[0]
+	email, _ := token.Extra("email").(string)
+	return &Identity{
+		Provider: p.name,
+		Subject:  strconv.FormatInt(user.ID, 10),
+		Email:    email,
+		// VK отдает только подтвержденный адрес
+		EmailVerified: email != "",
+		Name:          strings.TrimSpace(user.FirstName + " " + user.LastName),
+		AvatarURL:     user.Photo200,
+	}, nil
+}
@@ -0,0 +1,269 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const testVerifier = "test-verifier-0123456789-0123456789-0123456789"
+
+// fakeProvider поднимает токен-эндпоинт и userinfo одного провайдера.
+// Токен выдается только на код "code" с правильным PKCE verifier
+func fakeProvider(t *testing.T, tokenExtra map[string]interface{}, userinfo http.HandlerFunc) Config {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("code") != "code" || r.Form.Get("code_verifier") != testVerifier {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		resp := map[string]interface{}{"access_token": "access-token", "token_type": "bearer"}
+		for k, v := range tokenExtra {
+			resp[k] = v
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+	mux.HandleFunc("/", userinfo)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return Config{
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://api.test/callback",
+		AuthURL:      srv.URL + "/authorize",
+		TokenURL:     srv.URL + "/token",
+		UserInfoURL:  srv.URL + "/userinfo",
+	}
+}
+
+func requireAuth(t *testing.T, r *http.Request, want string) bool {
+	if got := r.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+		return false
+	}
+	return true
+}
+
+func login(t *testing.T, p Provider) *Identity {
+	t.Helper()
+	token, err := p.Exchange(context.Background(), "code", testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity, err := p.UserInfo(context.Background(), token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity
+}
+
+func TestGoogle(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool
+	}{
+		{"verified", true},
+		{"unverified", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fakeProvider(t, nil, func(w http.ResponseWriter, r *http.Request) {
+				if !requireAuth(t, r, "Bearer access-token") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"sub": "g-1", "email": "neo@gmail.com", "email_verified": tt.verified,
+					"name": "Neo", "picture": "https://lh3.googleusercontent.com/a",
+				})
+			})
+
+			got := *login(t, NewGoogle(cfg))
+			want := Identity{Provider: "google", Subject: "g-1", Email: "neo@gmail.com", EmailVerified: tt.verified, Name: "Neo", AvatarURL: "https://lh3.googleusercontent.com/a"}
+			if got != want {
+				t.Errorf("identity = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestGitHub(t *testing.T) {
+	tests := []struct {
+		name     string
+		emails   string
+		email    string
+		verified bool
+	}{
+		{"primary verified", `[{"email":"work@example.com","primary":false,"verified":true},{"email":"neo@example.com","primary":true,"verified":true}]`, "neo@example.com", true},
+		// Подтвержденный, но не основной адрес не используется
+		{"primary unverified", `[{"email":"work@example.com","primary":false,"verified":true},{"email":"neo@example.com","primary":true,"verified":false}]`, "neo@example.com", false},
+		{"no emails", `[]`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fakeProvider(t, nil, func(w http.ResponseWriter, r *http.Request) {
+				if !requireAuth(t, r, "Bearer access-token") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				switch r.URL.Path {
+				case "/userinfo":
+					w.Write([]byte(`{"id":42,"login":"neo","name":"","avatar_url":"https://avatars.githubusercontent.com/u/42"}`))
+				case "/userinfo/emails":
+					w.Write([]byte(tt.emails))
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			})
+
+			got := *login(t, NewGitHub(cfg))
+			// Без имени в профиле используется логин
+			want := Identity{Provider: "github", Subject: "42", Email: tt.email, EmailVerified: tt.verified, Name: "neo", AvatarURL: "https://avatars.githubusercontent.com/u/42"}
+			if got != want {
+				t.Errorf("identity = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestYandex(t *testing.T) {
+	tests := []struct {
+		name     string
+		info     string
+		email    string
+		verified bool
+		avatar   string
+	}{
+		{"with email", `{"id":"y-1","default_email":"neo@yandex.ru","display_name":"neo","default_avatar_id":"123/abc","is_avatar_empty":false}`,
+			"neo@yandex.ru", true, "https://avatars.yandex.net/get-yapic/123/abc/islands-200"},
+		{"without email", `{"id":"y-1","display_name":"neo","default_avatar_id":"0/0-0","is_avatar_empty":true}`, "", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := fakeProvider(t, nil, func(w http.ResponseWriter, r *http.Request) {
+				// Яндекс принимает токен только со схемой OAuth
+				if !requireAuth(t, r, "OAuth access-token") {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.Write([]byte(tt.info))
+			})
+
+			got := *login(t, NewYandex(cfg))
+			want := Identity{Provider: "yandex", Subject: "y-1", Email: tt.email, EmailVerified: tt.verified, Name: "neo", AvatarURL: tt.avatar}
+			if got != want {
+				t.Errorf("identity = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestVK(t *testing.T) {
+	userinfo := func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("access_token") != "access-token" || q.Get("v") != vkAPIVersion {
+			w.Write([]byte(`{"error":{"error_code":5,"error_msg":"User authorization failed"}}`))
+			return
+		}
+		w.Write([]byte(`{"response":[{"id":7,"first_name":"Thomas","last_name":"Anderson","photo_200":"https://vk.com/p.jpg"}]}`))
+	}
+
+	t.Run("email with token", func(t *testing.T) {
+		cfg := fakeProvider(t, map[string]interface{}{"email": "neo@vk.com", "user_id": 7}, userinfo)
+		got := *login(t, NewVK(cfg))
+		want := Identity{Provider: "vk", Subject: "7", Email: "neo@vk.com", EmailVerified: true, Name: "Thomas Anderson", AvatarURL: "https://vk.com/p.jpg"}
+		if got != want {
+			t.Errorf("identity = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("no email", func(t *testing.T) {
+		cfg := fakeProvider(t, nil, userinfo)
+		if got := login(t, NewVK(cfg)); got.Email != "" || got.EmailVerified {
+			t.Errorf("identity = %+v", got)
+		}
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		cfg := fakeProvider(t, nil, userinfo)
+		p := NewVK(cfg)
+		token, err := p.Exchange(context.Background(), "code", testVerifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token.AccessToken = "expired"
+		_, err = p.UserInfo(context.Background(), token)
+		if err == nil || !strings.Contains(err.Error(), "User authorization failed") {
+			t.Fatalf("err = %v", err)
+		}
+		// Токен передается в URL и не должен попадать в ошибку
+		if strings.Contains(err.Error(), "expired") {
+			t.Errorf("error leaks the token: %v", err)
+		}
+	})
+}
+
+func TestPKCE(t *testing.T) {
+	cfg := fakeProvider(t, nil, func(w http.ResponseWriter, r *http.Request) {})
+	p := NewGoogle(cfg)
+
+	u, err := url.Parse(p.AuthCodeURL("state-1", testVerifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("state") != "state-1" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		t.Errorf("consent URL = %s", u)
+	}
+	if strings.Contains(u.String(), testVerifier) {
+		t.Error("verifier is sent to the browser")
+	}
+
+	// Перехваченный код без verifier бесполезен
+	for _, verifier := range []string{"", "wrong-verifier"} {
+		if _, err := p.Exchange(context.Background(), "code", verifier); err == nil {
+			t.Errorf("exchange with verifier %q succeeded", verifier)
+		}
+	}
+}
+
+func TestUserInfoErrors(t *testing.T) {
+	cfg := fakeProvider(t, nil, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	p := NewGoogle(cfg)
+	token, err := p.Exchange(context.Background(), "code", testVerifier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.UserInfo(context.Background(), token); err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(NewVK(Config{}), NewGoogle(Config{}))
+	if got := strings.Join(r.Names(), ","); got != "google,vk" {
+		t.Errorf("names = %s", got)
+	}
+	if _, ok := r.Get("github"); ok {
+		t.Error("unconfigured provider is returned")
+	}
+	var empty *Registry
+	if _, ok := empty.Get("google"); ok || empty.Names() != nil {
+		t.Error("nil registry is not empty")
+	}
+	if !IsSupported("yandex") || IsSupported("facebook") {
+		t.Error("IsSupported is wrong")
+	}
+}
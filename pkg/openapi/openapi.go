@@ -0,0 +1,380 @@
+// Package openapi builds the OpenAPI 3.1 document from metadata attached to
+// mux routes at registration time, so the published spec cannot drift from
+// the route table.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Version is the OpenAPI version of generated documents.
+const Version = "3.1.0"
+
+// Param describes a path, query or header parameter.
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      Schema
+}
+
+// Query returns an optional query parameter.
+func Query(name string, schema Schema, description string) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// Path returns a path parameter. Path parameters are always required.
+func Path(name string, schema Schema, description string) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+// Header returns an optional header parameter.
+func Header(name string, schema Schema, description string) Param {
+	return Param{Name: name, In: "header", Description: description, Schema: schema}
+}
+
+// Require marks the parameter as required.
+func (p Param) Require() Param {
+	p.Required = true
+	return p
+}
+
+// Operation is the documentation attached to a single route. It is built
+// with chained calls starting from Op.
+type Operation struct {
+	tags        []string
+	summary     string
+	description string
+	params      []Param
+	body        interface{}
+	data        interface{}
+	raw         interface{}
+	hasData     bool
+	hasRaw      bool
+	contentType string
+	responses   map[int]string
+	hidden      bool
+}
+
+// Op starts an operation in the given tag group.
+func Op(tag, summary string) *Operation {
+	return &Operation{tags: []string{tag}, summary: summary, responses: make(map[int]string)}
+}
+
+// Hidden marks a route that is deliberately left out of the document,
+// such as the docs page itself.
+func Hidden() *Operation {
+	return &Operation{hidden: true}
+}
+
+// Describe sets the long description.
+func (o *Operation) Describe(text string) *Operation {
+	o.description = text
+	return o
+}
+
+// Params adds parameters. Path parameters that are not listed are derived
+// from the route template as required strings.
+func (o *Operation) Params(params ...Param) *Operation {
+	o.params = append(o.params, params...)
+	return o
+}
+
+// Accepts sets the JSON request body model.
+func (o *Operation) Accepts(v interface{}) *Operation {
+	o.body = v
+	return o
+}
+
+// Returns sets the model wrapped into models.APIResponse.data of the
+// success response.
+func (o *Operation) Returns(v interface{}) *Operation {
+	o.data, o.hasData = v, true
+	return o
+}
+
+// ReturnsRaw sets a success response model that is written as is, without
+// the APIResponse envelope.
+func (o *Operation) ReturnsRaw(v interface{}) *Operation {
+	o.raw, o.hasRaw = v, true
+	return o
+}
+
+// Produces sets a non-JSON content type of the success response, for
+// example text/html or image/*.
+func (o *Operation) Produces(contentType string) *Operation {
+	o.contentType = contentType
+	return o
+}
+
+// Response documents a status code. The lowest 2xx code carries the
+// success body; 200 is assumed when none is given.
+func (o *Operation) Response(code int, description string) *Operation {
+	o.responses[code] = description
+	return o
+}
+
+// Info is the document info block.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Document is a generated OpenAPI document.
+type Document struct {
+	OpenAPI    string                            `json:"openapi"`
+	Info       Info                              `json:"info"`
+	Servers    []map[string]string               `json:"servers"`
+	Paths      map[string]map[string]interface{} `json:"paths"`
+	Components map[string]interface{}            `json:"components"`
+}
+
+// Spec collects operation metadata for routes as they are registered.
+type Spec struct {
+	info       Info
+	envelope   interface{}
+	errorModel interface{}
+
+	mu      sync.RWMutex
+	ops     map[*mux.Route]*Operation
+	secured map[*mux.Route]bool
+	doc     *Document
+}
+
+// NewSpec creates a spec. envelope is the response wrapper used by
+// Operation.Returns; its "data" property is replaced with the operation
+// model. errorModel is the body of every 4xx and 5xx response.
+func NewSpec(info Info, envelope, errorModel interface{}) *Spec {
+	return &Spec{
+		info:       info,
+		envelope:   envelope,
+		errorModel: errorModel,
+		ops:        make(map[*mux.Route]*Operation),
+		secured:    make(map[*mux.Route]bool),
+	}
+}
+
+// Describe attaches op to route and returns the route.
+func (s *Spec) Describe(route *mux.Route, op *Operation) *mux.Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ops[route] = op
+	return route
+}
+
+// Secure marks route, typically a subrouter prefix, as requiring a bearer
+// token for every route below it.
+func (s *Spec) Secure(route *mux.Route) *mux.Route {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.secured[route] = true
+	return route
+}
+
+// Document returns the last document produced by Build.
+func (s *Spec) Document() *Document {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.doc
+}
+
+// muxPathVar matches a mux path variable with an optional pattern.
+var muxPathVar = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Build walks router and generates the document. Routes without metadata
+// are reported in the returned error but do not prevent the document from
+// being built.
+func (s *Spec) Build(router *mux.Router) (*Document, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	schemas := newSchemaRegistry()
+	envelope := schemas.of(s.envelope)
+	errorSchema := schemas.of(s.errorModel)
+	paths := make(map[string]map[string]interface{})
+	var undocumented []string
+
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, ancestors []*mux.Route) error {
+		tmpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Префиксы подроутеров не являются самостоятельными маршрутами
+			return nil
+		}
+
+		op, ok := s.ops[route]
+		if !ok {
+			for _, m := range methods {
+				undocumented = append(undocumented, m+" "+tmpl)
+			}
+			return nil
+		}
+		if op.hidden {
+			return nil
+		}
+
+		secured := s.secured[route]
+		for _, a := range ancestors {
+			secured = secured || s.secured[a]
+		}
+
+		path := muxPathVar.ReplaceAllString(tmpl, "{$1}")
+		if paths[path] == nil {
+			paths[path] = make(map[string]interface{})
+		}
+		for _, m := range methods {
+			paths[path][strings.ToLower(m)] = s.operation(schemas, envelope, errorSchema, op, tmpl, secured)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.doc = &Document{
+		OpenAPI: Version,
+		Info:    s.info,
+		// Относительный URL, чтобы документация работала за любым прокси
+		Servers: []map[string]string{{"url": "/"}},
+		Paths:   paths,
+		Components: map[string]interface{}{
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]string{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+			"schemas": schemas.schemas,
+		},
+	}
+
+	if len(undocumented) > 0 {
+		sort.Strings(undocumented)
+		return s.doc, fmt.Errorf("routes without OpenAPI metadata: %s", strings.Join(undocumented, ", "))
+	}
+	return s.doc, nil
+}
+
+func (s *Spec) operation(schemas *schemaRegistry, envelope, errorSchema Schema, op *Operation, tmpl string, secured bool) map[string]interface{} {
+	out := map[string]interface{}{
+		"tags":    op.tags,
+		"summary": op.summary,
+	}
+	if op.description != "" {
+		out["description"] = op.description
+	}
+
+	if params := s.parameters(op, tmpl); len(params) > 0 {
+		out["parameters"] = params
+	}
+
+	if op.body != nil {
+		out["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemas.of(op.body)},
+			},
+		}
+	}
+
+	responses := make(map[string]interface{})
+	success := 0
+	for code, desc := range op.responses {
+		responses[strconv.Itoa(code)] = map[string]interface{}{"description": desc}
+		if code >= 200 && code < 300 && (success == 0 || code < success) {
+			success = code
+		}
+	}
+	if success == 0 {
+		success = http.StatusOK
+		responses["200"] = map[string]interface{}{"description": http.StatusText(http.StatusOK)}
+	}
+	if content := s.successContent(schemas, envelope, op); content != nil {
+		responses[strconv.Itoa(success)].(map[string]interface{})["content"] = content
+	}
+	if secured {
+		out["security"] = []map[string][]string{{"bearerAuth": {}}}
+		if _, ok := responses["401"]; !ok {
+			responses["401"] = map[string]interface{}{"description": "Требуется авторизация"}
+		}
+	}
+	// Любой маршрут может вернуть ошибку в формате errorModel
+	responses["default"] = map[string]interface{}{"description": "Ошибка"}
+	for code, resp := range responses {
+		if status, err := strconv.Atoi(code); code == "default" || err == nil && status >= 400 {
+			resp.(map[string]interface{})["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": errorSchema},
+			}
+		}
+	}
+	out["responses"] = responses
+
+	return out
+}
+
+func (s *Spec) successContent(schemas *schemaRegistry, envelope Schema, op *Operation) map[string]interface{} {
+	switch {
+	case op.contentType != "":
+		return map[string]interface{}{op.contentType: map[string]interface{}{}}
+	case op.hasRaw:
+		return map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schemas.of(op.raw)},
+		}
+	case op.hasData:
+		schema := envelope
+		if op.data != nil {
+			schema = Schema{"allOf": []Schema{envelope, {
+				"type":       "object",
+				"properties": map[string]interface{}{"data": schemas.of(op.data)},
+			}}}
+		}
+		return map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+	return nil
+}
+
+// parameters returns op's parameters plus any path variables of tmpl that
+// op does not describe explicitly.
+func (s *Spec) parameters(op *Operation, tmpl string) []map[string]interface{} {
+	described := make(map[string]bool)
+	var params []map[string]interface{}
+	add := func(p Param) {
+		param := map[string]interface{}{
+			"name":     p.Name,
+			"in":       p.In,
+			"required": p.Required,
+			"schema":   p.Schema,
+		}
+		if p.Description != "" {
+			param["description"] = p.Description
+		}
+		params = append(params, param)
+	}
+
+	for _, p := range op.params {
+		if p.In == "path" {
+			described[p.Name] = true
+		}
+	}
+	for _, m := range muxPathVar.FindAllStringSubmatch(tmpl, -1) {
+		if !described[m[1]] {
+			add(Path(m[1], String(), ""))
+		}
+	}
+	for _, p := range op.params {
+		add(p)
+	}
+	return params
+}
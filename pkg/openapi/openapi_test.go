@@ -0,0 +1,141 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+type testEnvelope struct {
+	Success bool        `json:"success"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+type testError struct {
+	Error string `json:"error"`
+}
+
+type testItem struct {
+	ID string `json:"id"`
+}
+
+func noop(http.ResponseWriter, *http.Request) {}
+
+func newTestSpec() *Spec {
+	return NewSpec(Info{Title: "test", Version: "1"}, testEnvelope{}, testError{})
+}
+
+func TestBuildReportsUndocumentedRoutes(t *testing.T) {
+	spec := newTestSpec()
+	r := mux.NewRouter()
+	spec.Describe(r.HandleFunc("/items", noop).Methods("GET"), Op("Items", "List"))
+	r.HandleFunc("/items", noop).Methods("POST", "PUT")
+
+	doc, err := spec.Build(r)
+	if err == nil || !strings.Contains(err.Error(), "POST /items, PUT /items") {
+		t.Fatalf("err = %v", err)
+	}
+	// Документ все равно строится из описанных маршрутов
+	if doc == nil || doc.Paths["/items"]["get"] == nil || spec.Document() != doc {
+		t.Fatalf("document = %+v", doc)
+	}
+}
+
+func TestBuildSkipsHiddenRoutes(t *testing.T) {
+	spec := newTestSpec()
+	r := mux.NewRouter()
+	spec.Describe(r.HandleFunc("/", noop).Methods("GET"), Hidden())
+	spec.Describe(r.HandleFunc("/items", noop).Methods("GET"), Op("Items", "List"))
+
+	doc, err := spec.Build(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Paths["/"]; ok || len(doc.Paths) != 1 {
+		t.Errorf("paths = %v", doc.Paths)
+	}
+}
+
+func TestBuildInheritsSecurity(t *testing.T) {
+	spec := newTestSpec()
+	r := mux.NewRouter()
+	api := r.PathPrefix("/api").Subrouter()
+	spec.Describe(api.HandleFunc("/public", noop).Methods("GET"), Op("Items", "Public"))
+	protected := spec.Secure(api.PathPrefix("")).Subrouter()
+	spec.Describe(protected.HandleFunc("/items/{id}", noop).Methods("GET"), Op("Items", "Get").Returns(testItem{}))
+	admin := protected.PathPrefix("/admin").Subrouter()
+	spec.Describe(admin.HandleFunc("/stats", noop).Methods("GET"), Op("Admin", "Stats"))
+
+	doc, err := spec.Build(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		secured bool
+	}{
+		{"/api/public", false},
+		{"/api/items/{id}", true},
+		// Вложенный подроутер наследует защиту через всех предков
+		{"/api/admin/stats", true},
+	}
+	for _, tt := range tests {
+		op := doc.Paths[tt.path]["get"].(map[string]interface{})
+		_, secured := op["security"]
+		_, unauthorized := op["responses"].(map[string]interface{})["401"]
+		if secured != tt.secured || unauthorized != tt.secured {
+			t.Errorf("%s: security = %v, 401 documented = %v, want %v", tt.path, secured, unauthorized, tt.secured)
+		}
+	}
+}
+
+func TestOperationResponses(t *testing.T) {
+	spec := newTestSpec()
+	r := mux.NewRouter()
+	spec.Describe(r.HandleFunc("/items/{path:.*}", noop).Methods("POST"),
+		Op("Items", "Create").Returns(testItem{}).Response(201, "Created").Response(404, "Not found").Response(503, "Unavailable"))
+
+	doc, err := spec.Build(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Шаблон mux без регулярного выражения
+	op, ok := doc.Paths["/items/{path}"]["post"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("paths = %v", doc.Paths)
+	}
+	if params := op["parameters"].([]map[string]interface{}); len(params) != 1 || params[0]["name"] != "path" {
+		t.Errorf("parameters = %v", params)
+	}
+
+	responses := op["responses"].(map[string]interface{})
+	if _, ok := responses["200"]; ok {
+		t.Error("200 is added although 201 is documented")
+	}
+	tests := []struct {
+		code  string
+		error bool
+	}{
+		{"201", false},
+		{"404", true},
+		{"503", true},
+		{"default", true},
+	}
+	for _, tt := range tests {
+		resp, ok := responses[tt.code].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s is not documented", tt.code)
+			continue
+		}
+		content, _ := resp["content"].(map[string]interface{})
+		schema, _ := content["application/json"].(map[string]interface{})["schema"].(Schema)
+		ref, _ := schema["$ref"].(string)
+		isError := strings.HasSuffix(ref, "testError")
+		if isError != tt.error {
+			t.Errorf("%s: error body = %v, want %v (content %v)", tt.code, isError, tt.error, content)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package openapi
+
+import (
+	"encoding"
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema object as used by OpenAPI 3.1.
+type Schema map[string]interface{}
+
+// String, Integer, Number and Boolean return primitive schemas for
+// parameters.
+func String() Schema  { return Schema{"type": "string"} }
+func Integer() Schema { return Schema{"type": "integer"} }
+func Number() Schema  { return Schema{"type": "number"} }
+func Boolean() Schema { return Schema{"type": "boolean"} }
+
+// Enum returns a string schema limited to values.
+func Enum(values ...string) Schema {
+	return Schema{"type": "string", "enum": values}
+}
+
+// Default returns a copy of s with a default value.
+func (s Schema) Default(v interface{}) Schema { return s.with("default", v) }
+
+// Min returns a copy of s with an inclusive minimum.
+func (s Schema) Min(v int) Schema { return s.with("minimum", v) }
+
+// Max returns a copy of s with an inclusive maximum.
+func (s Schema) Max(v int) Schema { return s.with("maximum", v) }
+
+func (s Schema) with(key string, v interface{}) Schema {
+	out := make(Schema, len(s)+1)
+	for k, val := range s {
+		out[k] = val
+	}
+	out[key] = v
+	return out
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schemaRegistry turns Go types into schemas. Named structs are emitted
+// once under components/schemas and referenced with $ref.
+type schemaRegistry struct {
+	schemas map[string]Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() *schemaRegistry {
+	return &schemaRegistry{
+		schemas: make(map[string]Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// of returns the schema for the type of v.
+func (g *schemaRegistry) of(v interface{}) Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *schemaRegistry) schema(t reflect.Type) Schema {
+	if t == nil {
+		return Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case t.Kind() != reflect.Struct && (t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType)),
+		t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		// ObjectID и подобные типы сериализуются строкой
+		return Schema{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "format": "byte"}
+		}
+		return Schema{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.Interface:
+		return Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + g.define(t)}
+	}
+	return Schema{}
+}
+
+// define registers a named struct and returns its component name.
+func (g *schemaRegistry) define(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		// Одинаковые имена из разных пакетов различаем по пакету
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+
+	// Регистрируем имя до обхода полей, чтобы рекурсивные типы не зацикливались
+	g.names[t] = name
+	g.schemas[name] = nil
+	g.schemas[name] = g.object(t)
+	return name
+}
+
+func (g *schemaRegistry) object(t reflect.Type) Schema {
+	properties := make(map[string]interface{})
+	var required []string
+	g.fields(t, properties, &required)
+
+	s := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func (g *schemaRegistry) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, properties, required)
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		properties[name] = constrain(g.schema(f.Type), f.Tag.Get("validate"))
+		if isRequired(f.Tag.Get("validate")) {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// isRequired reads the go-playground style validate tag.
+func isRequired(tag string) bool {
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// constrain adds the limits of a validate tag (oneof, min, max, email) to a
+// primitive schema. References and nested rules after "dive" are left as is.
+func constrain(s Schema, tag string) Schema {
+	if tag == "" {
+		return s
+	}
+	typ, _ := s["type"].(string)
+	if typ != "string" && typ != "integer" && typ != "number" && typ != "array" {
+		return s
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		if rule == "dive" {
+			break
+		}
+		key, param, _ := strings.Cut(rule, "=")
+		n, err := strconv.Atoi(param)
+		switch {
+		case key == "email" && typ == "string":
+			s = s.with("format", "email")
+		case (key == "url" || key == "http_url") && typ == "string":
+			s = s.with("format", "uri")
+		case key == "oneof" && typ == "string":
+			s = s.with("enum", strings.Fields(param))
+		case (key == "min" || key == "max") && err == nil:
+			s = s.with(limitKeyword(typ, key), n)
+		}
+	}
+	return s
+}
+
+func limitKeyword(typ, rule string) string {
+	prefix := map[string]string{"string": "Length", "array": "Items"}[typ]
+	if prefix == "" {
+		if rule == "min" {
+			return "minimum"
+		}
+		return "maximum"
+	}
+	return rule + prefix
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second, Scope: ScopeIP}
+
+	// Полная корзина позволяет всплеск до Limit запросов
+	for i := 2; i >= 0; i-- {
+		res, _ := store.Take(ctx, "k", policy)
+		if !res.Allowed || res.Remaining != i {
+			t.Fatalf("take: %+v, want allowed with %d remaining", res, i)
+		}
+	}
+
+	res, _ := store.Take(ctx, "k", policy)
+	if res.Allowed {
+		t.Fatal("take over the limit was allowed")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("retry after = %v, want 1s", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Errorf("reset = %v, want 3s", res.Reset)
+	}
+
+	// Токен восстанавливается за Period/Limit
+	now = now.Add(time.Second)
+	if res, _ := store.Take(ctx, "k", policy); !res.Allowed {
+		t.Fatal("token was not refilled")
+	}
+
+	// Ключи не влияют друг на друга
+	if res, _ := store.Take(ctx, "other", policy); !res.Allowed || res.Remaining != 2 {
+		t.Fatalf("other key: %+v", res)
+	}
+}
+
+func TestMemoryStoreSweepsIdleBuckets(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	policy := Policy{Name: "test", Limit: 1, Period: time.Second, Scope: ScopeIP}
+
+	store.Take(ctx, "idle", policy)
+	now = now.Add(sweepInterval)
+	store.Take(ctx, "active", policy)
+
+	if _, ok := store.buckets["idle"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := store.buckets["active"]; !ok {
+		t.Error("active bucket was swept")
+	}
+}
@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/middleware"
+)
+
+// Limiter applies named policies to HTTP handlers. A nil *Limiter is valid
+// and does not limit anything, which is how rate limiting is disabled.
+type Limiter struct {
+	store      Store
+	policies   map[string]Policy
+	trustProxy bool
+}
+
+// NewLimiter creates a limiter. When trustProxy is set the client IP is
+// taken from X-Forwarded-For / X-Real-IP, which is only safe behind a
+// proxy that overwrites these headers.
+func NewLimiter(store Store, policies map[string]Policy, trustProxy bool) *Limiter {
+	return &Limiter{
+		store:      store,
+		policies:   policies,
+		trustProxy: trustProxy,
+	}
+}
+
+// New builds a limiter from configuration: the store backend, policy
+// overrides in ParseOverrides format and whether proxy headers are trusted.
+func New(backend string, db *mongo.Database, overrides string, trustProxy bool) (*Limiter, error) {
+	policies := DefaultPolicies()
+	if err := ParseOverrides(policies, overrides); err != nil {
+		return nil, err
+	}
+	store, err := NewStore(backend, db)
+	if err != nil {
+		return nil, err
+	}
+	return NewLimiter(store, policies, trustProxy), nil
+}
+
+// Middleware returns a mux-compatible middleware enforcing the named policy.
+// It panics on an unknown policy name since that is a programming error in
+// route registration.
+func (l *Limiter) Middleware(name string) func(http.Handler) http.Handler {
+	if l == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	policy, ok := l.policies[name]
+	if !ok {
+		panic("ratelimit: unknown policy " + name)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := policy.Name + ":" + l.clientKey(r, policy.Scope)
+			res, err := l.store.Take(r.Context(), key, policy)
+			if err != nil {
+				// Недоступность хранилища не должна ронять API
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				apperr.Write(w, r, apperr.TooManyRequests("Too many requests, please try again later").WithCode("rate_limited"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Wrap applies the named policy to a single handler function.
+func (l *Limiter) Wrap(name string, handler http.HandlerFunc) http.Handler {
+	return l.Middleware(name)(handler)
+}
+
+func (l *Limiter) clientKey(r *http.Request, scope Scope) string {
+	if scope == ScopeUser {
+		if userID, ok := middleware.GetUserIDFromContext(r.Context()); ok && userID != "" {
+			return "user:" + userID
+		}
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+func (l *Limiter) clientIP(r *http.Request) string {
+	if l.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			if ip := strings.TrimSpace(first); ip != "" {
+				return ip
+			}
+		}
+		if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,144 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"neomovies-api/pkg/middleware"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	return Result{}, errors.New("store is down")
+}
+
+func newTestLimiter(store Store, trustProxy bool) *Limiter {
+	return NewLimiter(store, map[string]Policy{
+		"ip":   {Name: "ip", Limit: 2, Period: time.Minute, Scope: ScopeIP},
+		"user": {Name: "user", Limit: 1, Period: time.Minute, Scope: ScopeUser},
+	}, trustProxy)
+}
+
+func serve(handler http.Handler, remoteAddr string, modify func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+	req.RemoteAddr = remoteAddr
+	if modify != nil {
+		modify(req)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+func TestMiddlewareLimitsAndSetsHeaders(t *testing.T) {
+	handler := newTestLimiter(NewMemoryStore(), false).Middleware("ip")(okHandler)
+
+	rec := serve(handler, "10.0.0.1:1234", nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-RateLimit-Limit") != "2" || rec.Header().Get("X-RateLimit-Remaining") != "1" {
+		t.Fatalf("first request: %d %v", rec.Code, rec.Header())
+	}
+	serve(handler, "10.0.0.1:1234", nil)
+
+	rec = serve(handler, "10.0.0.1:5678", nil)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") != "30" {
+		t.Errorf("Retry-After = %q", rec.Header().Get("Retry-After"))
+	}
+
+	// Другой IP считается отдельно
+	if rec := serve(handler, "10.0.0.2:1234", nil); rec.Code != http.StatusOK {
+		t.Errorf("other client: status = %d", rec.Code)
+	}
+	// Preflight-запросы не тратят лимит
+	rec = serve(handler, "10.0.0.1:1234", func(r *http.Request) { r.Method = http.MethodOptions })
+	if rec.Code != http.StatusOK {
+		t.Errorf("OPTIONS: status = %d", rec.Code)
+	}
+}
+
+func TestMiddlewareUserScope(t *testing.T) {
+	handler := newTestLimiter(NewMemoryStore(), false).Middleware("user")(okHandler)
+	asUser := func(userID string) func(*http.Request) {
+		return func(r *http.Request) {
+			*r = *r.WithContext(context.WithValue(r.Context(), middleware.UserIDKey, userID))
+		}
+	}
+
+	if rec := serve(handler, "10.0.0.1:1", asUser("a")); rec.Code != http.StatusOK {
+		t.Fatalf("user a: %d", rec.Code)
+	}
+	// Тот же IP, другой пользователь — своя корзина
+	if rec := serve(handler, "10.0.0.1:1", asUser("b")); rec.Code != http.StatusOK {
+		t.Fatalf("user b: %d", rec.Code)
+	}
+	if rec := serve(handler, "10.0.0.2:1", asUser("a")); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("user a from another IP: %d, want 429", rec.Code)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	forwarded := func(r *http.Request) {
+		r.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+		r.Header.Set("X-Real-IP", "203.0.113.8")
+	}
+	realIP := func(r *http.Request) { r.Header.Set("X-Real-IP", "203.0.113.8") }
+
+	tests := []struct {
+		name       string
+		trustProxy bool
+		modify     func(*http.Request)
+		want       string
+	}{
+		{"remote addr", false, nil, "10.0.0.1"},
+		{"untrusted headers", false, forwarded, "10.0.0.1"},
+		{"forwarded for", true, forwarded, "203.0.113.7"},
+		{"real ip", true, realIP, "203.0.113.8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			if tt.modify != nil {
+				tt.modify(req)
+			}
+			if got := newTestLimiter(nil, tt.trustProxy).clientIP(req); got != tt.want {
+				t.Errorf("clientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareFailsOpen(t *testing.T) {
+	handler := newTestLimiter(failingStore{}, false).Middleware("ip")(okHandler)
+	if rec := serve(handler, "10.0.0.1:1", nil); rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 when the store is down", rec.Code)
+	}
+}
+
+func TestNilLimiterDoesNotLimit(t *testing.T) {
+	var limiter *Limiter
+	handler := limiter.Wrap("anything", okHandler)
+	for i := 0; i < 5; i++ {
+		if rec := serve(handler, "10.0.0.1:1", nil); rec.Code != http.StatusOK {
+			t.Fatalf("status = %d", rec.Code)
+		}
+	}
+}
+
+func TestUnknownPolicyPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for an unknown policy")
+		}
+	}()
+	newTestLimiter(NewMemoryStore(), false).Middleware("missing")
+}
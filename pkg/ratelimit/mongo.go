@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore keeps buckets in a MongoDB collection so that every instance
+// of the API shares the same limits. Each Take is a single atomic
+// pipeline update that refills and decrements the bucket using the
+// server clock. Idle buckets are removed by a TTL index on expiresAt.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoStore creates a store backed by the given collection and ensures its TTL index.
+func NewMongoStore(db *mongo.Database, collectionName string) (*MongoStore, error) {
+	if collectionName == "" {
+		collectionName = "rate_limits"
+	}
+	s := &MongoStore{collection: db.Collection(collectionName)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *MongoStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	capacity := float64(policy.Limit)
+	perMillisecond := capacity / float64(policy.Period.Milliseconds())
+
+	refilled := bson.M{"$min": bson.A{capacity, bson.M{"$add": bson.A{
+		bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+		bson.M{"$multiply": bson.A{
+			bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$updatedAt", "$$NOW"}}}},
+			perMillisecond,
+		}},
+	}}}}
+	hasToken := bson.M{"$gte": bson.A{"$tokens", 1}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled, "updatedAt": "$$NOW"}}},
+		{{Key: "$set", Value: bson.M{
+			"allowed":   hasToken,
+			"tokens":    bson.M{"$cond": bson.A{hasToken, bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"expiresAt": bson.M{"$add": bson.A{"$$NOW", policy.Period.Milliseconds()}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var doc struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err := s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc)
+	if mongo.IsDuplicateKeyError(err) {
+		// Два инстанса одновременно создали корзину, повторяем уже как обновление
+		err = s.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&doc)
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return policy.result(doc.Tokens, doc.Allowed), nil
+}
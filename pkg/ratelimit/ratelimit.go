@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Scope selects what a policy counts requests by.
+type Scope string
+
+const (
+	// ScopeIP counts requests per client IP address.
+	ScopeIP Scope = "ip"
+	// ScopeUser counts requests per authenticated user and falls back to the
+	// client IP for anonymous requests.
+	ScopeUser Scope = "user"
+)
+
+// Policy is a token bucket: it holds up to Limit tokens and refills
+// completely over Period, so Limit requests per Period are allowed on
+// average with bursts of up to Limit.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Scope  Scope
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // time until the next token, zero when allowed
+	Reset      time.Duration // time until the bucket is full again
+}
+
+// Store keeps token buckets by key.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// DefaultPolicies returns the built-in policies used by the API.
+func DefaultPolicies() map[string]Policy {
+	return map[string]Policy{
+		// Общий лимит на все маршруты API
+		"default": {Name: "default", Limit: 300, Period: time.Minute, Scope: ScopeIP},
+		// Защищенные маршруты считаются по пользователю
+		"user": {Name: "user", Limit: 600, Period: time.Minute, Scope: ScopeUser},
+		// Вход, регистрация, подтверждение и сброс пароля
+		"auth": {Name: "auth", Limit: 10, Period: time.Minute, Scope: ScopeIP},
+		// Обновление токенов — штатная операция клиентов, не тратит лимит входа
+		"refresh": {Name: "refresh", Limit: 30, Period: time.Minute, Scope: ScopeIP},
+		// Маршруты, отправляющие письма
+		"email": {Name: "email", Limit: 3, Period: 10 * time.Minute, Scope: ScopeIP},
+		// Отписка и подписка на рассылку по токену из письма
+		"digest": {Name: "digest", Limit: 5, Period: 10 * time.Minute, Scope: ScopeIP},
+		// Поиск торрентов через RedAPI; маршруты публичные, поэтому по IP
+		"torrents": {Name: "torrents", Limit: 30, Period: time.Minute, Scope: ScopeIP},
+	}
+}
+
+// ParseOverrides applies overrides in the form "name=limit/period,..."
+// (for example "auth=20/1m,email=5/15m") to policies. Unknown names are
+// rejected so that typos do not silently disable a limit.
+func ParseOverrides(policies map[string]Policy, overrides string) error {
+	for _, item := range strings.Split(overrides, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid rate limit %q: expected name=limit/period", item)
+		}
+		name = strings.TrimSpace(name)
+		policy, exists := policies[name]
+		if !exists {
+			return fmt.Errorf("unknown rate limit policy %q", name)
+		}
+		limitStr, periodStr, ok := strings.Cut(spec, "/")
+		if !ok {
+			return fmt.Errorf("invalid rate limit %q: expected name=limit/period", item)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+		if err != nil || limit <= 0 {
+			return fmt.Errorf("invalid limit in rate limit %q", item)
+		}
+		period, err := time.ParseDuration(strings.TrimSpace(periodStr))
+		if err != nil || period <= 0 {
+			return fmt.Errorf("invalid period in rate limit %q", item)
+		}
+		policy.Limit = limit
+		policy.Period = period
+		policies[name] = policy
+	}
+	return nil
+}
+
+// NewStore creates a store for the configured backend: "memory" (default)
+// or "mongo".
+func NewStore(backend string, db *mongo.Database) (Store, error) {
+	switch strings.ToLower(backend) {
+	case "", "memory":
+		return NewMemoryStore(), nil
+	case "mongo", "mongodb":
+		if db == nil {
+			return nil, fmt.Errorf("mongo rate limit store requires a database connection")
+		}
+		return NewMongoStore(db, "")
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q", backend)
+	}
+}
+
+// refillRate returns tokens added to the bucket per second.
+func (p Policy) refillRate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// result builds a Result from the number of tokens left after a Take.
+func (p Policy) result(tokens float64, allowed bool) Result {
+	res := Result{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((float64(p.Limit) - tokens) / p.refillRate()),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / p.refillRate())
+	}
+	return res
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
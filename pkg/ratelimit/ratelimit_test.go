@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOverrides(t *testing.T) {
+	policies := DefaultPolicies()
+	if err := ParseOverrides(policies, " auth=20/1m, email=5/15m ,"); err != nil {
+		t.Fatal(err)
+	}
+	if p := policies["auth"]; p.Limit != 20 || p.Period != time.Minute || p.Scope != ScopeIP {
+		t.Errorf("auth = %+v", p)
+	}
+	if p := policies["email"]; p.Limit != 5 || p.Period != 15*time.Minute {
+		t.Errorf("email = %+v", p)
+	}
+
+	for _, overrides := range []string{"auth", "auth=20", "unknown=1/1m", "auth=0/1m", "auth=x/1m", "auth=1/0s", "auth=1/soon"} {
+		if err := ParseOverrides(DefaultPolicies(), overrides); err == nil {
+			t.Errorf("ParseOverrides(%q): expected error", overrides)
+		}
+	}
+}
+
+func TestDefaultPolicies(t *testing.T) {
+	policies := DefaultPolicies()
+	for name, policy := range policies {
+		if policy.Name != name || policy.Limit <= 0 || policy.Period <= 0 {
+			t.Errorf("%s: %+v", name, policy)
+		}
+	}
+	// Публичные маршруты не знают пользователя, лимит по пользователю
+	// на них молча превращался бы в лимит по IP
+	for _, name := range []string{"auth", "refresh", "email", "digest", "torrents"} {
+		if policies[name].Scope != ScopeIP {
+			t.Errorf("%s: scope = %s, want ip", name, policies[name].Scope)
+		}
+	}
+}
+
+func TestNewStore(t *testing.T) {
+	if _, err := NewStore("memory", nil); err != nil {
+		t.Error(err)
+	}
+	if _, err := NewStore("mongo", nil); err == nil {
+		t.Error("mongo store without a database: expected error")
+	}
+	if _, err := NewStore("redis", nil); err == nil {
+		t.Error("unknown store: expected error")
+	}
+}
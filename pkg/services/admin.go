@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+// adminTopLimit — сколько самых популярных тайтлов возвращает статистика
+const adminTopLimit = 10
+
+var ErrCannotBanSelf = apperr.Validation("admins cannot ban themselves").WithCode("cannot_ban_self")
+
+// usersSortFields maps sort parameters of ListUsers to document fields.
+var usersSortFields = map[string]string{
+	"createdAt": "createdAt",
+	"email":     "email",
+	"name":      "name",
+}
+
+// AdminService implements the admin API. Every change it makes is written
+// to the audit log with the acting admin as ActorID.
+type AdminService struct {
+	db       *mongo.Database
+	sessions *SessionService
+	audit    *AuditService
+	tmdb     *TMDBService
+	outbox   *OutboxService
+}
+
+func NewAdminService(db *mongo.Database, sessions *SessionService, audit *AuditService, tmdb *TMDBService, outbox *OutboxService) *AdminService {
+	return &AdminService{
+		db:       db,
+		sessions: sessions,
+		audit:    audit,
+		tmdb:     tmdb,
+		outbox:   outbox,
+	}
+}
+
+// ListUsers returns a page of users matching filter.
+func (s *AdminService) ListUsers(ctx context.Context, filter models.AdminUserFilter, page models.PageRequest) (*models.PaginatedResponse, error) {
+	query := bson.M{}
+	if filter.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(filter.Query), Options: "i"}
+		// $or верхнего уровня занят условием курсора в findPage
+		query["$and"] = bson.A{bson.M{"$or": bson.A{
+			bson.M{"email": pattern},
+			bson.M{"name": pattern},
+		}}}
+	}
+	if filter.Banned != nil {
+		if *filter.Banned {
+			query["banned"] = true
+		} else {
+			query["banned"] = bson.M{"$ne": true}
+		}
+	}
+
+	// У пользователей нет типа медиа
+	page.MediaType = ""
+
+	docs, result, err := findPage(ctx, s.db.Collection("users"), query, page, usersSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]models.User, 0, len(docs))
+	for _, doc := range docs {
+		var user models.User
+		if err := bson.Unmarshal(doc, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	result.Items = users
+	return result, nil
+}
+
+// GetUser returns a single user by ID.
+func (s *AdminService) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	var user models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"_id": objectID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// BanUser blocks the account and ends all of its sessions, so issued access
+// tokens stop working immediately.
+func (s *AdminService) BanUser(ctx context.Context, actorID, userID, reason string) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotBanSelf
+	}
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = s.db.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{
+			"banned":    true,
+			"bannedAt":  now,
+			"banReason": reason,
+			"updatedAt": now,
+		}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.RevokeAllForUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		ActorID: actorID,
+		Action:  models.AuditAdminUserBanned,
+		Changes: map[string]models.AuditChange{
+			"banned":    {From: user.Banned, To: true},
+			"banReason": {From: user.BanReason, To: reason},
+		},
+	})
+	return s.GetUser(ctx, userID)
+}
+
+// UnbanUser lifts a ban. The user has to log in again.
+func (s *AdminService) UnbanUser(ctx context.Context, actorID, userID string) (*models.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set":   bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{"banned": "", "bannedAt": "", "banReason": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		ActorID: actorID,
+		Action:  models.AuditAdminUserUnbanned,
+		Changes: map[string]models.AuditChange{"banned": {From: user.Banned, To: false}},
+	})
+	return s.GetUser(ctx, userID)
+}
+
+// VerifyEmail marks the user's email as verified without a code.
+func (s *AdminService) VerifyEmail(ctx context.Context, actorID, userID string) (*models.User, error) {
+	user, err := s.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set":   bson.M{"verified": true, "updatedAt": time.Now()},
+			"$unset": bson.M{"verificationCode": "", "verificationExpires": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		ActorID: actorID,
+		Action:  models.AuditAdminEmailVerified,
+		Changes: map[string]models.AuditChange{"verified": {From: user.Verified, To: true}},
+	})
+	return s.GetUser(ctx, userID)
+}
+
+// Stats counts users, favorites, reactions and queued emails.
+func (s *AdminService) Stats(ctx context.Context) (*models.AdminStats, error) {
+	var stats models.AdminStats
+	var err error
+
+	users := s.db.Collection("users")
+	counts := []struct {
+		dst    *int64
+		filter bson.M
+	}{
+		{&stats.Users.Total, bson.M{}},
+		{&stats.Users.Verified, bson.M{"verified": true}},
+		{&stats.Users.Banned, bson.M{"banned": true}},
+		{&stats.Users.Admins, bson.M{"isAdmin": true}},
+	}
+	for _, c := range counts {
+		if *c.dst, err = users.CountDocuments(ctx, c.filter); err != nil {
+			return nil, err
+		}
+	}
+
+	favorites := s.db.Collection("favorites")
+	if stats.Favorites.ByMediaType, stats.Favorites.Total, err = countBy(ctx, favorites, "mediaType"); err != nil {
+		return nil, err
+	}
+	if stats.Favorites.Top, err = topMedia(ctx, favorites); err != nil {
+		return nil, err
+	}
+
+	reactions := s.db.Collection("reactions")
+	if stats.Reactions.ByType, stats.Reactions.Total, err = countBy(ctx, reactions, "type"); err != nil {
+		return nil, err
+	}
+	if stats.Reactions.Top, err = topMedia(ctx, reactions); err != nil {
+		return nil, err
+	}
+
+	if stats.Emails.ByStatus, stats.Emails.Total, err = countBy(ctx, s.db.Collection("email_outbox"), "status"); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// PurgeCache drops every cached TMDB response.
+func (s *AdminService) PurgeCache(ctx context.Context, actorID string) error {
+	if err := s.tmdb.PurgeCache(ctx); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, models.AuditEntry{
+		ActorID: actorID,
+		Action:  models.AuditAdminCachePurged,
+		Changes: map[string]models.AuditChange{"cache": {To: "tmdb"}},
+	})
+	return nil
+}
+
+// ListEmails returns a page of the email outbox.
+func (s *AdminService) ListEmails(ctx context.Context, filter models.OutboxFilter, page models.PageRequest) (*models.PaginatedResponse, error) {
+	return s.outbox.List(ctx, filter, page)
+}
+
+// GetEmail returns a single outbox email.
+func (s *AdminService) GetEmail(ctx context.Context, emailID string) (*models.OutboxEmail, error) {
+	return s.outbox.Get(ctx, emailID)
+}
+
+// RetryEmail requeues a failed or dead-lettered email.
+func (s *AdminService) RetryEmail(ctx context.Context, actorID, emailID string) (*models.OutboxEmail, error) {
+	email, err := s.outbox.Retry(ctx, emailID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditEntry{
+		ActorID: actorID,
+		Action:  models.AuditAdminEmailRetried,
+		Changes: map[string]models.AuditChange{"email": {To: emailID}},
+	})
+	return email, nil
+}
+
+// countBy groups collection by field and returns per-value counts and their sum.
+func countBy(ctx context.Context, collection *mongo.Collection, field string) (map[string]int64, int64, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": "$" + field, "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Key   string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, 0, err
+	}
+
+	result := make(map[string]int64, len(rows))
+	var total int64
+	for _, row := range rows {
+		result[row.Key] = row.Count
+		total += row.Count
+	}
+	return result, total, nil
+}
+
+// topMedia returns the titles with the most documents in collection.
+func topMedia(ctx context.Context, collection *mongo.Collection) ([]models.MediaCount, error) {
+	cursor, err := collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"mediaId": "$mediaId", "mediaType": "$mediaType"},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id.mediaId", Value: 1}}}},
+		{{Key: "$limit", Value: adminTopLimit}},
+		{{Key: "$project", Value: bson.M{"_id": 0, "mediaId": "$_id.mediaId", "mediaType": "$_id.mediaType", "count": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	top := make([]models.MediaCount, 0, adminTopLimit)
+	if err := cursor.All(ctx, &top); err != nil {
+		return nil, err
+	}
+	return top, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func newTestAdminService(mt *mtest.T) *AdminService {
+	return NewAdminService(mt.DB, NewSessionService(mt.DB, "secret"), NewAuditService(mt.DB), nil, nil)
+}
+
+func TestBanUser(t *testing.T) {
+	t.Run("self", func(t *testing.T) {
+		admin := NewAdminService(nil, nil, nil, nil, nil)
+		if _, err := admin.BanUser(context.Background(), "admin-1", "admin-1", "test"); !errors.Is(err, ErrCannotBanSelf) {
+			t.Fatalf("err = %v", err)
+		}
+	})
+
+	runMock(t, "revokes sessions", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		session := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "userId", Value: id.Hex()},
+			{Key: "accessTokenId", Value: "jti-1"},
+		}
+		mt.AddMockResponses(
+			found("test.users", userDoc(id)),
+			modified(1),
+			found("test.sessions", session),
+			modified(1),
+			modified(1),
+			acknowledged(),
+			found("test.users", userDoc(id, bson.E{Key: "banned", Value: true})),
+		)
+		admin := newTestAdminService(mt)
+
+		user, err := admin.BanUser(context.Background(), "admin-1", id.Hex(), "spam")
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if !user.Banned {
+			mt.Error("returned user is not banned")
+		}
+
+		commands := sentCommands(mt)
+		ban, _ := findCommand(commands, "update", "users")
+		if got := statement(ban, "updates").Lookup("u", "$set", "banReason").StringValue(); got != "spam" {
+			mt.Errorf("banReason = %q", got)
+		}
+		// Выданный access-токен перестает работать сразу
+		deny, ok := findCommand(commands, "update", "revoked_tokens")
+		if !ok {
+			mt.Fatal("access token of the session was not denied")
+		}
+		if got := statement(deny, "updates").Lookup("q", "_id").StringValue(); got != "jti-1" {
+			mt.Errorf("denied jti = %q", got)
+		}
+		audit, _ := findCommand(commands, "insert", "audit_log")
+		if got := statement(audit, "documents").Lookup("actorId").StringValue(); got != "admin-1" {
+			mt.Errorf("audit actor = %q", got)
+		}
+	})
+}
+
+func TestIsAdmin(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  []bson.D
+		want bool
+	}{
+		{"admin", []bson.D{{{Key: "isAdmin", Value: true}}}, true},
+		{"banned admin", []bson.D{{{Key: "isAdmin", Value: true}, {Key: "banned", Value: true}}}, false},
+		{"regular user", []bson.D{{{Key: "isAdmin", Value: false}}}, false},
+		{"deleted user", nil, false},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(found("test.users", tt.doc...))
+			auth := newTestAuthService(mt)
+
+			got, err := auth.IsAdmin(context.Background(), primitive.NewObjectID().Hex())
+			if err != nil || got != tt.want {
+				mt.Fatalf("IsAdmin = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
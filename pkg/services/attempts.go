@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+)
+
+// Failed attempts are free up to attemptsFree; after that each failure locks
+// the key for attemptsBaseDelay, doubling up to attemptsMaxDelay. Counters
+// are forgotten attemptsWindow after the last failure.
+const (
+	attemptsFree      = 5
+	attemptsBaseDelay = 30 * time.Second
+	attemptsMaxDelay  = 15 * time.Minute
+	attemptsWindow    = 24 * time.Hour
+)
+
+var ErrTooManyAttempts = apperr.TooManyRequests("too many failed attempts, try again later").WithCode("too_many_attempts")
+
+// attemptTracker counts failed credential checks per key (e.g. "login:" plus
+// the email) in the auth_attempts collection.
+type attemptTracker struct {
+	collection *mongo.Collection
+}
+
+type attemptEntry struct {
+	Failures    int       `bson:"failures"`
+	LockedUntil time.Time `bson:"lockedUntil,omitempty"`
+}
+
+// attemptKey builds the tracker key for an action and an email address.
+func attemptKey(action, email string) string {
+	return action + ":" + normalizeEmail(email)
+}
+
+func (t attemptTracker) ensureIndexes(ctx context.Context) error {
+	_, err := t.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// check returns ErrTooManyAttempts, with the remaining wait in seconds as
+// details, while key is locked.
+func (t attemptTracker) check(ctx context.Context, key string) error {
+	var entry attemptEntry
+	err := t.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if wait := time.Until(entry.LockedUntil); wait > 0 {
+		return ErrTooManyAttempts.WithDetails(map[string]int{"retryAfter": int(wait.Seconds()) + 1})
+	}
+	return nil
+}
+
+// fail records a failed attempt and locks key once the free attempts are
+// used up.
+func (t attemptTracker) fail(ctx context.Context, key string) error {
+	now := time.Now()
+	var entry attemptEntry
+	err := t.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$set": bson.M{"lastFailureAt": now, "expiresAt": now.Add(attemptsWindow)},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&entry)
+	if err != nil {
+		return err
+	}
+	if entry.Failures < attemptsFree {
+		return nil
+	}
+
+	_, err = t.collection.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$set": bson.M{"lockedUntil": now.Add(attemptsBackoff(entry.Failures - attemptsFree))}},
+	)
+	return err
+}
+
+// reset clears the counter after a successful attempt.
+func (t attemptTracker) reset(ctx context.Context, key string) error {
+	_, err := t.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
+
+// attemptsBackoff returns the lock duration after n failures over the free
+// limit, starting at zero.
+func attemptsBackoff(n int) time.Duration {
+	delay := attemptsBaseDelay
+	for i := 0; i < n && delay < attemptsMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > attemptsMaxDelay {
+		delay = attemptsMaxDelay
+	}
+	return delay
+}
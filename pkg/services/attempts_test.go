@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+func TestAttemptsBackoff(t *testing.T) {
+	tests := []struct {
+		n    int
+		want time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, time.Minute},
+		{4, 8 * time.Minute},
+		{5, attemptsMaxDelay},
+		{100, attemptsMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := attemptsBackoff(tt.n); got != tt.want {
+			t.Errorf("attemptsBackoff(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestAttemptTracker(t *testing.T) {
+	runMock(t, "locked", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.auth_attempts", bson.D{
+			{Key: "_id", Value: "login:user@example.com"},
+			{Key: "failures", Value: 6},
+			{Key: "lockedUntil", Value: time.Now().Add(time.Minute)},
+		}))
+		tracker := attemptTracker{collection: mt.DB.Collection("auth_attempts")}
+
+		// WithDetails возвращает копию, поэтому сравниваем по коду
+		e := apperr.From(tracker.check(context.Background(), "login:user@example.com"))
+		if e.Code != ErrTooManyAttempts.Code {
+			mt.Fatalf("err = %v", e)
+		}
+		details, _ := e.Details.(map[string]int)
+		if retry := details["retryAfter"]; retry < 1 || retry > 61 {
+			mt.Errorf("retryAfter = %d", retry)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		failures int
+		locked   bool
+	}{
+		{"free attempt", attemptsFree - 1, false},
+		{"over the limit", attemptsFree + 1, true},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(
+				acknowledged(bson.E{Key: "value", Value: bson.D{{Key: "failures", Value: tt.failures}}}),
+				modified(1),
+			)
+			tracker := attemptTracker{collection: mt.DB.Collection("auth_attempts")}
+
+			if err := tracker.fail(context.Background(), "login:user@example.com"); err != nil {
+				mt.Fatal(err)
+			}
+			lock, locked := findCommand(sentCommands(mt), "update", "auth_attempts")
+			if locked != tt.locked {
+				mt.Fatalf("locked = %v, want %v", locked, tt.locked)
+			}
+			if !locked {
+				return
+			}
+			until := statement(lock, "updates").Lookup("u", "$set", "lockedUntil").Time()
+			if wait := time.Until(until); wait < time.Minute-time.Second || wait > time.Minute {
+				mt.Errorf("locked for %v, want 1m", wait)
+			}
+		})
+	}
+}
+
+func TestLoginThrottling(t *testing.T) {
+	runMock(t, "locked email", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.auth_attempts", bson.D{
+			{Key: "failures", Value: attemptsFree},
+			{Key: "lockedUntil", Value: time.Now().Add(time.Minute)},
+		}))
+		auth := newTestAuthService(mt)
+
+		_, err := auth.Login(context.Background(), models.LoginRequest{Email: "User@Example.com", Password: "secret123"})
+		if apperr.From(err).Code != ErrTooManyAttempts.Code {
+			mt.Fatalf("err = %v", err)
+		}
+		// Пока ключ заблокирован, пароль не проверяется вовсе
+		if _, ok := findCommand(sentCommands(mt), "find", "users"); ok {
+			mt.Error("password was checked while locked")
+		}
+	})
+
+	runMock(t, "unknown email", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			found("test.auth_attempts"),
+			found("test.users"),
+			acknowledged(bson.E{Key: "value", Value: bson.D{{Key: "failures", Value: 1}}}),
+		)
+		auth := newTestAuthService(mt)
+
+		_, err := auth.Login(context.Background(), models.LoginRequest{Email: "nobody@example.com", Password: "secret123"})
+		if !errors.Is(err, ErrInvalidCredentials) {
+			mt.Fatalf("err = %v", err)
+		}
+		failure, ok := findCommand(sentCommands(mt), "findAndModify", "auth_attempts")
+		if !ok {
+			mt.Fatal("failure for an unknown email was not counted")
+		}
+		if got := failure.Lookup("query", "_id").StringValue(); got != "login:nobody@example.com" {
+			mt.Errorf("attempt key = %q", got)
+		}
+	})
+}
+
+func TestGenerateVerificationCode(t *testing.T) {
+	auth := &AuthService{}
+	format := regexp.MustCompile(`^[1-9][0-9]{5}$`)
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		code, err := auth.generateVerificationCode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !format.MatchString(code) {
+			t.Fatalf("code %q is not 6 digits", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) < 45 {
+		t.Errorf("only %d distinct codes out of 50", len(seen))
+	}
+}
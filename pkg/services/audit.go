@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"neomovies-api/pkg/logger"
+	"neomovies-api/pkg/models"
+)
+
+// AuditService stores account changes in the audit_log collection.
+type AuditService struct {
+	db *mongo.Database
+}
+
+func NewAuditService(db *mongo.Database) *AuditService {
+	return &AuditService{db: db}
+}
+
+// EnsureIndexes creates the index used to read a user's audit trail.
+func (s *AuditService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("audit_log").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}},
+	})
+	return err
+}
+
+// Record stores entry, filling in the request ID, client address and time
+// from ctx. The change it describes has already been applied, so a failed
+// write is logged rather than returned.
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry) {
+	if entry.ActorID == "" {
+		entry.ActorID = entry.UserID
+	}
+	if info := logger.RequestInfoFromContext(ctx); info != nil {
+		entry.RequestID = info.ID
+		entry.RemoteIP = info.RemoteIP
+		entry.UserAgent = info.UserAgent
+	}
+	entry.CreatedAt = time.Now()
+
+	// Запись не должна теряться, если клиент уже закрыл соединение
+	if _, err := s.db.Collection("audit_log").InsertOne(context.WithoutCancel(ctx), entry); err != nil {
+		logger.FromContext(ctx).Error("failed to write audit entry", "action", entry.Action, "error", err)
+	}
+}
@@ -2,40 +2,54 @@ package services
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"io"
-	"math/rand"
+	"log/slog"
+	"crypto/rand"
+	"crypto/subtle"
+	"math/big"
 	"net/http"
-	"net/url"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	"encoding/json"
 
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/metrics"
 	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/oauth"
 )
 
+// verificationCodeTTL — срок действия кода подтверждения email
+const verificationCodeTTL = 10 * time.Minute
+
 // AuthService contains the database connection, JWT secret, and email service.
 type AuthService struct {
 	db           *mongo.Database
 	jwtSecret    string
 	emailService *EmailService
+	sessions     *SessionService
+	audit        *AuditService
+	attempts     attemptTracker
 	baseURL      string
-	googleClientID     string
-	googleClientSecret string
-	googleRedirectURL  string
-	frontendURL        string
+	frontendURL  string
+	providers    *oauth.Registry
 }
 
+var (
+	ErrEmailTaken              = apperr.Conflict("email already registered").WithCode("email_taken")
+	ErrInvalidCredentials      = apperr.Unauthorized("invalid email or password").WithCode("invalid_credentials")
+	ErrEmailNotVerified        = apperr.Forbidden("Account not activated. Please verify your email.").WithCode("email_not_verified")
+	ErrUserNotFound            = apperr.NotFound("user not found").WithCode("user_not_found")
+	ErrInvalidVerificationCode = apperr.Validation("invalid or expired verification code").WithCode("invalid_verification_code")
+	ErrAccountBanned           = apperr.Forbidden("account is banned").WithCode("account_banned")
+)
+
 // Reaction represents a reaction entry in the database.
 type Reaction struct {
     MediaID string `bson:"mediaId"`
@@ -43,168 +57,135 @@ type Reaction struct {
     UserID  primitive.ObjectID `bson:"userId"`
 }
 
+// EnsureIndexes normalizes emails stored before lookups were normalized and
+// creates indexes used by password reset lookups, the expiry of failed
+// attempt counters and OAuth identity lookups.
+func (s *AuthService) EnsureIndexes(ctx context.Context) error {
+	if err := s.normalizeStoredEmails(ctx); err != nil {
+		return err
+	}
+
+	_, err := s.db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "resetPasswordToken", Value: 1}},
+		Options: options.Index().SetSparse(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection("password_reset_requests").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.attempts.ensureIndexes(ctx); err != nil {
+		return err
+	}
+
+	return s.ensureOAuthIndexes(ctx)
+}
+
 // NewAuthService creates and initializes a new AuthService.
-func NewAuthService(db *mongo.Database, jwtSecret string, emailService *EmailService, baseURL string, googleClientID string, googleClientSecret string, googleRedirectURL string, frontendURL string) *AuthService {
+func NewAuthService(db *mongo.Database, jwtSecret string, emailService *EmailService, sessions *SessionService, audit *AuditService, baseURL string, frontendURL string, providers *oauth.Registry) *AuthService {
 	service := &AuthService{
 		db:           db,
 		jwtSecret:    jwtSecret,
 		emailService: emailService,
+		sessions:     sessions,
+		audit:        audit,
+		attempts:     attemptTracker{collection: db.Collection("auth_attempts")},
 		baseURL:      baseURL,
-		googleClientID:     googleClientID,
-		googleClientSecret: googleClientSecret,
-		googleRedirectURL:  googleRedirectURL,
-		frontendURL:        frontendURL,
+		frontendURL:  frontendURL,
+		providers:    providers,
 	}
 	return service
 }
 
-func (s *AuthService) googleOAuthConfig() *oauth2.Config {
-	redirectURL := s.googleRedirectURL
-	if redirectURL == "" && s.baseURL != "" {
-		redirectURL = fmt.Sprintf("%s/api/v1/auth/google/callback", s.baseURL)
-	}
-	return &oauth2.Config{
-		ClientID:     s.googleClientID,
-		ClientSecret: s.googleClientSecret,
-		RedirectURL:  redirectURL,
-		Scopes:       []string{"openid", "email", "profile"},
-		Endpoint:     google.Endpoint,
-	}
-}
-
-func (s *AuthService) GetGoogleLoginURL(state string) (string, error) {
-	cfg := s.googleOAuthConfig()
-	if cfg.ClientID == "" || cfg.ClientSecret == "" || cfg.RedirectURL == "" {
-		return "", errors.New("google oauth not configured")
+// generateVerificationCode creates a 6-digit verification code.
+func (s *AuthService) generateVerificationCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(900000))
+	if err != nil {
+		return "", err
 	}
-	return cfg.AuthCodeURL(state, oauth2.AccessTypeOffline), nil
-}
-
-type googleUserInfo struct {
-	Sub     string `json:"sub"`
-	Email   string `json:"email"`
-	Name    string `json:"name"`
-	Picture string `json:"picture"`
-	EmailVerified bool `json:"email_verified"`
+	return fmt.Sprintf("%06d", n.Int64()+100000), nil
 }
 
-// BuildFrontendRedirect builds frontend URL for redirect after OAuth; returns false if not configured
-func (s *AuthService) BuildFrontendRedirect(token string, authErr string) (string, bool) {
-	if s.frontendURL == "" {
-		return "", false
-	}
-	if authErr != "" {
-		u, _ := url.Parse(s.frontendURL + "/login")
-		q := u.Query()
-		q.Set("oauth", "google")
-		q.Set("error", authErr)
-		u.RawQuery = q.Encode()
-		return u.String(), true
-	}
-	u, _ := url.Parse(s.frontendURL + "/auth/callback")
-	q := u.Query()
-	q.Set("provider", "google")
-	q.Set("token", token)
-	u.RawQuery = q.Encode()
-	return u.String(), true
+// normalizeEmail приводит адрес к виду, в котором он хранится в users:
+// без пробелов по краям и в нижнем регистре. Адреса, сохраненные до
+// нормализации, приводит normalizeStoredEmails
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
-func (s *AuthService) HandleGoogleCallback(ctx context.Context, code string) (*models.AuthResponse, error) {
-	cfg := s.googleOAuthConfig()
-	tok, err := cfg.Exchange(ctx, code)
-	if err != nil {
-		return nil, fmt.Errorf("failed to exchange code: %w", err)
-	}
-
-	client := cfg.Client(ctx, tok)
-	resp, err := client.Get("https://www.googleapis.com/oauth2/v3/userinfo")
+// normalizeStoredEmails rewrites emails saved as typed by older versions of
+// Register, so that lookups by the normalized address find them. Accounts
+// whose normalized address collides with another account are left as they
+// are and logged: merging them needs a decision about which one to keep.
+func (s *AuthService) normalizeStoredEmails(ctx context.Context) error {
+	users := s.db.Collection("users")
+	cursor, err := users.Find(ctx, bson.M{
+		"email": bson.M{"$type": "string"},
+		"$expr": bson.M{"$ne": bson.A{"$email", bson.M{"$toLower": bson.M{"$trim": bson.M{"input": "$email"}}}}},
+	}, options.Find().SetProjection(bson.M{"email": 1}))
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch userinfo: %w", err)
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("userinfo request failed: status %d", resp.StatusCode)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+		return err
 	}
-	var gUser googleUserInfo
-	if err := json.Unmarshal(body, &gUser); err != nil {
-		return nil, fmt.Errorf("failed to parse userinfo: %w", err)
+	var legacy []models.User
+	if err := cursor.All(ctx, &legacy); err != nil {
+		return err
 	}
-	if gUser.Email == "" {
-		return nil, errors.New("email not provided by Google")
-	}
-
-	collection := s.db.Collection("users")
 
-	// Try by googleId first
-	var user models.User
-	err = collection.FindOne(ctx, bson.M{"googleId": gUser.Sub}).Decode(&user)
-	if err == mongo.ErrNoDocuments {
-		// Try by email
-		err = collection.FindOne(ctx, bson.M{"email": gUser.Email}).Decode(&user)
+	// Разные написания одного адреса конфликтуют и между собой
+	var emails []string
+	byEmail := make(map[string][]models.User)
+	for _, user := range legacy {
+		email := normalizeEmail(user.Email)
+		if _, ok := byEmail[email]; !ok {
+			emails = append(emails, email)
+		}
+		byEmail[email] = append(byEmail[email], user)
 	}
-	if err == mongo.ErrNoDocuments {
-		// Create new user
-		user = models.User{
-			ID:        primitive.NewObjectID(),
-			Email:     gUser.Email,
-			Password:  "",
-			Name:      gUser.Name,
-			Avatar:    gUser.Picture,
-			Favorites: []string{},
-			Verified:  true,
-			IsAdmin:   false,
-			AdminVerified: false,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			Provider:  "google",
-			GoogleID:  gUser.Sub,
+
+	for _, email := range emails {
+		group := byEmail[email]
+		taken, err := users.CountDocuments(ctx, bson.M{"email": email})
+		if err != nil {
+			return err
 		}
-		if _, err := collection.InsertOne(ctx, user); err != nil {
-			return nil, err
+		if taken > 0 || len(group) > 1 {
+			ids := make([]string, len(group))
+			for i, user := range group {
+				ids[i] = user.ID.Hex()
+			}
+			slog.Warn("email not normalized: another account has the same address",
+				"user_ids", ids, "normalized_accounts", taken)
+			continue
 		}
-	} else if err != nil {
-		return nil, err
-	} else {
-		// Existing user: ensure fields
-		update := bson.M{
-			"verified": true,
-			"provider": "google",
-			"googleId": gUser.Sub,
-			"updatedAt": time.Now(),
+		// Условие на старый адрес не дает затереть адрес, измененный тем временем
+		_, err = users.UpdateOne(ctx,
+			bson.M{"_id": group[0].ID, "email": group[0].Email},
+			bson.M{"$set": bson.M{"email": email}},
+		)
+		if err != nil {
+			return err
 		}
-		if user.Name == "" && gUser.Name != "" { update["name"] = gUser.Name }
-		if user.Avatar == "" && gUser.Picture != "" { update["avatar"] = gUser.Picture }
-		_, _ = collection.UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": update})
-	}
-
-	// Generate JWT
-	if user.ID.IsZero() {
-		// If we created user above, we already have user.ID set; else fetch updated
-		_ = collection.FindOne(ctx, bson.M{"email": gUser.Email}).Decode(&user)
 	}
-	token, err := s.generateJWT(user.ID.Hex())
-	if err != nil { return nil, err }
-
-	return &models.AuthResponse{ Token: token, User: user }, nil
-}
-
-// generateVerificationCode creates a 6-digit verification code.
-func (s *AuthService) generateVerificationCode() string {
-	return fmt.Sprintf("%06d", rand.Intn(900000)+100000)
+	return nil
 }
 
 // Register registers a new user.
 func (s *AuthService) Register(req models.RegisterRequest) (map[string]interface{}, error) {
 	collection := s.db.Collection("users")
 
+	email := normalizeEmail(req.Email)
+
 	var existingUser models.User
-	err := collection.FindOne(context.Background(), bson.M{"email": req.Email}).Decode(&existingUser)
+	err := collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&existingUser)
 	if err == nil {
-		return nil, errors.New("email already registered")
+		return nil, ErrEmailTaken
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
@@ -212,12 +193,15 @@ func (s *AuthService) Register(req models.RegisterRequest) (map[string]interface
 		return nil, err
 	}
 
-	code := s.generateVerificationCode()
-	codeExpires := time.Now().Add(10 * time.Minute)
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+	codeExpires := time.Now().Add(verificationCodeTTL)
 
 	user := models.User{
 		ID:                 primitive.NewObjectID(),
-		Email:              req.Email,
+		Email:              email,
 		Password:           string(hashedPassword),
 		Name:               req.Name,
 		Favorites:          []string{},
@@ -226,6 +210,7 @@ func (s *AuthService) Register(req models.RegisterRequest) (map[string]interface
 		VerificationExpires: codeExpires,
 		IsAdmin:            false,
 		AdminVerified:      false,
+		Preferences:        models.UserPreferences{Language: req.Language},
 		CreatedAt:          time.Now(),
 		UpdatedAt:          time.Now(),
 	}
@@ -235,8 +220,12 @@ func (s *AuthService) Register(req models.RegisterRequest) (map[string]interface
 		return nil, err
 	}
 
+	// Пользователь уже создан: без письма он запросит код повторно через
+	// resend-code, а ошибка заставила бы его регистрироваться заново
 	if s.emailService != nil {
-		go s.emailService.SendVerificationEmail(user.Email, code)
+		if err := s.emailService.SendVerificationEmail(context.Background(), user.Email, user.Preferences.Language, code); err != nil {
+			slog.Warn("failed to queue verification email", "userId", user.ID.Hex(), "error", err)
+		}
 	}
 
 	return map[string]interface{}{
@@ -245,36 +234,82 @@ func (s *AuthService) Register(req models.RegisterRequest) (map[string]interface
 	}, nil
 }
 
-// Login authenticates a user.
-func (s *AuthService) Login(req models.LoginRequest) (*models.AuthResponse, error) {
-	collection := s.db.Collection("users")
-    
+// dummyPasswordHash is compared against when the email is unknown, so the
+// response time does not reveal whether an account exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("neomovies-dummy-password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// Login authenticates a user. Unknown emails and wrong passwords produce the
+// same error, and repeated failures lock the email with exponential backoff.
+func (s *AuthService) Login(ctx context.Context, req models.LoginRequest) (*models.AuthResponse, error) {
+	key := attemptKey("login", req.Email)
+	if err := s.attempts.check(ctx, key); err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	err := collection.FindOne(context.Background(), bson.M{"email": req.Email}).Decode(&user)
-	if err != nil {
-		return nil, errors.New("User not found")
+	err := s.db.Collection("users").FindOne(ctx, bson.M{"email": normalizeEmail(req.Email)}).Decode(&user)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	hash := []byte(user.Password)
+	if err == mongo.ErrNoDocuments {
+		hash = dummyPasswordHash()
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(req.Password)) != nil || err == mongo.ErrNoDocuments {
+		if err := s.attempts.fail(ctx, key); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidCredentials
+	}
+	if err := s.attempts.reset(ctx, key); err != nil {
+		return nil, err
 	}
 
+	// Статус подтверждения раскрываем только знающему пароль
 	if !user.Verified {
-		return nil, errors.New("Account not activated. Please verify your email.")
+		return nil, ErrEmailNotVerified
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
+	return s.issueAuthResponse(ctx, user)
+}
+
+// RefreshTokens exchanges a refresh token for a new access/refresh pair.
+func (s *AuthService) RefreshTokens(ctx context.Context, refreshToken string) (*models.AuthResponse, error) {
+	pair, userID, err := s.sessions.Refresh(ctx, refreshToken)
 	if err != nil {
-		return nil, errors.New("Invalid password")
+		return nil, err
 	}
 
-	token, err := s.generateJWT(user.ID.Hex())
+	user, err := s.GetUserByID(userID)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidRefreshToken
+	}
+	if user.Banned {
+		return nil, ErrAccountBanned
 	}
 
 	return &models.AuthResponse{
-		Token: token,
-		User:  user,
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+		User:         *user,
 	}, nil
 }
 
+// Logout revokes the current session and its access token.
+func (s *AuthService) Logout(ctx context.Context, userID, sessionID, tokenID string) error {
+	return s.sessions.Revoke(ctx, userID, sessionID, tokenID)
+}
+
+// LogoutAll revokes every session of the user.
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	return s.sessions.RevokeAllForUser(ctx, userID)
+}
+
 // GetUserByID retrieves a user by their ID.
 func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
 	collection := s.db.Collection("users")
@@ -293,77 +328,140 @@ func (s *AuthService) GetUserByID(userID string) (*models.User, error) {
 	return &user, nil
 }
 
-// UpdateUser updates a user's information.
-func (s *AuthService) UpdateUser(userID string, updates bson.M) (*models.User, error) {
-	collection := s.db.Collection("users")
-
+// IsAdmin reports whether the user may use the admin API. The flag is read
+// on every request, so revoking it or banning the user takes effect at once.
+func (s *AuthService) IsAdmin(ctx context.Context, userID string) (bool, error) {
 	objectID, err := primitive.ObjectIDFromHex(userID)
 	if err != nil {
-		return nil, err
+		return false, nil
 	}
 
-	updates["updated_at"] = time.Now()
+	var user models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"_id": objectID},
+		options.FindOne().SetProjection(bson.M{"isAdmin": 1, "banned": 1})).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin && !user.Banned, nil
+}
 
-	_, err = collection.UpdateOne(
-		context.Background(),
-		bson.M{"_id": objectID},
-		bson.M{"$set": updates},
-	)
+// UpdateProfile applies the allow-listed profile fields of req and records
+// what changed in the audit log.
+func (s *AuthService) UpdateProfile(ctx context.Context, userID string, req models.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.GetUserByID(userID)
 	if err != nil {
 		return nil, err
 	}
 
+	set := bson.M{}
+	changes := map[string]models.AuditChange{}
+	if req.Name != nil {
+		if name := strings.TrimSpace(*req.Name); name != user.Name {
+			set["name"] = name
+			changes["name"] = models.AuditChange{From: user.Name, To: name}
+		}
+	}
+	if req.Avatar != nil {
+		if avatar := strings.TrimSpace(*req.Avatar); avatar != user.Avatar {
+			set["avatar"] = avatar
+			changes["avatar"] = models.AuditChange{From: user.Avatar, To: avatar}
+		}
+	}
+	if req.Preferences != nil && *req.Preferences != user.Preferences {
+		set["preferences"] = *req.Preferences
+		changes["preferences"] = models.AuditChange{From: user.Preferences, To: *req.Preferences}
+	}
+	if len(set) == 0 {
+		return user, nil
+	}
+	set["updatedAt"] = time.Now()
+
+	if _, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, bson.M{"$set": set}); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditProfileUpdated, Changes: changes})
+
 	return s.GetUserByID(userID)
 }
 
-// generateJWT generates a new JWT for a given user ID.
-func (s *AuthService) generateJWT(userID string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
-		"iat":     time.Now().Unix(),
-		"jti":     uuid.New().String(),
+// issueAuthResponse starts a new session and returns the user with its tokens.
+func (s *AuthService) issueAuthResponse(ctx context.Context, user models.User) (*models.AuthResponse, error) {
+	if user.Banned {
+		return nil, ErrAccountBanned
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.jwtSecret))
+	pair, err := s.sessions.CreateSession(ctx, user.ID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuthResponse{
+		Token:        pair.AccessToken,
+		RefreshToken: pair.RefreshToken,
+		ExpiresIn:    pair.ExpiresIn,
+		User:         user,
+	}, nil
 }
 
-// VerifyEmail verifies a user's email with a code.
-func (s *AuthService) VerifyEmail(req models.VerifyEmailRequest) (map[string]interface{}, error) {
+// maxCodeAttempts is how many wrong guesses a single emailed code allows.
+// After that the code is discarded and a new one has to be requested.
+const maxCodeAttempts = 5
+
+// VerifyEmail verifies a user's email with a code. An unknown email is
+// reported as an invalid code.
+func (s *AuthService) VerifyEmail(ctx context.Context, req models.VerifyEmailRequest) (map[string]interface{}, error) {
+	key := attemptKey("verify", req.Email)
+	if err := s.attempts.check(ctx, key); err != nil {
+		return nil, err
+	}
+
 	collection := s.db.Collection("users")
 
 	var user models.User
-	err := collection.FindOne(context.Background(), bson.M{"email": req.Email}).Decode(&user)
-	if err != nil {
-		return nil, errors.New("user not found")
+	err := collection.FindOne(ctx, bson.M{"email": normalizeEmail(req.Email)}).Decode(&user)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
 	}
 
-	if user.Verified {
+	if err == nil && user.Verified {
 		return map[string]interface{}{
 			"success": true,
 			"message": "Email already verified",
 		}, nil
 	}
 
-	if user.VerificationCode != req.Code || user.VerificationExpires.Before(time.Now()) {
-		return nil, errors.New("invalid or expired verification code")
+	if err == mongo.ErrNoDocuments || !codeMatches(user.VerificationCode, req.Code) || user.VerificationExpires.Before(time.Now()) {
+		if err == nil && user.VerificationCode != "" {
+			if err := s.burnCodeAttempt(ctx, user.ID, "verificationAttempts", user.VerificationAttempts, "verificationCode", "verificationExpires"); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.attempts.fail(ctx, key); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidVerificationCode
 	}
 
-	_, err = collection.UpdateOne(
-		context.Background(),
-		bson.M{"email": req.Email},
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
 		bson.M{
-			"$set": bson.M{"verified": true},
+			"$set": bson.M{"verified": true, "updatedAt": time.Now()},
 			"$unset": bson.M{
-				"verificationCode":    "",
-				"verificationExpires": "",
+				"verificationCode":     "",
+				"verificationExpires":  "",
+				"verificationAttempts": "",
 			},
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.attempts.reset(ctx, key); err != nil {
+		return nil, err
+	}
 
 	return map[string]interface{}{
 		"success": true,
@@ -371,31 +469,42 @@ func (s *AuthService) VerifyEmail(req models.VerifyEmailRequest) (map[string]int
 	}, nil
 }
 
-// ResendVerificationCode sends a new verification email.
-func (s *AuthService) ResendVerificationCode(req models.ResendCodeRequest) (map[string]interface{}, error) {
+// ResendVerificationCode sends a new verification email. The response is the
+// same whether or not the email is registered or already verified.
+func (s *AuthService) ResendVerificationCode(ctx context.Context, req models.ResendCodeRequest) (map[string]interface{}, error) {
+	response := map[string]interface{}{
+		"success": true,
+		"message": "If the email is registered and not yet verified, a new code has been sent",
+	}
+
 	collection := s.db.Collection("users")
 
 	var user models.User
-	err := collection.FindOne(context.Background(), bson.M{"email": req.Email}).Decode(&user)
+	err := collection.FindOne(ctx, bson.M{"email": normalizeEmail(req.Email)}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return response, nil
+	}
 	if err != nil {
-		return nil, errors.New("user not found")
+		return nil, err
 	}
-
 	if user.Verified {
-		return nil, errors.New("email already verified")
+		return response, nil
 	}
 
-	code := s.generateVerificationCode()
-	codeExpires := time.Now().Add(10 * time.Minute)
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+	codeExpires := time.Now().Add(verificationCodeTTL)
 
-	_, err = collection.UpdateOne(
-		context.Background(),
-		bson.M{"email": req.Email},
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
 		bson.M{
 			"$set": bson.M{
 				"verificationCode":    code,
 				"verificationExpires": codeExpires,
 			},
+			"$unset": bson.M{"verificationAttempts": ""},
 		},
 	)
 	if err != nil {
@@ -403,13 +512,62 @@ func (s *AuthService) ResendVerificationCode(req models.ResendCodeRequest) (map[
 	}
 
 	if s.emailService != nil {
-		go s.emailService.SendVerificationEmail(user.Email, code)
+		if err := s.emailService.SendVerificationEmail(ctx, user.Email, user.Preferences.Language, code); err != nil {
+			return nil, err
+		}
 	}
 
-	return map[string]interface{}{
-		"success": true,
-		"message": "Verification code sent to your email",
-	}, nil
+	return response, nil
+}
+
+// burnCodeAttempt counts a wrong guess of an emailed code stored in the
+// user document and discards the code once maxCodeAttempts is reached.
+// attempts is the counter value the guess was checked against.
+func (s *AuthService) burnCodeAttempt(ctx context.Context, userID primitive.ObjectID, counterField string, attempts int, codeFields ...string) error {
+	collection := s.db.Collection("users")
+	for {
+		// Фильтр по прочитанному значению счетчика: параллельные неверные
+		// попытки не посчитаются за одну и не обойдут стирание кода
+		filter := bson.M{"_id": userID, counterField: attempts}
+		if attempts == 0 {
+			filter[counterField] = bson.M{"$exists": false}
+		}
+		update := bson.M{"$inc": bson.M{counterField: 1}}
+		if attempts+1 >= maxCodeAttempts {
+			unset := bson.M{counterField: ""}
+			for _, f := range codeFields {
+				unset[f] = ""
+			}
+			update = bson.M{"$unset": unset}
+		}
+		result, err := collection.UpdateOne(ctx, filter, update)
+		if err != nil || result.MatchedCount > 0 {
+			return err
+		}
+
+		// Счетчик успел изменить другой запрос: перечитываем его и пробуем снова
+		var current bson.Raw
+		err = collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&current)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := current.LookupErr(codeFields[0]); err != nil {
+			// Код уже стерт: попытки исчерпаны или адрес подтвержден
+			return nil
+		}
+		attempts = 0
+		if value, err := current.LookupErr(counterField); err == nil {
+			attempts = int(value.AsInt64())
+		}
+	}
+}
+
+// codeMatches compares an emailed code in constant time.
+func codeMatches(stored, given string) bool {
+	return stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(given)) == 1
 }
 
 // DeleteAccount deletes a user and all associated data.
@@ -432,7 +590,7 @@ func (s *AuthService) DeleteAccount(ctx context.Context, userID string) error {
 		}
 
 		var wg sync.WaitGroup
-		client := &http.Client{Timeout: 10 * time.Second}
+		client := metrics.NewClient("cubrip", 10*time.Second)
 
 		for _, reaction := range userReactions {
 			wg.Add(1)
@@ -442,27 +600,32 @@ func (s *AuthService) DeleteAccount(ctx context.Context, userID string) error {
 				req, err := http.NewRequestWithContext(ctx, "POST", url, nil) // or "DELETE"
 				if err != nil {
 					// Log the error but don't stop the process
-					fmt.Printf("failed to create request for cub.rip: %v\n", err)
+					slog.Warn("failed to create request for cub.rip", "error", err)
 					return
 				}
 				
 				resp, err := client.Do(req)
 				if err != nil {
-					fmt.Printf("failed to send request to cub.rip: %v\n", err)
+					slog.Warn("failed to send request to cub.rip", "error", err)
 					return
 				}
 				defer resp.Body.Close()
 				
 				if resp.StatusCode != http.StatusOK {
 					body, _ := io.ReadAll(resp.Body)
-					fmt.Printf("cub.rip API responded with status %d: %s\n", resp.StatusCode, body)
+					slog.Warn("cub.rip API error", "status", resp.StatusCode, "body", string(body))
 				}
 			}(reaction)
 		}
 		wg.Wait()
 	}
 
-	// Step 2: Delete all user-related data from the database
+	// Step 2: Revoke sessions so issued tokens stop working immediately
+	if err := s.sessions.DeleteAllForUser(ctx, userID); err != nil {
+		return fmt.Errorf("failed to revoke user sessions: %w", err)
+	}
+
+	// Step 3: Delete all user-related data from the database
 	usersCollection := s.db.Collection("users")
 	favoritesCollection := s.db.Collection("favorites")
 	reactionsCollection := s.db.Collection("reactions")
@@ -482,5 +645,346 @@ func (s *AuthService) DeleteAccount(ctx context.Context, userID string) error {
 		return fmt.Errorf("failed to delete user reactions: %w", err)
 	}
 
+	_, err = s.db.Collection("watch_history").DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete user watch history: %w", err)
+	}
+
+	_, err = s.db.Collection("lists").DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete user lists: %w", err)
+	}
+
+	_, err = s.db.Collection("digest_items").DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete user digest history: %w", err)
+	}
+
+	_, err = s.db.Collection("follows").DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete user follows: %w", err)
+	}
+
+	_, err = s.db.Collection("notifications").DeleteMany(ctx, bson.M{"userId": userID})
+	if err != nil {
+		return fmt.Errorf("failed to delete user notifications: %w", err)
+	}
+
+	return nil
+}
+
+const (
+	passwordResetTTL         = time.Hour
+	passwordResetWindow      = time.Hour
+	passwordResetMaxRequests = 3
+)
+
+var (
+	ErrTooManyResetRequests = apperr.TooManyRequests("too many password reset requests, try again later").WithCode("too_many_reset_requests")
+	ErrInvalidResetToken    = apperr.Validation("invalid or expired reset token").WithCode("invalid_reset_token")
+)
+
+// RequestPasswordReset sends a single-use reset link to the user. The response
+// is the same whether or not the email is registered.
+func (s *AuthService) RequestPasswordReset(ctx context.Context, req models.ForgotPasswordRequest) error {
+	email := normalizeEmail(req.Email)
+	if err := s.checkPasswordResetRate(ctx, email); err != nil {
+		return err
+	}
+
+	collection := s.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := generateRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{"$set": bson.M{
+			"resetPasswordToken":   hashToken(token),
+			"resetPasswordExpires": time.Now().Add(passwordResetTTL),
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if s.emailService != nil {
+		return s.emailService.SendPasswordResetEmail(ctx, user.Email, user.Preferences.Language, token)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token and ends all sessions.
+func (s *AuthService) ResetPassword(ctx context.Context, req models.ResetPasswordRequest) error {
+	collection := s.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOne(ctx, bson.M{
+		"resetPasswordToken":   hashToken(req.Token),
+		"resetPasswordExpires": bson.M{"$gt": time.Now()},
+	}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	// Фильтр по токену делает сброс одноразовым даже при гонке запросов
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "resetPasswordToken": user.ResetPasswordToken},
+		bson.M{
+			"$set": bson.M{
+				"password":  string(hashedPassword),
+				"updatedAt": time.Now(),
+			},
+			"$unset": bson.M{
+				"resetPasswordToken":   "",
+				"resetPasswordExpires": "",
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.ModifiedCount == 0 {
+		return ErrInvalidResetToken
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{UserID: user.ID.Hex(), Action: models.AuditPasswordReset})
+	// Владелец почты подтвердил себя, блокировка входа больше не нужна
+	if err := s.attempts.reset(ctx, attemptKey("login", user.Email)); err != nil {
+		return err
+	}
+
+	return s.sessions.RevokeAllForUser(ctx, user.ID.Hex())
+}
+
+// checkPasswordResetRate limits reset requests per email address.
+func (s *AuthService) checkPasswordResetRate(ctx context.Context, email string) error {
+	collection := s.db.Collection("password_reset_requests")
+	now := time.Now()
+
+	var entry struct {
+		Count       int       `bson:"count"`
+		WindowStart time.Time `bson:"windowStart"`
+	}
+	err := collection.FindOne(ctx, bson.M{"_id": email}).Decode(&entry)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	if err == mongo.ErrNoDocuments || now.Sub(entry.WindowStart) > passwordResetWindow {
+		_, err = collection.UpdateOne(ctx,
+			bson.M{"_id": email},
+			bson.M{"$set": bson.M{"count": 1, "windowStart": now, "expiresAt": now.Add(passwordResetWindow)}},
+			options.Update().SetUpsert(true),
+		)
+		return err
+	}
+
+	if entry.Count >= passwordResetMaxRequests {
+		return ErrTooManyResetRequests
+	}
+
+	_, err = collection.UpdateOne(ctx, bson.M{"_id": email}, bson.M{"$inc": bson.M{"count": 1}})
+	return err
+}
+
+const emailChangeTTL = 10 * time.Minute
+
+var (
+	ErrInvalidPassword = apperr.Forbidden("current password is incorrect").WithCode("invalid_password")
+	ErrPasswordNotSet  = apperr.Conflict("account has no password, use password reset to set one").WithCode("password_not_set")
+	ErrEmailUnchanged  = apperr.Validation("new email matches the current one").WithCode("email_unchanged")
+)
+
+// RequestEmailChange sends a confirmation code to the new address. The email
+// is switched only after ConfirmEmailChange, so a typo cannot lock the user out.
+func (s *AuthService) RequestEmailChange(ctx context.Context, userID string, req models.ChangeEmailRequest) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCurrentPassword(ctx, user, req.Password); err != nil {
+		return err
+	}
+
+	newEmail := normalizeEmail(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+
+	collection := s.db.Collection("users")
+	count, err := collection.CountDocuments(ctx, bson.M{"email": newEmail})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEmailTaken
+	}
+
+	code, err := s.generateVerificationCode()
+	if err != nil {
+		return err
+	}
+	_, err = collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{
+				"pendingEmail":        newEmail,
+				"pendingEmailCode":    hashToken(code),
+				"pendingEmailExpires": time.Now().Add(emailChangeTTL),
+			},
+			"$unset": bson.M{"pendingEmailAttempts": ""},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		Action:  models.AuditEmailChangeRequested,
+		Changes: map[string]models.AuditChange{"email": {From: user.Email, To: newEmail}},
+	})
+
+	if s.emailService != nil {
+		return s.emailService.SendEmailChangeCode(ctx, newEmail, user.Preferences.Language, code)
+	}
 	return nil
 }
+
+// ConfirmEmailChange switches the account to the pending email. The new
+// address is verified by the code, so the account stays verified.
+func (s *AuthService) ConfirmEmailChange(ctx context.Context, userID string, req models.ConfirmEmailChangeRequest) (*models.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.PendingEmail == "" || !codeMatches(user.PendingEmailCode, hashToken(req.Code)) || user.PendingEmailExpires.Before(time.Now()) {
+		if user.PendingEmailCode != "" {
+			if err := s.burnCodeAttempt(ctx, user.ID, "pendingEmailAttempts", user.PendingEmailAttempts, "pendingEmail", "pendingEmailCode", "pendingEmailExpires"); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidVerificationCode
+	}
+
+	collection := s.db.Collection("users")
+	// Адрес мог занять кто-то другой, пока письмо шло
+	count, err := collection.CountDocuments(ctx, bson.M{"email": user.PendingEmail})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrEmailTaken
+	}
+
+	// Фильтр по коду делает подтверждение одноразовым
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": user.ID, "pendingEmailCode": user.PendingEmailCode},
+		bson.M{
+			"$set": bson.M{
+				"email":     user.PendingEmail,
+				"verified":  true,
+				"updatedAt": time.Now(),
+			},
+			"$unset": bson.M{
+				"pendingEmail":         "",
+				"pendingEmailCode":     "",
+				"pendingEmailExpires":  "",
+				"pendingEmailAttempts": "",
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, ErrInvalidVerificationCode
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		Action:  models.AuditEmailChanged,
+		Changes: map[string]models.AuditChange{"email": {From: user.Email, To: user.PendingEmail}},
+	})
+
+	return s.GetUserByID(userID)
+}
+
+// ChangePassword replaces the password after checking the current one and
+// ends every other session of the user.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID string, req models.ChangePasswordRequest) error {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkCurrentPassword(ctx, user, req.CurrentPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": user.ID},
+		bson.M{
+			"$set": bson.M{
+				"password":  string(hashedPassword),
+				"updatedAt": time.Now(),
+			},
+			// Выданная ранее ссылка сброса больше не нужна
+			"$unset": bson.M{
+				"resetPasswordToken":   "",
+				"resetPasswordExpires": "",
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{UserID: userID, Action: models.AuditPasswordChanged})
+
+	return s.sessions.RevokeOthers(ctx, userID, sessionID)
+}
+
+// checkCurrentPassword confirms a sensitive change with the user's password.
+// Accounts created through Google have none. Wrong guesses are throttled
+// like logins, so a stolen access token cannot be used to brute-force it.
+func (s *AuthService) checkCurrentPassword(ctx context.Context, user *models.User, password string) error {
+	if user.Password == "" {
+		return ErrPasswordNotSet
+	}
+	key := attemptKey("password", user.ID.Hex())
+	if err := s.attempts.check(ctx, key); err != nil {
+		return err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		if err := s.attempts.fail(ctx, key); err != nil {
+			return err
+		}
+		return ErrInvalidPassword
+	}
+	return s.attempts.reset(ctx, key)
+}
@@ -0,0 +1,565 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/models"
+)
+
+// Digest scheduling. Due users are looked up every digestPollInterval; a
+// digest that failed is retried after digestRetryDelay instead of waiting
+// for the next period.
+const (
+	digestPollInterval = time.Hour
+	digestRetryDelay   = time.Hour
+	// digestSeeds — сколько последних тайтлов из избранного и из реакций
+	// берется за основу рекомендаций
+	digestSeeds = 5
+	// digestItemTTL — через сколько уже отправленный тайтл может попасть
+	// в рассылку снова
+	digestItemTTL = 180 * 24 * time.Hour
+)
+
+// Digest tokens. The link in an email can only unsubscribe and expires
+// after digestUnsubscribeTTL. Opting back in needs the short-lived token
+// that Unsubscribe returns, so a forwarded email cannot resubscribe the
+// user later.
+const (
+	digestUnsubscribeTTL = 90 * 24 * time.Hour
+	digestResubscribeTTL = time.Hour
+
+	digestScopeUnsubscribe = "unsubscribe"
+	digestScopeResubscribe = "resubscribe"
+)
+
+// digestReactions are the reactions that count as liking a title.
+var digestReactions = []string{"fire", "nice"}
+
+// tmdbLanguages maps email locales to TMDB languages.
+var tmdbLanguages = map[string]string{
+	"ru": "ru-RU",
+	"en": "en-US",
+	"uk": "uk-UA",
+}
+
+// tmdbLanguage returns the TMDB language for a user language preference.
+func tmdbLanguage(lang string) string {
+	return tmdbLanguages[mail.NormalizeLocale(lang)]
+}
+
+var (
+	ErrInvalidDigestToken = apperr.Validation("invalid unsubscribe token").WithCode("invalid_digest_token")
+	ErrDigestTokenExpired = apperr.Validation("unsubscribe link has expired").WithCode("digest_token_expired")
+)
+
+// DigestService sends the periodic recommendation digest to users who
+// opted in. Recommendations come from TMDB for the user's latest favorites
+// and positive reactions, minus everything the user has already favorited,
+// reacted to, watched or been sent before.
+type DigestService struct {
+	db       *mongo.Database
+	tmdb     *TMDBService
+	email    *EmailService
+	secret   []byte
+	interval time.Duration
+	size     int
+	baseURL  string
+	frontend string
+}
+
+func NewDigestService(db *mongo.Database, cfg *config.Config, tmdb *TMDBService, email *EmailService) *DigestService {
+	return &DigestService{
+		db:       db,
+		tmdb:     tmdb,
+		email:    email,
+		secret:   digestKey(cfg),
+		interval: cfg.DigestInterval,
+		size:     cfg.DigestSize,
+		baseURL:  cfg.BaseURL,
+		frontend: cfg.FrontendURL,
+	}
+}
+
+// digestKey returns the key digest tokens are signed with.
+func digestKey(cfg *config.Config) []byte {
+	if cfg.DigestSecret != "" {
+		return []byte(cfg.DigestSecret)
+	}
+	// Без DIGEST_SECRET (он обязателен только в production) ключ выводится
+	// из JWT_SECRET, чтобы хотя бы не совпадать с ключом сессий
+	mac := hmac.New(sha256.New, []byte(cfg.JWTSecret))
+	mac.Write([]byte("digest-secret"))
+	return mac.Sum(nil)
+}
+
+// EnsureIndexes creates the index the scheduler polls and the index that
+// deduplicates and expires sent titles.
+func (s *DigestService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("users").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "digestSentAt", Value: 1}},
+		Options: options.Index().
+			SetName("digest_due").
+			SetPartialFilterExpression(bson.M{"digestSubscribed": true}),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = s.items().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "mediaType", Value: 1}, {Key: "mediaId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "sentAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(digestItemTTL.Seconds())),
+		},
+	})
+	return err
+}
+
+// Subscription returns the digest setting of the user.
+func (s *DigestService) Subscription(ctx context.Context, userID string) (*models.DigestSubscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	var user models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"_id": objectID},
+		options.FindOne().SetProjection(bson.M{"digestSubscribed": 1, "digestSentAt": 1}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.DigestSubscription{Subscribed: user.DigestSubscribed, LastSentAt: user.DigestSentAt}, nil
+}
+
+// SetSubscribed opts the user in or out. Only verified addresses get
+// digests, so opting in requires a verified email.
+func (s *DigestService) SetSubscribed(ctx context.Context, userID string, subscribed bool) (*models.DigestSubscription, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	var user models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"_id": objectID},
+		options.FindOne().SetProjection(bson.M{"verified": 1}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if subscribed && !user.Verified {
+		return nil, ErrEmailNotVerified
+	}
+
+	if err := s.setSubscribed(ctx, objectID, subscribed); err != nil {
+		return nil, err
+	}
+	return s.Subscription(ctx, userID)
+}
+
+// Unsubscribe opts out the user the email token was issued for and returns
+// a token for Resubscribe. Repeating it is not an error.
+func (s *DigestService) Unsubscribe(ctx context.Context, token string) (string, error) {
+	objectID, err := s.parseToken(token, digestScopeUnsubscribe, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if err := s.setSubscribed(ctx, objectID, false); err != nil {
+		return "", err
+	}
+	return s.token(objectID.Hex(), digestScopeResubscribe, time.Now().Add(digestResubscribeTTL)), nil
+}
+
+// Resubscribe undoes Unsubscribe, so the page behind the unsubscribe link
+// can offer to opt back in without logging in. It only accepts the token
+// returned by Unsubscribe.
+func (s *DigestService) Resubscribe(ctx context.Context, token string) error {
+	objectID, err := s.parseToken(token, digestScopeResubscribe, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.setSubscribed(ctx, objectID, true)
+}
+
+func (s *DigestService) setSubscribed(ctx context.Context, userID primitive.ObjectID, subscribed bool) error {
+	update := bson.M{"$set": bson.M{"digestSubscribed": true, "updatedAt": time.Now()}}
+	if !subscribed {
+		update = bson.M{
+			"$set":   bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{"digestSubscribed": ""},
+		}
+	}
+	res, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Token returns the unsubscribe token put into a digest for the user.
+func (s *DigestService) Token(userID string) string {
+	return s.token(userID, digestScopeUnsubscribe, time.Now().Add(digestUnsubscribeTTL))
+}
+
+// token имеет вид userID.scope.expires.signature, где expires — Unix-время
+func (s *DigestService) token(userID, scope string, expires time.Time) string {
+	payload := userID + "." + scope + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(s.sign(payload))
+}
+
+func (s *DigestService) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, s.secret)
+	// Префикс отделяет эту подпись от других, сделанных тем же секретом
+	mac.Write([]byte("digest:" + payload))
+	return mac.Sum(nil)
+}
+
+func (s *DigestService) parseToken(token, scope string, now time.Time) (primitive.ObjectID, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return primitive.NilObjectID, ErrInvalidDigestToken
+	}
+	payload := token[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !hmac.Equal(mac, s.sign(payload)) {
+		return primitive.NilObjectID, ErrInvalidDigestToken
+	}
+
+	parts := strings.Split(payload, ".")
+	if len(parts) != 3 || parts[1] != scope {
+		return primitive.NilObjectID, ErrInvalidDigestToken
+	}
+	objectID, err := primitive.ObjectIDFromHex(parts[0])
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidDigestToken
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidDigestToken
+	}
+	if now.Unix() >= expires {
+		return primitive.NilObjectID, ErrDigestTokenExpired
+	}
+	return objectID, nil
+}
+
+// Run sends due digests until ctx is cancelled.
+func (s *DigestService) Run(ctx context.Context) {
+	ticker := time.NewTicker(digestPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("digest poll failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue sends a digest to every subscribed user whose last one is
+// older than the interval and returns how many users were processed.
+func (s *DigestService) ProcessDue(ctx context.Context) (int, error) {
+	processed := 0
+	for ctx.Err() == nil {
+		user, err := s.claim(ctx)
+		if err != nil || user == nil {
+			return processed, err
+		}
+		processed++
+
+		if err := s.send(ctx, user); err != nil {
+			slog.Warn("digest failed", "userId", user.ID.Hex(), "error", err)
+			// Повтор через digestRetryDelay, а не через полный интервал
+			retryAt := time.Now().Add(digestRetryDelay - s.interval)
+			if _, err := s.db.Collection("users").UpdateOne(ctx,
+				bson.M{"_id": user.ID},
+				bson.M{"$set": bson.M{"digestSentAt": retryAt}},
+			); err != nil {
+				return processed, err
+			}
+		}
+	}
+	return processed, nil
+}
+
+// claim marks one due user as sent, so concurrent instances do not pick
+// the same user, and returns it; nil means nobody is due.
+func (s *DigestService) claim(ctx context.Context) (*models.User, error) {
+	now := time.Now()
+	filter := bson.M{
+		"digestSubscribed": true,
+		"verified":         true,
+		"banned":           bson.M{"$ne": true},
+		"$or": bson.A{
+			bson.M{"digestSentAt": bson.M{"$exists": false}},
+			bson.M{"digestSentAt": bson.M{"$lte": now.Add(-s.interval)}},
+		},
+	}
+	var user models.User
+	err := s.db.Collection("users").FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"digestSentAt": now}},
+		options.FindOneAndUpdate().SetProjection(bson.M{
+			"email": 1, "name": 1, "preferences": 1,
+		}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *DigestService) send(ctx context.Context, user *models.User) error {
+	locale := mail.NormalizeLocale(user.Preferences.Language)
+	titles, err := s.Recommend(ctx, user.ID.Hex(), locale)
+	if err != nil {
+		return err
+	}
+	// Нечего рекомендовать — письмо не отправляем, попробуем в следующий раз
+	if len(titles) == 0 {
+		return nil
+	}
+
+	token := url.QueryEscape(s.Token(user.ID.Hex()))
+	frontend := s.frontend
+	if frontend == "" {
+		frontend = s.baseURL
+	}
+	err = s.email.SendMovieRecommendationEmail(ctx, user.Email, locale, RecommendationEmail{
+		Name:           user.Name,
+		Titles:         titles,
+		UnsubscribeURL: frontend + "/unsubscribe?token=" + token,
+		OneClickURL:    s.baseURL + "/api/v1/digest/unsubscribe?token=" + token,
+	})
+	if err != nil {
+		return err
+	}
+	return s.remember(ctx, user.ID.Hex(), titles)
+}
+
+// Recommend picks up to the configured number of titles for the user,
+// taking recommendations for each seed title in turn.
+func (s *DigestService) Recommend(ctx context.Context, userID, locale string) ([]RecommendedTitle, error) {
+	seeds, err := s.seeds(ctx, userID)
+	if err != nil || len(seeds) == 0 {
+		return nil, err
+	}
+	seen, err := s.seen(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	language := tmdbLanguage(locale)
+	var (
+		lists   [][]RecommendedTitle
+		lastErr error
+	)
+	for _, seed := range seeds {
+		list, err := s.recommendationsFor(seed, language)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		lists = append(lists, list)
+	}
+	if len(lists) == 0 {
+		return nil, lastErr
+	}
+
+	// По очереди берем по одному тайтлу из рекомендаций каждого seed,
+	// чтобы один фильм не занял всю рассылку
+	var titles []RecommendedTitle
+	for i := 0; len(titles) < s.size; i++ {
+		added := false
+		for _, list := range lists {
+			if i >= len(list) {
+				continue
+			}
+			added = true
+			title := list[i]
+			key := title.MediaType + ":" + title.MediaID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			titles = append(titles, title)
+			if len(titles) == s.size {
+				break
+			}
+		}
+		if !added {
+			break
+		}
+	}
+	return titles, nil
+}
+
+type digestSeed struct {
+	mediaType string
+	id        int
+}
+
+// seeds returns the latest favorites and liked titles, without duplicates.
+func (s *DigestService) seeds(ctx context.Context, userID string) ([]digestSeed, error) {
+	var refs []mediaRef
+	favorites, err := s.findRefs(ctx, "favorites", bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}).SetLimit(digestSeeds))
+	if err != nil {
+		return nil, err
+	}
+	refs = append(refs, favorites...)
+	liked, err := s.findRefs(ctx, "reactions", bson.M{"userId": userID, "type": bson.M{"$in": digestReactions}},
+		options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}}).SetLimit(digestSeeds))
+	if err != nil {
+		return nil, err
+	}
+	refs = append(refs, liked...)
+
+	var seeds []digestSeed
+	added := make(map[string]bool)
+	for _, ref := range refs {
+		id, err := strconv.Atoi(ref.MediaID)
+		if err != nil || (ref.MediaType != "movie" && ref.MediaType != "tv") || added[ref.key()] {
+			continue
+		}
+		added[ref.key()] = true
+		seeds = append(seeds, digestSeed{mediaType: ref.MediaType, id: id})
+	}
+	return seeds, nil
+}
+
+// seen collects titles the user already knows about: favorites, any
+// reaction, watch history and earlier digests.
+func (s *DigestService) seen(ctx context.Context, userID string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	for _, collection := range []string{"favorites", "reactions", "watch_history", "digest_items"} {
+		refs, err := s.findRefs(ctx, collection, bson.M{"userId": userID}, options.Find())
+		if err != nil {
+			return nil, err
+		}
+		for _, ref := range refs {
+			seen[ref.key()] = true
+		}
+	}
+	return seen, nil
+}
+
+type mediaRef struct {
+	MediaType string `bson:"mediaType"`
+	MediaID   string `bson:"mediaId"`
+}
+
+func (r mediaRef) key() string {
+	return r.MediaType + ":" + r.MediaID
+}
+
+func (s *DigestService) findRefs(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]mediaRef, error) {
+	cursor, err := s.db.Collection(collection).Find(ctx, filter,
+		opts.SetProjection(bson.M{"_id": 0, "mediaType": 1, "mediaId": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var refs []mediaRef
+	if err := cursor.All(ctx, &refs); err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+func (s *DigestService) recommendationsFor(seed digestSeed, language string) ([]RecommendedTitle, error) {
+	var titles []RecommendedTitle
+	if seed.mediaType == "tv" {
+		resp, err := s.tmdb.GetTVRecommendations(seed.id, 1, language)
+		if err != nil {
+			return nil, err
+		}
+		for _, show := range resp.Results {
+			titles = append(titles, RecommendedTitle{
+				MediaType: "tv",
+				MediaID:   strconv.Itoa(show.ID),
+				Title:     show.Name,
+				Year:      releaseYear(show.FirstAirDate),
+				Rating:    show.VoteAverage,
+			})
+		}
+		return titles, nil
+	}
+
+	resp, err := s.tmdb.GetMovieRecommendations(seed.id, 1, language)
+	if err != nil {
+		return nil, err
+	}
+	for _, movie := range resp.Results {
+		titles = append(titles, RecommendedTitle{
+			MediaType: "movie",
+			MediaID:   strconv.Itoa(movie.ID),
+			Title:     movie.Title,
+			Year:      releaseYear(movie.ReleaseDate),
+			Rating:    movie.VoteAverage,
+		})
+	}
+	return titles, nil
+}
+
+// remember records sent titles so later digests skip them.
+func (s *DigestService) remember(ctx context.Context, userID string, titles []RecommendedTitle) error {
+	now := time.Now()
+	docs := make([]interface{}, 0, len(titles))
+	for _, title := range titles {
+		docs = append(docs, models.DigestItem{
+			UserID:    userID,
+			MediaType: title.MediaType,
+			MediaID:   title.MediaID,
+			SentAt:    now,
+		})
+	}
+	_, err := s.items().InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *DigestService) items() *mongo.Collection {
+	return s.db.Collection("digest_items")
+}
+
+// releaseYear cuts the year off a TMDB date like "2010-07-15".
+func releaseYear(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
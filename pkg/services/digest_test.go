@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/config"
+)
+
+func newTestDigestService(mt *mtest.T) *DigestService {
+	return NewDigestService(mt.DB, &config.Config{JWTSecret: "jwt-secret", DigestSecret: "digest-secret"}, nil, nil)
+}
+
+func TestDigestUnsubscribeAndResubscribe(t *testing.T) {
+	runMock(t, "round trip", func(mt *mtest.T) {
+		mt.AddMockResponses(modified(1), modified(1))
+		digest := newTestDigestService(mt)
+		userID := primitive.NewObjectID()
+
+		resubscribe, err := digest.Unsubscribe(context.Background(), digest.Token(userID.Hex()))
+		if err != nil {
+			mt.Fatal(err)
+		}
+		update, _ := findCommand(sentCommands(mt), "update", "users")
+		stmt := statement(update, "updates")
+		if stmt.Lookup("q", "_id").ObjectID() != userID {
+			mt.Errorf("unsubscribed %v", stmt.Lookup("q"))
+		}
+		if _, err := stmt.Lookup("u").Document().LookupErr("$unset", "digestSubscribed"); err != nil {
+			mt.Error("subscription is kept")
+		}
+
+		mt.ClearEvents()
+		if err := digest.Resubscribe(context.Background(), resubscribe); err != nil {
+			mt.Fatal(err)
+		}
+		update, _ = findCommand(sentCommands(mt), "update", "users")
+		if !statement(update, "updates").Lookup("u", "$set", "digestSubscribed").Boolean() {
+			mt.Error("subscription is not restored")
+		}
+	})
+}
+
+func TestDigestTokenRejected(t *testing.T) {
+	userID := primitive.NewObjectID().Hex()
+	other := NewDigestService(nil, &config.Config{DigestSecret: "other-secret"}, nil, nil)
+
+	tests := []struct {
+		name        string
+		token       func(s *DigestService) string
+		resubscribe bool
+		want        error
+	}{
+		// Пересланное письмо не позволяет подписать пользователя обратно
+		{"email token used to resubscribe", func(s *DigestService) string { return s.Token(userID) }, true, ErrInvalidDigestToken},
+		{"resubscribe token used to unsubscribe", func(s *DigestService) string {
+			return s.token(userID, digestScopeResubscribe, time.Now().Add(time.Hour))
+		}, false, ErrInvalidDigestToken},
+		{"expired email token", func(s *DigestService) string {
+			return s.token(userID, digestScopeUnsubscribe, time.Now().Add(-time.Second))
+		}, false, ErrDigestTokenExpired},
+		{"expired resubscribe token", func(s *DigestService) string {
+			return s.token(userID, digestScopeResubscribe, time.Now().Add(-time.Second))
+		}, true, ErrDigestTokenExpired},
+		{"other key", func(*DigestService) string { return other.Token(userID) }, false, ErrInvalidDigestToken},
+		{"other user", func(s *DigestService) string {
+			token := s.Token(userID)
+			return primitive.NewObjectID().Hex() + token[len(userID):]
+		}, false, ErrInvalidDigestToken},
+		{"extended expiry", func(s *DigestService) string {
+			parts := strings.Split(s.Token(userID), ".")
+			parts[2] = "9999999999"
+			return strings.Join(parts, ".")
+		}, false, ErrInvalidDigestToken},
+		// Токены до появления срока действия: userID.signature
+		{"legacy format", func(s *DigestService) string {
+			return userID + "." + strings.Split(s.Token(userID), ".")[3]
+		}, false, ErrInvalidDigestToken},
+		{"garbage", func(*DigestService) string { return "not-a-token" }, false, ErrInvalidDigestToken},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			digest := newTestDigestService(mt)
+			token := tt.token(digest)
+
+			var err error
+			if tt.resubscribe {
+				err = digest.Resubscribe(context.Background(), token)
+			} else {
+				_, err = digest.Unsubscribe(context.Background(), token)
+			}
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if len(sentCommands(mt)) != 0 {
+				mt.Error("subscription changed with a rejected token")
+			}
+		})
+	}
+}
+
+func TestDigestTokenExpiry(t *testing.T) {
+	digest := NewDigestService(nil, &config.Config{DigestSecret: "digest-secret"}, nil, nil)
+	userID := primitive.NewObjectID().Hex()
+
+	parts := strings.Split(digest.Token(userID), ".")
+	if len(parts) != 4 || parts[1] != digestScopeUnsubscribe {
+		t.Fatalf("token parts = %q", parts)
+	}
+	// Срок ссылки в письме — digestUnsubscribeTTL с момента отправки
+	now := time.Now()
+	if _, err := digest.parseToken(digest.Token(userID), digestScopeUnsubscribe, now.Add(digestUnsubscribeTTL-time.Minute)); err != nil {
+		t.Errorf("token expired early: %v", err)
+	}
+	if _, err := digest.parseToken(digest.Token(userID), digestScopeUnsubscribe, now.Add(digestUnsubscribeTTL+time.Minute)); !errors.Is(err, ErrDigestTokenExpired) {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestDigestKey(t *testing.T) {
+	cfg := &config.Config{JWTSecret: "jwt-secret", DigestSecret: "digest-secret"}
+	if got := digestKey(cfg); string(got) != "digest-secret" {
+		t.Errorf("key = %q", got)
+	}
+
+	// Без DIGEST_SECRET ключ выводится из JWT_SECRET, но не равен ему
+	cfg.DigestSecret = ""
+	derived := digestKey(cfg)
+	if len(derived) == 0 || bytes.Equal(derived, []byte(cfg.JWTSecret)) {
+		t.Errorf("derived key = %x", derived)
+	}
+	cfg.JWTSecret = "another"
+	if bytes.Equal(digestKey(cfg), derived) {
+		t.Error("derived key does not depend on JWT_SECRET")
+	}
+}
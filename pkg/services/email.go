@@ -1,150 +1,235 @@
 package services
 
 import (
+	"context"
 	"fmt"
-	"net/smtp"
+	"net/url"
 	"strings"
+	"time"
 
+	"neomovies-api/pkg/apperr"
 	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/models"
 )
 
 type EmailService struct {
-	config *config.Config
+	config    *config.Config
+	outbox    *OutboxService
+	templates *mail.Templates
 }
 
-func NewEmailService(cfg *config.Config) *EmailService {
+func NewEmailService(cfg *config.Config, outbox *OutboxService, templates *mail.Templates) *EmailService {
 	return &EmailService{
-		config: cfg,
+		config:    cfg,
+		outbox:    outbox,
+		templates: templates,
 	}
 }
 
+// Имена шаблонов в pkg/mail/templates; они же служат видом письма в очереди
+const (
+	EmailVerification    = "verification"
+	EmailChange          = "email_change"
+	EmailPasswordReset   = "password_reset"
+	EmailRecommendations = "recommendations"
+	EmailNotification    = "notification"
+)
+
+var ErrEmailTemplateNotFound = apperr.NotFound("email template not found").WithCode("email_template_not_found")
+
+// Данные шаблонов. Поля экранируются html/template, поэтому сюда можно
+// класть пользовательский ввод как есть
+type codeEmailData struct {
+	Code string
+}
+
+type passwordResetEmailData struct {
+	ResetURL string
+}
+
+// RecommendationEmail is the content of a recommendation digest.
+type RecommendationEmail struct {
+	Name   string
+	Titles []RecommendedTitle
+	// UnsubscribeURL ведет на страницу отписки во фронтенде, OneClickURL —
+	// на API для заголовка List-Unsubscribe (RFC 8058)
+	UnsubscribeURL string
+	OneClickURL    string
+}
+
+// NotificationEmail is the email copy of an in-app notification.
+type NotificationEmail struct {
+	Name          string
+	Type          string
+	Title         string
+	SeasonNumber  int
+	EpisodeNumber int
+	AirDate       string
+}
+
+// RecommendedTitle is a movie or TV show in a digest.
+type RecommendedTitle struct {
+	MediaType string
+	MediaID   string
+	Title     string
+	Year      string
+	Rating    float64
+}
+
+// EmailOptions — письмо с текстовой и HTML-версией. Клиенты без HTML
+// показывают Text. Key и ValidFor передаются в очередь (см. OutboxMessage)
 type EmailOptions struct {
-	To      []string
-	Subject string
-	Body    string
-	IsHTML  bool
+	To       []string
+	Subject  string
+	Text     string
+	HTML     string
+	Headers  map[string]string
+	Kind     string
+	Key      string
+	ValidFor time.Duration
 }
 
-func (s *EmailService) SendEmail(options *EmailOptions) error {
-	if s.config.GmailUser == "" || s.config.GmailPassword == "" {
-		return fmt.Errorf("Gmail credentials not configured")
-	}
+// SendEmail ставит письмо в очередь email_outbox. Ошибка означает, что
+// письмо не сохранено; ошибки доставки обрабатывает очередь
+func (s *EmailService) SendEmail(ctx context.Context, options *EmailOptions) error {
+	_, err := s.outbox.Enqueue(ctx, OutboxMessage{
+		Key:  options.Key,
+		Kind: options.Kind,
+		Message: mail.Message{
+			To:      options.To,
+			Subject: options.Subject,
+			Text:    options.Text,
+			HTML:    options.HTML,
+			Headers: options.Headers,
+		},
+		ValidFor: options.ValidFor,
+	})
+	return err
+}
 
-	// Gmail SMTP конфигурация
-	smtpHost := "smtp.gmail.com"
-	smtpPort := "587"
-	auth := smtp.PlainAuth("", s.config.GmailUser, s.config.GmailPassword, smtpHost)
+// emailKey строит ключ идемпотентности из вида письма и его содержимого,
+// не раскрывая коды и токены
+func emailKey(kind string, parts ...string) string {
+	return kind + ":" + hashToken(kind + ":" + strings.Join(parts, ":"))[:32]
+}
 
-	// Создаем заголовки email
-	headers := make(map[string]string)
-	headers["From"] = s.config.GmailUser
-	headers["To"] = strings.Join(options.To, ",")
-	headers["Subject"] = options.Subject
+// sendTemplate рендерит шаблон на языке получателя и ставит письмо в очередь
+func (s *EmailService) sendTemplate(ctx context.Context, name, to, locale string, data any, key string, validFor time.Duration) error {
+	return s.sendTemplateWithHeaders(ctx, name, to, locale, data, nil, key, validFor)
+}
 
-	if options.IsHTML {
-		headers["MIME-Version"] = "1.0"
-		headers["Content-Type"] = "text/html; charset=UTF-8"
+func (s *EmailService) sendTemplateWithHeaders(ctx context.Context, name, to, locale string, data any, headers map[string]string, key string, validFor time.Duration) error {
+	rendered, err := s.templates.Render(name, locale, data)
+	if err != nil {
+		return err
 	}
+	return s.SendEmail(ctx, &EmailOptions{
+		To:       []string{to},
+		Subject:  rendered.Subject,
+		Text:     rendered.Text,
+		HTML:     rendered.HTML,
+		Headers:  headers,
+		Kind:     name,
+		Key:      key,
+		ValidFor: validFor,
+	})
+}
 
-	// Формируем сообщение
-	message := ""
-	for key, value := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
-	}
-	message += "\r\n" + options.Body
+func (s *EmailService) SendVerificationEmail(ctx context.Context, userEmail, locale, code string) error {
+	return s.sendTemplate(ctx, EmailVerification, userEmail, locale, codeEmailData{Code: code},
+		emailKey(EmailVerification, userEmail, code), verificationCodeTTL)
+}
 
-	// Отправляем email
-	err := smtp.SendMail(
-		smtpHost+":"+smtpPort,
-		auth,
-		s.config.GmailUser,
-		options.To,
-		[]byte(message),
-	)
+func (s *EmailService) SendEmailChangeCode(ctx context.Context, newEmail, locale, code string) error {
+	return s.sendTemplate(ctx, EmailChange, newEmail, locale, codeEmailData{Code: code},
+		emailKey(EmailChange, newEmail, code), emailChangeTTL)
+}
 
-	return err
+func (s *EmailService) SendPasswordResetEmail(ctx context.Context, userEmail, locale, resetToken string) error {
+	return s.sendTemplate(ctx, EmailPasswordReset, userEmail, locale,
+		passwordResetEmailData{ResetURL: s.resetURL(resetToken)},
+		emailKey(EmailPasswordReset, userEmail, resetToken), passwordResetTTL)
 }
 
-// Предустановленные шаблоны email
-func (s *EmailService) SendVerificationEmail(userEmail, code string) error {
-	options := &EmailOptions{
-		To:      []string{userEmail},
-		Subject: "Подтверждение регистрации Neo Movies",
-		Body: fmt.Sprintf(`
-			<div style="font-family: sans-serif; max-width: 600px; margin: 0 auto;">
-				<h1 style="color: #2196f3;">Neo Movies</h1>
-				<p>Здравствуйте!</p>
-				<p>Для завершения регистрации введите этот код:</p>
-				<div style="
-					background: #f5f5f5;
-					padding: 20px;
-					border-radius: 8px;
-					text-align: center;
-					font-size: 24px;
-					letter-spacing: 4px;
-					margin: 20px 0;
-				">
-					%s
-				</div>
-				<p>Код действителен в течение 10 минут.</p>
-				<p>Если вы не регистрировались на нашем сайте, просто проигнорируйте это письмо.</p>
-			</div>
-		`, code),
-		IsHTML: true,
+// SendMovieRecommendationEmail queues a digest. The same titles are not
+// queued twice for the same address.
+func (s *EmailService) SendMovieRecommendationEmail(ctx context.Context, userEmail, locale string, digest RecommendationEmail) error {
+	ids := make([]string, 0, len(digest.Titles))
+	for _, title := range digest.Titles {
+		ids = append(ids, title.MediaType+"/"+title.MediaID)
 	}
-
-	return s.SendEmail(options)
-}
-
-func (s *EmailService) SendPasswordResetEmail(userEmail, resetToken string) error {
-	resetURL := fmt.Sprintf("%s/reset-password?token=%s", s.config.BaseURL, resetToken)
-	
-	options := &EmailOptions{
-		To:      []string{userEmail},
-		Subject: "Сброс пароля Neo Movies",
-		Body: fmt.Sprintf(`
-			<html>
-			<body>
-				<h2>Сброс пароля</h2>
-				<p>Вы запросили сброс пароля для вашего аккаунта Neo Movies.</p>
-				<p>Нажмите на ссылку ниже, чтобы создать новый пароль:</p>
-				<p><a href="%s">Сбросить пароль</a></p>
-				<p>Ссылка действительна в течение 1 часа.</p>
-				<p>Если вы не запрашивали сброс пароля, проигнорируйте это сообщение.</p>
-				<br>
-				<p>С уважением,<br>Команда Neo Movies</p>
-			</body>
-			</html>
-		`, resetURL),
-		IsHTML: true,
+	var headers map[string]string
+	if digest.OneClickURL != "" {
+		headers = map[string]string{
+			"List-Unsubscribe":      "<" + digest.OneClickURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		}
 	}
+	return s.sendTemplateWithHeaders(ctx, EmailRecommendations, userEmail, locale, digest, headers,
+		emailKey(EmailRecommendations, append([]string{userEmail}, ids...)...), 0)
+}
 
-	return s.SendEmail(options)
+// SendNotificationEmail queues the email copy of a notification. key is the
+// notification key, so one event is emailed at most once.
+func (s *EmailService) SendNotificationEmail(ctx context.Context, userEmail, locale, key string, notification NotificationEmail, validFor time.Duration) error {
+	return s.sendTemplate(ctx, EmailNotification, userEmail, locale, notification,
+		emailKey(EmailNotification, userEmail, key), validFor)
 }
 
-func (s *EmailService) SendMovieRecommendationEmail(userEmail, userName string, movies []string) error {
-	moviesList := ""
-	for _, movie := range movies {
-		moviesList += fmt.Sprintf("<li>%s</li>", movie)
-	}
+// resetURL ведет на фронтенд, где пользователь вводит новый пароль
+func (s *EmailService) resetURL(token string) string {
+	return fmt.Sprintf("%s/reset-password?token=%s", s.frontendURL(), url.QueryEscape(token))
+}
 
-	options := &EmailOptions{
-		To:      []string{userEmail},
-		Subject: "Новые рекомендации фильмов от Neo Movies",
-		Body: fmt.Sprintf(`
-			<html>
-			<body>
-				<h2>Привет, %s!</h2>
-				<p>У нас есть новые рекомендации фильмов специально для вас:</p>
-				<ul>%s</ul>
-				<p>Заходите в приложение, чтобы узнать больше деталей!</p>
-				<br>
-				<p>С уважением,<br>Команда Neo Movies</p>
-			</body>
-			</html>
-		`, userName, moviesList),
-		IsHTML: true,
+// frontendURL — база ссылок в письмах; без фронтенда ссылки ведут на API
+func (s *EmailService) frontendURL() string {
+	if s.config.FrontendURL != "" {
+		return s.config.FrontendURL
 	}
+	return s.config.BaseURL
+}
+
+// EmailTemplates describes the available templates and locales.
+type EmailTemplates struct {
+	Templates []string `json:"templates"`
+	Locales   []string `json:"locales"`
+}
 
-	return s.SendEmail(options)
-}
\ No newline at end of file
+func (s *EmailService) Templates() EmailTemplates {
+	return EmailTemplates{Templates: s.templates.Names(), Locales: mail.Locales}
+}
+
+// Preview renders a template with sample data, so admins can check a
+// translation without triggering a real email.
+func (s *EmailService) Preview(name, locale string) (*mail.Rendered, error) {
+	var data any
+	switch name {
+	case EmailVerification, EmailChange:
+		data = codeEmailData{Code: "123456"}
+	case EmailPasswordReset:
+		data = passwordResetEmailData{ResetURL: s.resetURL("preview-token")}
+	case EmailRecommendations:
+		data = RecommendationEmail{
+			Name: "Alex",
+			Titles: []RecommendedTitle{
+				{MediaType: "movie", MediaID: "27205", Title: "Inception", Year: "2010", Rating: 8.4},
+				{MediaType: "movie", MediaID: "157336", Title: "Interstellar", Year: "2014", Rating: 8.4},
+				{MediaType: "tv", MediaID: "1396", Title: "Breaking Bad", Year: "2008", Rating: 8.9},
+			},
+			UnsubscribeURL: s.frontendURL() + "/unsubscribe?token=preview-token",
+		}
+	case EmailNotification:
+		data = NotificationEmail{
+			Name:          "Alex",
+			Type:          models.NotificationNewEpisode,
+			Title:         "The Last of Us",
+			SeasonNumber:  2,
+			EpisodeNumber: 3,
+			AirDate:       "2025-04-27",
+		}
+	default:
+		return nil, ErrEmailTemplateNotFound
+	}
+	return s.templates.Render(name, locale, data)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/models"
+)
+
+func newTestEmailService(t testing.TB, outbox *OutboxService) *EmailService {
+	templates, err := mail.LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewEmailService(&config.Config{BaseURL: "http://api.test", FrontendURL: "http://app.test"}, outbox, templates)
+}
+
+// Preview рендерит шаблоны со структурами данных из этого пакета, поэтому
+// проверяет, что поля в шаблонах совпадают с полями структур
+func TestEmailPreviewEveryTemplate(t *testing.T) {
+	emails := newTestEmailService(t, nil)
+
+	for _, name := range emails.Templates().Templates {
+		for _, locale := range mail.Locales {
+			rendered, err := emails.Preview(name, locale)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", locale, name, err)
+			}
+			if rendered.Subject == "" || strings.Contains(rendered.Text, "<no value>") {
+				t.Errorf("%s/%s = %+v", locale, name, rendered)
+			}
+		}
+	}
+
+	if _, err := emails.Preview("welcome", "en"); apperr.From(err).Code != "email_template_not_found" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestSendPasswordResetEmail(t *testing.T) {
+	runMock(t, "queued", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged(), acknowledged(bson.E{Key: "value", Value: nil}))
+		emails := newTestEmailService(mt, NewOutboxService(mt.DB, &recordingMailer{}))
+
+		if err := emails.SendPasswordResetEmail(context.Background(), "user@example.com", "en", "token+/="); err != nil {
+			mt.Fatal(err)
+		}
+		waitBackground(mt)
+
+		insert, _ := findCommand(sentCommands(mt), "insert", "email_outbox")
+		doc := statement(insert, "documents")
+		if !strings.Contains(doc.Lookup("text").StringValue(), "http://app.test/reset-password?token=token%2B%2F%3D") {
+			mt.Errorf("text = %s", doc.Lookup("text").StringValue())
+		}
+		if doc.Lookup("kind").StringValue() != EmailPasswordReset {
+			mt.Errorf("kind = %v", doc.Lookup("kind"))
+		}
+		// Ключ идемпотентности не раскрывает токен
+		if key := doc.Lookup("key").StringValue(); strings.Contains(key, "token") || !strings.HasPrefix(key, EmailPasswordReset+":") {
+			mt.Errorf("key = %q", key)
+		}
+		if _, err := doc.LookupErr("deliverBefore"); err != nil {
+			mt.Error("reset link has no delivery deadline")
+		}
+	})
+}
+
+func TestRegisterWhenEmailCannotBeQueued(t *testing.T) {
+	runMock(t, "outbox down", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			found("test.users"),
+			acknowledged(),
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 2, Name: "BadValue", Message: "outbox unavailable"}),
+		)
+		emails := newTestEmailService(mt, NewOutboxService(mt.DB, &recordingMailer{}))
+		auth := NewAuthService(mt.DB, "secret", emails, NewSessionService(mt.DB, "secret"), NewAuditService(mt.DB), "http://api.test", "http://app.test", nil)
+
+		// Пользователь уже создан, код можно запросить повторно
+		if _, err := auth.Register(models.RegisterRequest{Email: "user@example.com", Password: "Passw0rd!", Name: "Neo"}); err != nil {
+			mt.Fatalf("err = %v", err)
+		}
+		if _, ok := findCommand(sentCommands(mt), "insert", "email_outbox"); !ok {
+			mt.Error("verification email was not queued")
+		}
+	})
+}
@@ -9,6 +9,8 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/config"
 	"neomovies-api/pkg/models"
 )
 
@@ -41,35 +43,9 @@ func (s *FavoritesService) AddToFavorites(userID, mediaID, mediaType string) err
 		return nil
 	}
 	
-	var title, posterPath string
-	
-	// Получаем информацию из TMDB в зависимости от типа медиа
-	mediaIDInt, err := strconv.Atoi(mediaID)
+	title, posterPath, err := fetchMediaSummary(s.tmdb, mediaID, mediaType)
 	if err != nil {
-		return fmt.Errorf("invalid media ID: %s", mediaID)
-	}
-	
-	if mediaType == "movie" {
-		movie, err := s.tmdb.GetMovie(mediaIDInt, "en-US")
-		if err != nil {
-			return err
-		}
-		title = movie.Title
-		posterPath = movie.PosterPath
-	} else if mediaType == "tv" {
-		tv, err := s.tmdb.GetTVShow(mediaIDInt, "en-US")
-		if err != nil {
-			return err
-		}
-		title = tv.Name
-		posterPath = tv.PosterPath
-	} else {
-		return fmt.Errorf("invalid media type: %s", mediaType)
-	}
-	
-	// Формируем полный URL для постера
-	if posterPath != "" {
-		posterPath = fmt.Sprintf("https://image.tmdb.org/t/p/w500%s", posterPath)
+		return err
 	}
 	
 	favorite := models.Favorite{
@@ -98,31 +74,42 @@ func (s *FavoritesService) RemoveFromFavorites(userID, mediaID, mediaType string
 	return err
 }
 
-func (s *FavoritesService) GetFavorites(userID string) ([]models.Favorite, error) {
-	collection := s.db.Collection("favorites")
-	
-	filter := bson.M{
-		"userId": userID,
-	}
-	
-	cursor, err := collection.Find(context.Background(), filter)
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(context.Background())
-	
-	var favorites []models.Favorite
-	err = cursor.All(context.Background(), &favorites)
+// favoritesSortFields maps sort parameters of GetFavorites to document fields.
+var favoritesSortFields = map[string]string{
+	"createdAt": "createdAt",
+	"title":     "title",
+}
+
+// EnsureIndexes creates indexes backing the paginated favorites queries.
+func (s *FavoritesService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("favorites").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "mediaId", Value: 1}, {Key: "mediaType", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "mediaType", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "mediaType", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	return err
+}
+
+func (s *FavoritesService) GetFavorites(ctx context.Context, userID string, page models.PageRequest) (*models.PaginatedResponse, error) {
+	docs, result, err := findPage(ctx, s.db.Collection("favorites"), bson.M{"userId": userID}, page, favoritesSortFields)
 	if err != nil {
 		return nil, err
 	}
-	
+
 	// Возвращаем пустой массив вместо nil если нет избранных
-	if favorites == nil {
-		favorites = []models.Favorite{}
+	favorites := make([]models.Favorite, 0, len(docs))
+	for _, doc := range docs {
+		var favorite models.Favorite
+		if err := bson.Unmarshal(doc, &favorite); err != nil {
+			return nil, err
+		}
+		favorites = append(favorites, favorite)
 	}
-	
-	return favorites, nil
+
+	result.Items = favorites
+	return result, nil
 }
 
 func (s *FavoritesService) IsFavorite(userID, mediaID, mediaType string) (bool, error) {
@@ -144,4 +131,39 @@ func (s *FavoritesService) IsFavorite(userID, mediaID, mediaType string) (bool,
 	}
 	
 	return true, nil
-}
\ No newline at end of file
+}
+
+// fetchMediaSummary получает название и полный URL постера из TMDB
+func fetchMediaSummary(tmdb *TMDBService, mediaID, mediaType string) (string, string, error) {
+	var title, posterPath string
+
+	mediaIDInt, err := strconv.Atoi(mediaID)
+	if err != nil {
+		return "", "", apperr.Validation("invalid media ID: " + mediaID)
+	}
+
+	if mediaType == "movie" {
+		movie, err := tmdb.GetMovie(mediaIDInt, "en-US")
+		if err != nil {
+			return "", "", err
+		}
+		title = movie.Title
+		posterPath = movie.PosterPath
+	} else if mediaType == "tv" {
+		tv, err := tmdb.GetTVShow(mediaIDInt, "en-US")
+		if err != nil {
+			return "", "", err
+		}
+		title = tv.Name
+		posterPath = tv.PosterPath
+	} else {
+		return "", "", ErrInvalidMediaType
+	}
+
+	// Формируем полный URL для постера
+	if posterPath != "" {
+		posterPath = fmt.Sprintf("%s/w500%s", config.TMDBImageBaseURL, posterPath)
+	}
+
+	return title, posterPath, nil
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/models"
+)
+
+var ErrFollowNotFound = apperr.NotFound("title is not followed").WithCode("follow_not_found")
+
+// followsSortFields maps sort parameters of List to document fields.
+var followsSortFields = map[string]string{
+	"createdAt": "createdAt",
+	"title":     "title",
+}
+
+// FollowsService stores the titles users follow for release and
+// new-episode notifications.
+type FollowsService struct {
+	db   *mongo.Database
+	tmdb *TMDBService
+}
+
+func NewFollowsService(db *mongo.Database, tmdb *TMDBService) *FollowsService {
+	return &FollowsService{db: db, tmdb: tmdb}
+}
+
+// EnsureIndexes creates the uniqueness index, the indexes backing the
+// paginated list and the index the notification scheduler looks titles up
+// by.
+func (s *FollowsService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "mediaType", Value: 1}, {Key: "mediaId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "title", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "mediaType", Value: 1}, {Key: "mediaId", Value: 1}}},
+	})
+	return err
+}
+
+// Follow starts following a title. The current state of the title is
+// recorded, so the user is only notified about what comes out afterwards.
+// Following a title again returns the existing follow.
+func (s *FollowsService) Follow(ctx context.Context, userID, mediaType, mediaID string) (*models.Follow, error) {
+	if mediaType != "movie" && mediaType != "tv" {
+		return nil, ErrInvalidMediaType
+	}
+	id, err := strconv.Atoi(mediaID)
+	if err != nil {
+		return nil, apperr.Validation("invalid media ID: " + mediaID)
+	}
+
+	var existing models.Follow
+	err = s.collection().FindOne(ctx, followFilter(userID, mediaType, mediaID)).Decode(&existing)
+	if err == nil {
+		return &existing, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	// Название сохраняем на языке пользователя: оно же попадет в уведомления
+	language := ""
+	if objectID, err := primitive.ObjectIDFromHex(userID); err == nil {
+		var user models.User
+		if err := s.db.Collection("users").FindOne(ctx, bson.M{"_id": objectID},
+			options.FindOne().SetProjection(bson.M{"preferences": 1}),
+		).Decode(&user); err == nil {
+			language = tmdbLanguage(user.Preferences.Language)
+		}
+	}
+
+	state, err := fetchMediaState(s.tmdb, mediaType, id, language)
+	if err != nil {
+		return nil, err
+	}
+
+	follow := models.Follow{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		MediaID:     mediaID,
+		MediaType:   mediaType,
+		Title:       state.title,
+		PosterPath:  state.posterPath,
+		LastSeason:  state.season,
+		LastEpisode: state.episode,
+		ReleaseDate: state.releaseDate,
+		Released:    state.released,
+		CreatedAt:   time.Now(),
+	}
+	if _, err := s.collection().InsertOne(ctx, follow); err != nil {
+		// Параллельный запрос успел подписаться первым
+		if mongo.IsDuplicateKeyError(err) {
+			err = s.collection().FindOne(ctx, followFilter(userID, mediaType, mediaID)).Decode(&existing)
+			if err != nil {
+				return nil, err
+			}
+			return &existing, nil
+		}
+		return nil, err
+	}
+	return &follow, nil
+}
+
+func (s *FollowsService) Unfollow(ctx context.Context, userID, mediaType, mediaID string) error {
+	res, err := s.collection().DeleteOne(ctx, followFilter(userID, mediaType, mediaID))
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrFollowNotFound
+	}
+	return nil
+}
+
+// List returns a page of the user's followed titles.
+func (s *FollowsService) List(ctx context.Context, userID string, page models.PageRequest) (*models.PaginatedResponse, error) {
+	docs, result, err := findPage(ctx, s.collection(), bson.M{"userId": userID}, page, followsSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	follows := make([]models.Follow, 0, len(docs))
+	for _, doc := range docs {
+		var follow models.Follow
+		if err := bson.Unmarshal(doc, &follow); err != nil {
+			return nil, err
+		}
+		follows = append(follows, follow)
+	}
+
+	result.Items = follows
+	return result, nil
+}
+
+func (s *FollowsService) collection() *mongo.Collection {
+	return s.db.Collection("follows")
+}
+
+func followFilter(userID, mediaType, mediaID string) bson.M {
+	return bson.M{"userId": userID, "mediaType": mediaType, "mediaId": mediaID}
+}
+
+// mediaState is what the notification scheduler compares between checks:
+// the latest aired episode of a show or whether a movie is out.
+type mediaState struct {
+	title       string
+	posterPath  string
+	releaseDate string
+	released    bool
+	season      int
+	episode     int
+	airDate     string
+}
+
+// fetchMediaState loads the current state of a title from TMDB.
+func fetchMediaState(tmdb *TMDBService, mediaType string, id int, language string) (*mediaState, error) {
+	today := time.Now().UTC().Format("2006-01-02")
+
+	if mediaType == "movie" {
+		movie, err := tmdb.GetMovie(id, language)
+		if err != nil {
+			return nil, err
+		}
+		return &mediaState{
+			title:       movie.Title,
+			posterPath:  posterURL(movie.PosterPath),
+			releaseDate: movie.ReleaseDate,
+			released:    movie.ReleaseDate != "" && movie.ReleaseDate <= today,
+		}, nil
+	}
+
+	show, err := tmdb.GetTVShow(id, language)
+	if err != nil {
+		return nil, err
+	}
+	state := &mediaState{title: show.Name, posterPath: posterURL(show.PosterPath)}
+
+	// Последний начавшийся сезон; спецвыпуски (сезон 0) не считаются
+	season := 0
+	for _, s := range show.Seasons {
+		if s.SeasonNumber > season && s.AirDate != "" && s.AirDate <= today {
+			season = s.SeasonNumber
+		}
+	}
+	if season == 0 {
+		return state, nil
+	}
+	details, err := tmdb.GetTVSeason(id, season, language)
+	if err != nil {
+		return nil, err
+	}
+	for _, episode := range details.Episodes {
+		if episode.AirDate != "" && episode.AirDate <= today && episode.EpisodeNumber > state.episode {
+			state.season = season
+			state.episode = episode.EpisodeNumber
+			state.airDate = episode.AirDate
+		}
+	}
+	return state, nil
+}
+
+func posterURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/w500%s", config.TMDBImageBaseURL, path)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// day возвращает дату TMDB со сдвигом в днях от сегодняшней
+func day(offset int) string {
+	return time.Now().UTC().AddDate(0, 0, offset).Format("2006-01-02")
+}
+
+// tvStub отвечает как TMDB для сериала 1: сезон 2 идет, сезон 3 анонсирован
+func tvStub(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/tv/1":
+		fmt.Fprintf(w, `{"id":1,"name":"The Last of Us","poster_path":"/p.jpg","seasons":[
+			{"season_number":0,"air_date":%q},
+			{"season_number":1,"air_date":%q},
+			{"season_number":2,"air_date":%q},
+			{"season_number":3,"air_date":%q}]}`, day(-900), day(-800), day(-14), day(300))
+	case "/tv/1/season/2":
+		fmt.Fprintf(w, `{"season_number":2,"episodes":[
+			{"episode_number":1,"air_date":%q},
+			{"episode_number":2,"air_date":%q},
+			{"episode_number":3,"air_date":%q}]}`, day(-14), day(-7), day(7))
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestFetchMediaState(t *testing.T) {
+	srv, _ := newTMDBStub(t, tvStub)
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", nil)
+
+	// Спецвыпуски и анонсированные сезоны и серии не учитываются
+	state, err := fetchMediaState(tmdb, "tv", 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.season != 2 || state.episode != 2 || state.airDate != day(-7) || state.title != "The Last of Us" {
+		t.Errorf("state = %+v", state)
+	}
+
+	tests := []struct {
+		name        string
+		releaseDate string
+		released    bool
+	}{
+		{"released", day(-1), true},
+		{"today", day(0), true},
+		{"upcoming", day(30), false},
+		{"no date", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, `{"id":2,"title":"Dune","release_date":%q}`, tt.releaseDate)
+			})
+			state, err := fetchMediaState(NewTMDBServiceWithConfig("token", srv.URL+"/3", nil), "movie", 2, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state.released != tt.released || state.releaseDate != tt.releaseDate {
+				t.Errorf("state = %+v", state)
+			}
+		})
+	}
+}
+
+func TestFollow(t *testing.T) {
+	runMock(t, "invalid media", func(mt *mtest.T) {
+		follows := NewFollowsService(mt.DB, nil)
+		for _, tt := range []struct{ mediaType, mediaID string }{{"book", "1"}, {"tv", "abc"}} {
+			if _, err := follows.Follow(context.Background(), "user-1", tt.mediaType, tt.mediaID); err == nil {
+				mt.Errorf("followed %s/%s", tt.mediaType, tt.mediaID)
+			}
+		}
+	})
+
+	runMock(t, "already followed", func(mt *mtest.T) {
+		srv, calls := newTMDBStub(t, tvStub)
+		mt.AddMockResponses(found("test.follows", bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "userId", Value: "user-1"},
+			{Key: "mediaType", Value: "tv"},
+			{Key: "mediaId", Value: "1"},
+			{Key: "lastSeason", Value: 1},
+		}))
+		follows := NewFollowsService(mt.DB, NewTMDBServiceWithConfig("token", srv.URL+"/3", nil))
+
+		follow, err := follows.Follow(context.Background(), "user-1", "tv", "1")
+		if err != nil {
+			mt.Fatal(err)
+		}
+		// Повторная подписка не сдвигает запомненную серию
+		if follow.LastSeason != 1 || calls.Load() != 0 {
+			mt.Errorf("follow = %+v, TMDB calls = %d", follow, calls.Load())
+		}
+	})
+
+	runMock(t, "new", func(mt *mtest.T) {
+		var language string
+		srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+			language = r.URL.Query().Get("language")
+			tvStub(w, r)
+		})
+		userID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found("test.follows"),
+			found("test.users", userDoc(userID, bson.E{Key: "preferences", Value: bson.D{{Key: "language", Value: "uk"}}})),
+			acknowledged(),
+		)
+		follows := NewFollowsService(mt.DB, NewTMDBServiceWithConfig("token", srv.URL+"/3", nil))
+
+		if _, err := follows.Follow(context.Background(), userID.Hex(), "tv", "1"); err != nil {
+			mt.Fatal(err)
+		}
+		// Название запрашивается на языке пользователя
+		if language != "uk-UA" {
+			mt.Errorf("TMDB language = %q", language)
+		}
+		insert, _ := findCommand(sentCommands(mt), "insert", "follows")
+		doc := statement(insert, "documents")
+		// Уже вышедшие серии не приходят уведомлением
+		if doc.Lookup("lastSeason").Int32() != 2 || doc.Lookup("lastEpisode").Int32() != 2 {
+			mt.Errorf("follow = %v", doc)
+		}
+		if got := doc.Lookup("posterPath").StringValue(); got != posterURL("/p.jpg") {
+			mt.Errorf("posterPath = %q", got)
+		}
+	})
+}
+
+func TestUnfollowNotFound(t *testing.T) {
+	runMock(t, "not followed", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged(bson.E{Key: "n", Value: 0}))
+		follows := NewFollowsService(mt.DB, nil)
+
+		if err := follows.Unfollow(context.Background(), "user-1", "tv", "1"); !errors.Is(err, ErrFollowNotFound) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+const maxListItems = 500
+
+var (
+	ErrListNotFound     = apperr.NotFound("list not found").WithCode("list_not_found")
+	ErrListItemExists   = apperr.Conflict("item already in list").WithCode("list_item_exists")
+	ErrListFull         = apperr.Conflict("list is full").WithCode("list_full")
+	ErrInvalidListOrder = apperr.Validation("reorder must contain exactly the current list items").WithCode("invalid_list_order")
+)
+
+type ListsService struct {
+	db   *mongo.Database
+	tmdb *TMDBService
+}
+
+func NewListsService(db *mongo.Database, tmdb *TMDBService) *ListsService {
+	return &ListsService{
+		db:   db,
+		tmdb: tmdb,
+	}
+}
+
+// EnsureIndexes creates indexes for the lists collection.
+func (s *ListsService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("lists").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "slug", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "updatedAt", Value: -1}}},
+	})
+	return err
+}
+
+func (s *ListsService) CreateList(ctx context.Context, userID string, req models.CreateListRequest) (*models.UserList, error) {
+	slug, err := generateListSlug(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	list := models.UserList{
+		ID:          primitive.NewObjectID(),
+		UserID:      userID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: strings.TrimSpace(req.Description),
+		Slug:        slug,
+		IsPublic:    req.IsPublic,
+		Items:       []models.ListItem{},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if _, err := s.db.Collection("lists").InsertOne(ctx, list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+// GetUserLists returns the user's lists, most recently updated first.
+func (s *ListsService) GetUserLists(ctx context.Context, userID string) ([]models.UserList, error) {
+	cursor, err := s.db.Collection("lists").Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	lists := []models.UserList{}
+	if err := cursor.All(ctx, &lists); err != nil {
+		return nil, err
+	}
+	return lists, nil
+}
+
+// GetList returns a list owned by the user.
+func (s *ListsService) GetList(ctx context.Context, userID, listID string) (*models.UserList, error) {
+	filter, err := ownedListFilter(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	return s.findOne(ctx, filter)
+}
+
+// GetPublicList returns a public list by its shareable slug.
+func (s *ListsService) GetPublicList(ctx context.Context, slug string) (*models.UserList, error) {
+	return s.findOne(ctx, bson.M{"slug": slug, "isPublic": true})
+}
+
+func (s *ListsService) UpdateList(ctx context.Context, userID, listID string, req models.UpdateListRequest) (*models.UserList, error) {
+	filter, err := ownedListFilter(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{"updatedAt": time.Now()}
+	if req.Name != nil {
+		set["name"] = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		set["description"] = strings.TrimSpace(*req.Description)
+	}
+	if req.IsPublic != nil {
+		set["isPublic"] = *req.IsPublic
+	}
+
+	return s.findOneAndUpdate(ctx, filter, bson.M{"$set": set})
+}
+
+func (s *ListsService) DeleteList(ctx context.Context, userID, listID string) error {
+	filter, err := ownedListFilter(userID, listID)
+	if err != nil {
+		return err
+	}
+
+	result, err := s.db.Collection("lists").DeleteOne(ctx, filter)
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrListNotFound
+	}
+	return nil
+}
+
+// AddItem appends a title to the end of the list, looking up its title and poster in TMDB.
+func (s *ListsService) AddItem(ctx context.Context, userID, listID string, req models.ListItemRequest) (*models.UserList, error) {
+	list, err := s.GetList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err := canAddItem(list, req); err != nil {
+		return nil, err
+	}
+
+	title, posterPath, err := fetchMediaSummary(s.tmdb, req.MediaID, req.MediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	item := models.ListItem{
+		MediaID:    req.MediaID,
+		MediaType:  req.MediaType,
+		Title:      title,
+		PosterPath: posterPath,
+		AddedAt:    time.Now(),
+	}
+
+	// Условия в фильтре защищают от дубликатов и переполнения при
+	// параллельных запросах
+	filter := bson.M{
+		"_id":    list.ID,
+		"userId": userID,
+		"items": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"mediaId":   req.MediaID,
+			"mediaType": req.MediaType,
+		}}},
+		fmt.Sprintf("items.%d", maxListItems-1): bson.M{"$exists": false},
+	}
+	update := bson.M{
+		"$push": bson.M{"items": item},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	}
+	updated, err := s.findOneAndUpdate(ctx, filter, update)
+	if err != ErrListNotFound {
+		return updated, err
+	}
+
+	// Фильтр не совпал: перечитываем список, чтобы отличить удаленный список
+	// от заполненного или уже добавленного элемента
+	list, err = s.GetList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if err := canAddItem(list, req); err != nil {
+		return nil, err
+	}
+	// Мешавший элемент успели убрать, пробуем еще раз
+	return s.findOneAndUpdate(ctx, filter, update)
+}
+
+// canAddItem reports why the item can't be added to the list, if it can't.
+func canAddItem(list *models.UserList, req models.ListItemRequest) error {
+	for _, item := range list.Items {
+		if item.MediaID == req.MediaID && item.MediaType == req.MediaType {
+			return ErrListItemExists
+		}
+	}
+	if len(list.Items) >= maxListItems {
+		return ErrListFull
+	}
+	return nil
+}
+
+func (s *ListsService) RemoveItem(ctx context.Context, userID, listID, mediaType, mediaID string) (*models.UserList, error) {
+	filter, err := ownedListFilter(userID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.findOneAndUpdate(ctx, filter, bson.M{
+		"$pull": bson.M{"items": bson.M{"mediaId": mediaID, "mediaType": mediaType}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	})
+}
+
+// ReorderItems replaces the item order. The request must list every current item exactly once.
+func (s *ListsService) ReorderItems(ctx context.Context, userID, listID string, order []models.ListItemRequest) (*models.UserList, error) {
+	list, err := s.GetList(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if len(order) != len(list.Items) {
+		return nil, ErrInvalidListOrder
+	}
+
+	byKey := make(map[string]models.ListItem, len(list.Items))
+	for _, item := range list.Items {
+		byKey[item.MediaType+":"+item.MediaID] = item
+	}
+
+	reordered := make([]models.ListItem, 0, len(order))
+	for _, entry := range order {
+		key := entry.MediaType + ":" + entry.MediaID
+		item, ok := byKey[key]
+		if !ok {
+			return nil, ErrInvalidListOrder
+		}
+		delete(byKey, key)
+		reordered = append(reordered, item)
+	}
+
+	// Сверяем updatedAt, чтобы не затереть изменения, сделанные между чтением и записью
+	return s.findOneAndUpdate(ctx,
+		bson.M{"_id": list.ID, "userId": userID, "updatedAt": list.UpdatedAt},
+		bson.M{"$set": bson.M{"items": reordered, "updatedAt": time.Now()}},
+	)
+}
+
+// DeleteAllForUser removes every list of the user, e.g. on account deletion.
+func (s *ListsService) DeleteAllForUser(ctx context.Context, userID string) error {
+	_, err := s.db.Collection("lists").DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
+func (s *ListsService) findOne(ctx context.Context, filter bson.M) (*models.UserList, error) {
+	var list models.UserList
+	err := s.db.Collection("lists").FindOne(ctx, filter).Decode(&list)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (s *ListsService) findOneAndUpdate(ctx context.Context, filter, update bson.M) (*models.UserList, error) {
+	var list models.UserList
+	err := s.db.Collection("lists").FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&list)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrListNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func ownedListFilter(userID, listID string) (bson.M, error) {
+	objectID, err := primitive.ObjectIDFromHex(listID)
+	if err != nil {
+		return nil, ErrListNotFound
+	}
+	return bson.M{"_id": objectID, "userId": userID}, nil
+}
+
+// generateListSlug builds a URL-friendly slug with a random suffix so that
+// lists with the same name never collide.
+func generateListSlug(name string) (string, error) {
+	var b strings.Builder
+	lastDash := true
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			lastDash = false
+		case !lastDash:
+			b.WriteByte('-')
+			lastDash = true
+		}
+		if b.Len() >= 40 {
+			break
+		}
+	}
+	base := strings.Trim(b.String(), "-")
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	if base == "" {
+		return hex.EncodeToString(suffix), nil
+	}
+	return base + "-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,223 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/models"
+)
+
+func TestGenerateListSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		base string
+	}{
+		{"Best Movies 2024!", "best-movies-2024-"},
+		{"  --Sci-Fi / Horror--  ", "sci-fi-horror-"},
+		// Кириллица в слаг не попадает
+		{"Мои фильмы", ""},
+		{strings.Repeat("a", 100), strings.Repeat("a", 40) + "-"},
+	}
+	suffix := regexp.MustCompile(`^[0-9a-f]{8}$`)
+	for _, tt := range tests {
+		slug, err := generateListSlug(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(slug, tt.base) || !suffix.MatchString(strings.TrimPrefix(slug, tt.base)) {
+			t.Errorf("generateListSlug(%q) = %q, want %q + random suffix", tt.name, slug, tt.base)
+		}
+	}
+
+	a, _ := generateListSlug("same")
+	b, _ := generateListSlug("same")
+	if a == b {
+		t.Error("slugs of lists with the same name collide")
+	}
+}
+
+func listDoc(id primitive.ObjectID, updatedAt time.Time, items ...string) bson.D {
+	var docs bson.A
+	for _, mediaID := range items {
+		docs = append(docs, bson.D{{Key: "mediaId", Value: mediaID}, {Key: "mediaType", Value: "movie"}})
+	}
+	return bson.D{
+		{Key: "_id", Value: id},
+		{Key: "userId", Value: "user-1"},
+		{Key: "items", Value: docs},
+		{Key: "updatedAt", Value: updatedAt},
+	}
+}
+
+func movies(ids ...string) []models.ListItemRequest {
+	order := make([]models.ListItemRequest, 0, len(ids))
+	for _, id := range ids {
+		order = append(order, models.ListItemRequest{MediaID: id, MediaType: "movie"})
+	}
+	return order
+}
+
+func TestReorderItemsRejectsInvalidOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		order []models.ListItemRequest
+	}{
+		{"missing item", movies("1", "2")},
+		{"unknown item", movies("1", "2", "4")},
+		{"duplicate", movies("1", "1", "2")},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			mt.AddMockResponses(found("test.lists", listDoc(id, time.Now(), "1", "2", "3")))
+			lists := NewListsService(mt.DB, nil)
+
+			_, err := lists.ReorderItems(context.Background(), "user-1", id.Hex(), tt.order)
+			if !errors.Is(err, ErrInvalidListOrder) {
+				mt.Fatalf("err = %v", err)
+			}
+		})
+	}
+}
+
+func TestReorderItemsChecksUpdatedAt(t *testing.T) {
+	runMock(t, "reorder", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		updatedAt := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+		mt.AddMockResponses(
+			found("test.lists", listDoc(id, updatedAt, "1", "2", "3")),
+			acknowledged(bson.E{Key: "value", Value: listDoc(id, time.Now(), "3", "1", "2")}),
+		)
+		lists := NewListsService(mt.DB, nil)
+
+		list, err := lists.ReorderItems(context.Background(), "user-1", id.Hex(), movies("3", "1", "2"))
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if list.Items[0].MediaID != "3" {
+			mt.Errorf("items = %+v", list.Items)
+		}
+
+		update, _ := findCommand(sentCommands(mt), "findAndModify", "lists")
+		if got := update.Lookup("query", "updatedAt").Time(); !got.Equal(updatedAt) {
+			mt.Errorf("update is not guarded by updatedAt: %v", got)
+		}
+		var order []string
+		items, _ := update.Lookup("update", "$set", "items").Array().Values()
+		for _, item := range items {
+			order = append(order, item.Document().Lookup("mediaId").StringValue())
+		}
+		if strings.Join(order, ",") != "3,1,2" {
+			mt.Errorf("saved order = %v", order)
+		}
+	})
+}
+
+func TestAddItemRejectsDuplicatesAndFullLists(t *testing.T) {
+	full := make([]string, maxListItems)
+	for i := range full {
+		full[i] = primitive.NewObjectID().Hex()
+	}
+	tests := []struct {
+		name  string
+		items []string
+		want  error
+	}{
+		{"duplicate", []string{"550"}, ErrListItemExists},
+		{"full", full, ErrListFull},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			mt.AddMockResponses(found("test.lists", listDoc(id, time.Now(), tt.items...)))
+			lists := NewListsService(mt.DB, nil)
+
+			_, err := lists.AddItem(context.Background(), "user-1", id.Hex(), models.ListItemRequest{MediaID: "550", MediaType: "movie"})
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddItemReportsWhyUpdateMissed(t *testing.T) {
+	full := make([]string, maxListItems)
+	for i := range full {
+		full[i] = primitive.NewObjectID().Hex()
+	}
+	srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":550,"title":"Fight Club"}`))
+	})
+	tests := []struct {
+		name string
+		// Список, каким он стал после неудачного обновления; nil — удален
+		reread []string
+		want   error
+	}{
+		{"deleted", nil, ErrListNotFound},
+		{"added concurrently", []string{"1", "550"}, ErrListItemExists},
+		{"filled concurrently", full, ErrListFull},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			reread := found("test.lists")
+			if tt.reread != nil {
+				reread = found("test.lists", listDoc(id, time.Now(), tt.reread...))
+			}
+			mt.AddMockResponses(
+				found("test.lists", listDoc(id, time.Now(), "1")),
+				acknowledged(bson.E{Key: "value", Value: nil}),
+				reread,
+			)
+			lists := NewListsService(mt.DB, NewTMDBServiceWithConfig("token", srv.URL+"/3", nil))
+
+			_, err := lists.AddItem(context.Background(), "user-1", id.Hex(), models.ListItemRequest{MediaID: "550", MediaType: "movie"})
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+
+			update, _ := findCommand(sentCommands(mt), "findAndModify", "lists")
+			if _, err := update.LookupErr("query", "items.499"); err != nil {
+				mt.Errorf("update is not guarded by the list size: %v", update.Lookup("query"))
+			}
+		})
+	}
+}
+
+func TestGetPublicListRequiresPublicFlag(t *testing.T) {
+	runMock(t, "private", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.lists"))
+		lists := NewListsService(mt.DB, nil)
+
+		if _, err := lists.GetPublicList(context.Background(), "my-list-abcd1234"); !errors.Is(err, ErrListNotFound) {
+			mt.Fatalf("err = %v", err)
+		}
+		lookup, _ := findCommand(sentCommands(mt), "find", "lists")
+		if !lookup.Lookup("filter", "isPublic").Boolean() {
+			mt.Error("lookup does not require isPublic")
+		}
+	})
+}
+
+func TestListOwnership(t *testing.T) {
+	lists := NewListsService(nil, nil)
+
+	if _, err := lists.GetList(context.Background(), "user-1", "not-an-id"); !errors.Is(err, ErrListNotFound) {
+		t.Fatalf("err = %v", err)
+	}
+
+	filter, err := ownedListFilter("user-1", primitive.NewObjectID().Hex())
+	if err != nil || filter["userId"] != "user-1" {
+		t.Fatalf("filter = %v, err = %v", filter, err)
+	}
+}
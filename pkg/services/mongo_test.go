@@ -0,0 +1,65 @@
+package services
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// Сервисы тестируются на mock-деплойменте драйвера: ответы сервера задаются
+// по порядку через AddMockResponses, а отправленные команды проверяются
+// через started-события
+
+func runMock(t *testing.T, name string, fn func(mt *mtest.T)) {
+	t.Helper()
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	mt.Run(name, fn)
+}
+
+// found отвечает на find одним пакетом документов
+func found(ns string, docs ...bson.D) bson.D {
+	return mtest.CreateCursorResponse(0, ns, mtest.FirstBatch, docs...)
+}
+
+// modified отвечает на update, изменивший n документов
+func modified(n int) bson.D {
+	return mtest.CreateSuccessResponse(bson.E{Key: "n", Value: n}, bson.E{Key: "nModified", Value: n})
+}
+
+// acknowledged отвечает на insert, delete и прочие команды без результата
+func acknowledged(fields ...bson.E) bson.D {
+	return mtest.CreateSuccessResponse(fields...)
+}
+
+// sentCommand — команда, отправленная сервису, с именем коллекции
+type sentCommand struct {
+	name       string
+	collection string
+	command    bson.Raw
+}
+
+func sentCommands(mt *mtest.T) []sentCommand {
+	var commands []sentCommand
+	for _, ev := range mt.GetAllStartedEvents() {
+		collection, _ := ev.Command.Lookup(ev.CommandName).StringValueOK()
+		commands = append(commands, sentCommand{name: ev.CommandName, collection: collection, command: ev.Command})
+	}
+	return commands
+}
+
+// findCommand возвращает первую команду name к коллекции collection
+func findCommand(commands []sentCommand, name, collection string) (bson.Raw, bool) {
+	for _, c := range commands {
+		if c.name == name && c.collection == collection {
+			return c.command, true
+		}
+	}
+	return nil, false
+}
+
+// statement возвращает первый элемент массива field команды, например
+// первый update из "updates" или первый документ из "documents"
+func statement(command bson.Raw, field string) bson.Raw {
+	return command.Lookup(field).Array().Index(0).Value().Document()
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/models"
+)
+
+const (
+	// notificationTTL — сколько хранятся уведомления, прочитанные и нет
+	notificationTTL = 90 * 24 * time.Hour
+	// notificationEmailTTL — письмо о выходе серии, застрявшее в очереди
+	// дольше, уже не нужно
+	notificationEmailTTL = 72 * time.Hour
+)
+
+var ErrNotificationNotFound = apperr.NotFound("notification not found").WithCode("notification_not_found")
+
+// notificationsSortFields maps sort parameters of List to document fields.
+var notificationsSortFields = map[string]string{
+	"createdAt": "createdAt",
+}
+
+// NotificationsService keeps the in-app notifications and runs the
+// scheduler that creates them: every interval it loads each followed title
+// from TMDB once and notifies the followers that have not seen its latest
+// episode or release yet. Users who opted in also get an email.
+type NotificationsService struct {
+	db       *mongo.Database
+	tmdb     *TMDBService
+	email    *EmailService
+	interval time.Duration
+}
+
+func NewNotificationsService(db *mongo.Database, tmdb *TMDBService, email *EmailService, interval time.Duration) *NotificationsService {
+	return &NotificationsService{db: db, tmdb: tmdb, email: email, interval: interval}
+}
+
+// EnsureIndexes creates the deduplication index, the indexes backing the
+// paginated list and the TTL index that removes old notifications.
+func (s *NotificationsService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "read", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "createdAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(notificationTTL.Seconds())),
+		},
+	})
+	return err
+}
+
+// List returns a page of the user's notifications, newest first by default.
+func (s *NotificationsService) List(ctx context.Context, userID string, unreadOnly bool, page models.PageRequest) (*models.PaginatedResponse, error) {
+	filter := bson.M{"userId": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	docs, result, err := findPage(ctx, s.collection(), filter, page, notificationsSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]models.Notification, 0, len(docs))
+	for _, doc := range docs {
+		var notification models.Notification
+		if err := bson.Unmarshal(doc, &notification); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	result.Items = notifications
+	return result, nil
+}
+
+func (s *NotificationsService) UnreadCount(ctx context.Context, userID string) (int64, error) {
+	return s.collection().CountDocuments(ctx, bson.M{"userId": userID, "read": false})
+}
+
+// MarkRead marks one notification as read. Marking it again is not an
+// error.
+func (s *NotificationsService) MarkRead(ctx context.Context, userID, notificationID string) (*models.Notification, error) {
+	objectID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return nil, ErrNotificationNotFound
+	}
+
+	var notification models.Notification
+	err = s.collection().FindOneAndUpdate(ctx,
+		bson.M{"_id": objectID, "userId": userID},
+		// $min сохраняет время первого прочтения
+		bson.M{"$set": bson.M{"read": true}, "$min": bson.M{"readAt": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&notification)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotificationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and
+// returns how many were changed.
+func (s *NotificationsService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	res, err := s.collection().UpdateMany(ctx,
+		bson.M{"userId": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "readAt": time.Now()}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+func (s *NotificationsService) Delete(ctx context.Context, userID, notificationID string) error {
+	objectID, err := primitive.ObjectIDFromHex(notificationID)
+	if err != nil {
+		return ErrNotificationNotFound
+	}
+	res, err := s.collection().DeleteOne(ctx, bson.M{"_id": objectID, "userId": userID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// Settings returns the user's notification delivery options.
+func (s *NotificationsService) Settings(ctx context.Context, userID string) (*models.NotificationSettings, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationSettings{Email: user.NotifyByEmail}, nil
+}
+
+// UpdateSettings turns email delivery on or off. Emails only go to
+// verified addresses, so turning it on requires a verified email.
+func (s *NotificationsService) UpdateSettings(ctx context.Context, userID string, email bool) (*models.NotificationSettings, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if email && !user.Verified {
+		return nil, ErrEmailNotVerified
+	}
+
+	update := bson.M{"$set": bson.M{"notifyByEmail": true, "updatedAt": time.Now()}}
+	if !email {
+		update = bson.M{
+			"$set":   bson.M{"updatedAt": time.Now()},
+			"$unset": bson.M{"notifyByEmail": ""},
+		}
+	}
+	if _, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return nil, err
+	}
+	return &models.NotificationSettings{Email: email}, nil
+}
+
+func (s *NotificationsService) findUser(ctx context.Context, userID string) (*models.User, error) {
+	objectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	var user models.User
+	err = s.db.Collection("users").FindOne(ctx, bson.M{"_id": objectID},
+		options.FindOne().SetProjection(bson.M{
+			"email": 1, "name": 1, "preferences": 1, "verified": 1, "banned": 1, "notifyByEmail": 1,
+		}),
+	).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Run checks followed titles every interval until ctx is cancelled.
+func (s *NotificationsService) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.CheckFollowed(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("notification check failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckFollowed compares every followed title with TMDB and creates
+// notifications for new episodes and releases. It returns how many
+// notifications were created. A title TMDB fails to return is skipped until
+// the next check.
+func (s *NotificationsService) CheckFollowed(ctx context.Context) (int, error) {
+	// Вышедшие фильмы больше не проверяем, сериалы — всегда
+	cursor, err := s.follows().Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"mediaType": "tv"},
+			bson.M{"mediaType": "movie", "released": false},
+		}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"mediaType": "$mediaType", "mediaId": "$mediaId"}}}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	var titles []struct {
+		ID mediaRef `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &titles); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, title := range titles {
+		if ctx.Err() != nil {
+			break
+		}
+		id, err := strconv.Atoi(title.ID.MediaID)
+		if err != nil {
+			continue
+		}
+		state, err := fetchMediaState(s.tmdb, title.ID.MediaType, id, "")
+		if err != nil {
+			slog.Warn("notification check: TMDB request failed",
+				"mediaType", title.ID.MediaType, "mediaId", title.ID.MediaID, "error", err)
+			continue
+		}
+		n, err := s.notifyFollowers(ctx, title.ID, state)
+		created += n
+		if err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// notifyFollowers notifies everyone following the title who is behind its
+// current state and moves their follow forward.
+func (s *NotificationsService) notifyFollowers(ctx context.Context, title mediaRef, state *mediaState) (int, error) {
+	filter := bson.M{"mediaType": title.MediaType, "mediaId": title.MediaID}
+	event := models.Notification{
+		MediaID:   title.MediaID,
+		MediaType: title.MediaType,
+	}
+	var advance bson.M
+
+	switch title.MediaType {
+	case "tv":
+		if state.season == 0 {
+			return 0, nil
+		}
+		filter["$or"] = bson.A{
+			bson.M{"lastSeason": bson.M{"$lt": state.season}},
+			bson.M{"lastSeason": state.season, "lastEpisode": bson.M{"$lt": state.episode}},
+		}
+		event.Type = models.NotificationNewEpisode
+		event.Key = fmt.Sprintf("tv:%s:s%de%d", title.MediaID, state.season, state.episode)
+		event.SeasonNumber = state.season
+		event.EpisodeNumber = state.episode
+		event.AirDate = state.airDate
+		advance = bson.M{"lastSeason": state.season, "lastEpisode": state.episode}
+	default:
+		if !state.released {
+			// Дата выхода могла сдвинуться — показываем актуальную
+			_, err := s.follows().UpdateMany(ctx, filter, bson.M{"$set": bson.M{"releaseDate": state.releaseDate}})
+			return 0, err
+		}
+		filter["released"] = false
+		event.Type = models.NotificationRelease
+		event.Key = "movie:" + title.MediaID + ":release"
+		event.AirDate = state.releaseDate
+		advance = bson.M{"released": true, "releaseDate": state.releaseDate}
+	}
+
+	cursor, err := s.follows().Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	var follows []models.Follow
+	if err := cursor.All(ctx, &follows); err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, follow := range follows {
+		notification := event
+		notification.ID = primitive.NewObjectID()
+		notification.UserID = follow.UserID
+		notification.Title = follow.Title
+		notification.PosterPath = follow.PosterPath
+		notification.CreatedAt = time.Now()
+
+		ok, err := s.create(ctx, &notification)
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+		if _, err := s.follows().UpdateOne(ctx, bson.M{"_id": follow.ID}, bson.M{"$set": advance}); err != nil {
+			return created, err
+		}
+	}
+	return created, nil
+}
+
+// create stores the notification and emails it to users who opted in. It
+// reports false if the same notification already exists.
+func (s *NotificationsService) create(ctx context.Context, notification *models.Notification) (bool, error) {
+	if _, err := s.collection().InsertOne(ctx, notification); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if s.email == nil {
+		return true, nil
+	}
+	user, err := s.findUser(ctx, notification.UserID)
+	if err != nil {
+		// Пользователь удален, а подписка осталась — уведомление ему уже не нужно
+		if errors.Is(err, ErrUserNotFound) {
+			return true, nil
+		}
+		return true, err
+	}
+	if !user.NotifyByEmail || !user.Verified || user.Banned {
+		return true, nil
+	}
+	// Ошибка письма не отменяет уведомление в приложении
+	err = s.email.SendNotificationEmail(ctx, user.Email, mail.NormalizeLocale(user.Preferences.Language), notification.Key,
+		NotificationEmail{
+			Name:          user.Name,
+			Type:          notification.Type,
+			Title:         notification.Title,
+			SeasonNumber:  notification.SeasonNumber,
+			EpisodeNumber: notification.EpisodeNumber,
+			AirDate:       notification.AirDate,
+		}, notificationEmailTTL)
+	if err != nil {
+		slog.Warn("failed to queue notification email", "userId", notification.UserID, "error", err)
+	}
+	return true, nil
+}
+
+func (s *NotificationsService) collection() *mongo.Collection {
+	return s.db.Collection("notifications")
+}
+
+func (s *NotificationsService) follows() *mongo.Collection {
+	return s.db.Collection("follows")
+}
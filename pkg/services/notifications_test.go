@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/models"
+)
+
+func followDoc(userID string, fields ...bson.E) bson.D {
+	return append(bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "userId", Value: userID},
+		{Key: "title", Value: "The Last of Us"},
+	}, fields...)
+}
+
+func duplicateKey() bson.D {
+	return mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key"})
+}
+
+func TestNotifyFollowersNewEpisode(t *testing.T) {
+	runMock(t, "new episode", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			found("test.follows", followDoc("user-1"), followDoc("user-2")),
+			acknowledged(),
+			modified(1),
+			// Уведомление уже создано прошлой проверкой
+			duplicateKey(),
+			modified(1),
+		)
+		notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+
+		created, err := notifications.notifyFollowers(context.Background(), mediaRef{MediaType: "tv", MediaID: "1"},
+			&mediaState{season: 2, episode: 3, airDate: "2025-04-27"})
+		if err != nil || created != 1 {
+			mt.Fatalf("created = %d, err = %v", created, err)
+		}
+
+		commands := sentCommands(mt)
+		find, _ := findCommand(commands, "find", "follows")
+		// Только те, кто отстал от текущей серии
+		or := find.Lookup("filter", "$or").Array()
+		if or.Index(1).Value().Document().Lookup("lastEpisode", "$lt").AsInt64() != 3 {
+			mt.Errorf("filter = %v", find.Lookup("filter"))
+		}
+		insert, _ := findCommand(commands, "insert", "notifications")
+		doc := statement(insert, "documents")
+		if doc.Lookup("key").StringValue() != "tv:1:s2e3" || doc.Lookup("type").StringValue() != models.NotificationNewEpisode {
+			mt.Errorf("notification = %v", doc)
+		}
+		if doc.Lookup("read").Boolean() {
+			mt.Error("new notification is read")
+		}
+
+		// Подписка сдвигается и у того, кому уведомление уже приходило
+		advanced := 0
+		for _, c := range commands {
+			if c.name == "update" && c.collection == "follows" {
+				advanced++
+				if statement(c.command, "updates").Lookup("u", "$set", "lastEpisode").AsInt64() != 3 {
+					mt.Errorf("update = %v", c.command)
+				}
+			}
+		}
+		if advanced != 2 {
+			mt.Errorf("advanced follows = %d", advanced)
+		}
+	})
+
+	runMock(t, "nothing aired", func(mt *mtest.T) {
+		notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+		if created, err := notifications.notifyFollowers(context.Background(), mediaRef{MediaType: "tv", MediaID: "1"}, &mediaState{}); err != nil || created != 0 {
+			mt.Fatalf("created = %d, err = %v", created, err)
+		}
+		if len(sentCommands(mt)) != 0 {
+			mt.Error("show without episodes was processed")
+		}
+	})
+}
+
+func TestNotifyFollowersMovie(t *testing.T) {
+	runMock(t, "not released", func(mt *mtest.T) {
+		mt.AddMockResponses(modified(2))
+		notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+
+		created, err := notifications.notifyFollowers(context.Background(), mediaRef{MediaType: "movie", MediaID: "2"},
+			&mediaState{releaseDate: "2030-01-01"})
+		if err != nil || created != 0 {
+			mt.Fatalf("created = %d, err = %v", created, err)
+		}
+		// Сдвинувшаяся дата выхода обновляется у всех подписчиков
+		update, _ := findCommand(sentCommands(mt), "update", "follows")
+		stmt := statement(update, "updates")
+		if !stmt.Lookup("multi").Boolean() || stmt.Lookup("u", "$set", "releaseDate").StringValue() != "2030-01-01" {
+			mt.Errorf("update = %v", stmt)
+		}
+		if _, ok := findCommand(sentCommands(mt), "insert", "notifications"); ok {
+			mt.Error("unreleased movie produced a notification")
+		}
+	})
+
+	runMock(t, "released", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.follows", followDoc("user-1")), acknowledged(), modified(1))
+		notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+
+		created, err := notifications.notifyFollowers(context.Background(), mediaRef{MediaType: "movie", MediaID: "2"},
+			&mediaState{releaseDate: "2024-03-01", released: true})
+		if err != nil || created != 1 {
+			mt.Fatalf("created = %d, err = %v", created, err)
+		}
+		commands := sentCommands(mt)
+		find, _ := findCommand(commands, "find", "follows")
+		if find.Lookup("filter", "released").Boolean() {
+			mt.Errorf("filter = %v", find.Lookup("filter"))
+		}
+		insert, _ := findCommand(commands, "insert", "notifications")
+		if got := statement(insert, "documents").Lookup("key").StringValue(); got != "movie:2:release" {
+			mt.Errorf("key = %q", got)
+		}
+	})
+}
+
+func TestCheckFollowedSkipsTMDBFailures(t *testing.T) {
+	runMock(t, "check", func(mt *mtest.T) {
+		srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/movie/2" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprintf(w, `{"id":2,"title":"Dune","release_date":%q}`, day(-1))
+		})
+		mt.AddMockResponses(
+			found("test.follows",
+				bson.D{{Key: "_id", Value: bson.D{{Key: "mediaType", Value: "movie"}, {Key: "mediaId", Value: "404"}}}},
+				bson.D{{Key: "_id", Value: bson.D{{Key: "mediaType", Value: "movie"}, {Key: "mediaId", Value: "2"}}}},
+			),
+			found("test.follows", followDoc("user-1")),
+			acknowledged(),
+			modified(1),
+		)
+		notifications := NewNotificationsService(mt.DB, NewTMDBServiceWithConfig("token", srv.URL+"/3", nil), nil, 0)
+
+		created, err := notifications.CheckFollowed(context.Background())
+		if err != nil || created != 1 {
+			mt.Fatalf("created = %d, err = %v", created, err)
+		}
+		// Вышедшие фильмы больше не проверяются
+		aggregate, _ := findCommand(sentCommands(mt), "aggregate", "follows")
+		match := aggregate.Lookup("pipeline").Array().Index(0).Value().Document().Lookup("$match", "$or").Array()
+		if match.Index(1).Value().Document().Lookup("released").Boolean() {
+			mt.Errorf("match = %v", match)
+		}
+	})
+}
+
+func TestNotificationEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  bson.D
+		email bool
+	}{
+		{"opted in", userDoc(primitive.NewObjectID(), bson.E{Key: "notifyByEmail", Value: true}), true},
+		{"not opted in", userDoc(primitive.NewObjectID()), false},
+		{"banned", userDoc(primitive.NewObjectID(), bson.E{Key: "notifyByEmail", Value: true}, bson.E{Key: "banned", Value: true}), false},
+		// Подписка удаленного пользователя не должна ломать проверку
+		{"deleted user", nil, false},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			users := found("test.users")
+			if tt.user != nil {
+				users = found("test.users", tt.user)
+			}
+			mt.AddMockResponses(acknowledged(), users, acknowledged(), acknowledged(bson.E{Key: "value", Value: nil}))
+			outbox := NewOutboxService(mt.DB, &recordingMailer{})
+			notifications := NewNotificationsService(mt.DB, nil, newTestEmailService(mt, outbox), 0)
+
+			ok, err := notifications.create(context.Background(), &models.Notification{
+				ID:            primitive.NewObjectID(),
+				UserID:        primitive.NewObjectID().Hex(),
+				Key:           "tv:1:s2e3",
+				Type:          models.NotificationNewEpisode,
+				Title:         "The Last of Us",
+				SeasonNumber:  2,
+				EpisodeNumber: 3,
+			})
+			if err != nil || !ok {
+				mt.Fatalf("ok = %v, err = %v", ok, err)
+			}
+			waitBackground(mt)
+
+			insert, queued := findCommand(sentCommands(mt), "insert", "email_outbox")
+			if queued != tt.email {
+				mt.Fatalf("email queued = %v, want %v", queued, tt.email)
+			}
+			if !queued {
+				return
+			}
+			doc := statement(insert, "documents")
+			if doc.Lookup("kind").StringValue() != EmailNotification || doc.Lookup("to").Array().Index(0).Value().StringValue() != "user@example.com" {
+				mt.Errorf("email = %v", doc)
+			}
+			if _, err := doc.LookupErr("deliverBefore"); err != nil {
+				mt.Error("notification email has no delivery deadline")
+			}
+		})
+	}
+}
+
+func TestNotificationsByUser(t *testing.T) {
+	runMock(t, "mark read", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged(bson.E{Key: "value", Value: nil}))
+		notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+
+		if _, err := notifications.MarkRead(context.Background(), "user-1", "not-an-id"); !errors.Is(err, ErrNotificationNotFound) {
+			mt.Errorf("invalid id: err = %v", err)
+		}
+		// Чужое уведомление не находится
+		if _, err := notifications.MarkRead(context.Background(), "user-1", primitive.NewObjectID().Hex()); !errors.Is(err, ErrNotificationNotFound) {
+			mt.Fatalf("err = %v", err)
+		}
+		update, _ := findCommand(sentCommands(mt), "findAndModify", "notifications")
+		if update.Lookup("query", "userId").StringValue() != "user-1" {
+			mt.Errorf("query = %v", update.Lookup("query"))
+		}
+		if _, err := update.LookupErr("update", "$min", "readAt"); err != nil {
+			mt.Error("first read time is overwritten")
+		}
+	})
+
+	runMock(t, "delete", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged(bson.E{Key: "n", Value: 0}))
+		notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+
+		if err := notifications.Delete(context.Background(), "user-1", primitive.NewObjectID().Hex()); !errors.Is(err, ErrNotificationNotFound) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestUpdateNotificationSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		verified bool
+		email    bool
+		want     error
+	}{
+		{"enable unverified", false, true, ErrEmailNotVerified},
+		{"enable", true, true, nil},
+		// Отключить можно и без подтвержденного адреса
+		{"disable unverified", false, false, nil},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			mt.AddMockResponses(found("test.users", userDoc(id, bson.E{Key: "verified", Value: tt.verified})), modified(1))
+			notifications := NewNotificationsService(mt.DB, nil, nil, 0)
+
+			settings, err := notifications.UpdateSettings(context.Background(), id.Hex(), tt.email)
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			update, updated := findCommand(sentCommands(mt), "update", "users")
+			if updated != (tt.want == nil) {
+				mt.Fatalf("updated = %v", updated)
+			}
+			if !updated {
+				return
+			}
+			if settings.Email != tt.email {
+				mt.Errorf("settings = %+v", settings)
+			}
+			u := statement(update, "updates").Lookup("u").Document()
+			if _, err := u.LookupErr("$unset", "notifyByEmail"); (err == nil) == tt.email {
+				mt.Errorf("update = %v", u)
+			}
+		})
+	}
+}
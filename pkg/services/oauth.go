@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/oauth2"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/oauth"
+)
+
+// oauthStateTTL — сколько живет state между редиректом и коллбеком
+const oauthStateTTL = 10 * time.Minute
+
+// oauthLoginCodeTTL — сколько живет код входа, переданный фронтенду в редиректе
+const oauthLoginCodeTTL = time.Minute
+
+var (
+	ErrOAuthProviderNotFound = apperr.NotFound("unknown oauth provider").WithCode("oauth_provider_not_found")
+	ErrOAuthNotConfigured    = apperr.Unavailable("oauth provider not configured").WithCode("oauth_not_configured")
+	ErrInvalidOAuthState     = apperr.Validation("invalid oauth state").WithCode("invalid_oauth_state")
+	ErrInvalidOAuthCode      = apperr.Validation("invalid or expired oauth login code").WithCode("invalid_oauth_code")
+	ErrOAuthEmailMissing     = apperr.BadRequest("email not provided by the oauth provider").WithCode("oauth_email_missing")
+	ErrOAuthEmailUnverified  = apperr.Conflict("an account with this email exists; log in and link the provider from the profile").WithCode("oauth_email_unverified")
+	ErrIdentityInUse         = apperr.Conflict("this account is already linked to another user").WithCode("identity_in_use")
+	ErrProviderAlreadyLinked = apperr.Conflict("another account of this provider is already linked").WithCode("provider_already_linked")
+	ErrProviderNotLinked     = apperr.NotFound("provider is not linked").WithCode("provider_not_linked")
+	ErrLastLoginMethod       = apperr.Conflict("cannot unlink the only login method; set a password first").WithCode("last_login_method")
+)
+
+// OAuthResult is the outcome of an OAuth callback: the user who logged in,
+// or the updated user when a provider was linked to an existing account.
+// Tokens for a login are issued separately, by OAuthTokens or in exchange
+// for the code from OAuthLoginRedirect.
+type OAuthResult struct {
+	Linked bool
+	User   *models.User
+}
+
+// oauthState is a pending authorization. UserID is set when the flow links
+// a provider to that user instead of logging in. Verifier is the PKCE code
+// verifier; only its challenge is sent to the browser.
+type oauthState struct {
+	ID        string    `bson:"_id"`
+	Provider  string    `bson:"provider"`
+	UserID    string    `bson:"userId,omitempty"`
+	Verifier  string    `bson:"verifier"`
+	ExpiresAt time.Time `bson:"expiresAt"`
+}
+
+// oauthLoginCode is a finished OAuth login waiting for the frontend to
+// exchange it for tokens. Only the hash of the code is stored.
+type oauthLoginCode struct {
+	ID        string             `bson:"_id"`
+	UserID    primitive.ObjectID `bson:"userId"`
+	ExpiresAt time.Time          `bson:"expiresAt"`
+}
+
+// OAuthProviders returns the names of the configured providers.
+func (s *AuthService) OAuthProviders() []string {
+	return s.providers.Names()
+}
+
+// StartOAuth returns the provider consent URL and the state it carries.
+// A non-empty linkUserID links the provider to that user on callback.
+func (s *AuthService) StartOAuth(ctx context.Context, providerName, linkUserID string) (string, string, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state := uuid.New().String()
+	verifier := oauth2.GenerateVerifier()
+	_, err = s.db.Collection("oauth_states").InsertOne(ctx, oauthState{
+		ID:        hashToken(state),
+		Provider:  providerName,
+		UserID:    linkUserID,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(oauthStateTTL),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return provider.AuthCodeURL(state, verifier), state, nil
+}
+
+// ConsumeOAuthState checks and invalidates state. It returns the user to
+// link the provider to (empty for a login) and the PKCE verifier for the
+// code exchange.
+func (s *AuthService) ConsumeOAuthState(ctx context.Context, providerName, state string) (string, string, error) {
+	if state == "" {
+		return "", "", ErrInvalidOAuthState
+	}
+	var pending oauthState
+	err := s.db.Collection("oauth_states").FindOneAndDelete(ctx, bson.M{
+		"_id":       hashToken(state),
+		"provider":  providerName,
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&pending)
+	if err == mongo.ErrNoDocuments {
+		return "", "", ErrInvalidOAuthState
+	}
+	if err != nil {
+		return "", "", err
+	}
+	// State, выданный до появления PKCE, обменять уже не получится
+	if pending.Verifier == "" {
+		return "", "", ErrInvalidOAuthState
+	}
+	return pending.UserID, pending.Verifier, nil
+}
+
+// CompleteOAuth exchanges code and either logs the user in (finding or
+// creating the account) or links the identity to linkUserID.
+func (s *AuthService) CompleteOAuth(ctx context.Context, providerName, code, verifier, linkUserID string) (*OAuthResult, error) {
+	identity, err := s.fetchIdentity(ctx, providerName, code, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if linkUserID != "" {
+		user, err := s.linkIdentity(ctx, linkUserID, identity)
+		if err != nil {
+			return nil, err
+		}
+		return &OAuthResult{Linked: true, User: user}, nil
+	}
+
+	user, err := s.findOrCreateOAuthUser(ctx, identity)
+	if err != nil {
+		return nil, err
+	}
+	if user.Banned {
+		return nil, ErrAccountBanned
+	}
+	return &OAuthResult{User: user}, nil
+}
+
+// OAuthTokens starts a session for a user who logged in with OAuth.
+func (s *AuthService) OAuthTokens(ctx context.Context, user *models.User) (*models.AuthResponse, error) {
+	return s.issueAuthResponse(ctx, *user)
+}
+
+// ExchangeOAuthCode trades the one-time code from OAuthLoginRedirect for
+// tokens. A code works once and only within oauthLoginCodeTTL.
+func (s *AuthService) ExchangeOAuthCode(ctx context.Context, code string) (*models.AuthResponse, error) {
+	var pending oauthLoginCode
+	err := s.db.Collection("oauth_login_codes").FindOneAndDelete(ctx, bson.M{
+		"_id":       hashToken(code),
+		"expiresAt": bson.M{"$gt": time.Now()},
+	}).Decode(&pending)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvalidOAuthCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUserByID(pending.UserID.Hex())
+	if err != nil {
+		// Аккаунт удалили, пока фронтенд обменивал код
+		return nil, ErrInvalidOAuthCode
+	}
+	return s.issueAuthResponse(ctx, *user)
+}
+
+// UnlinkProvider removes a linked identity. The last login method of an
+// account without a password cannot be removed.
+func (s *AuthService) UnlinkProvider(ctx context.Context, userID, providerName string) (*models.User, error) {
+	if !oauth.IsSupported(providerName) {
+		return nil, ErrOAuthProviderNotFound
+	}
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	linked := false
+	for _, identity := range user.Identities {
+		linked = linked || identity.Provider == providerName
+	}
+	if !linked {
+		return nil, ErrProviderNotLinked
+	}
+	if user.Password == "" && len(user.Identities) == 1 {
+		return nil, ErrLastLoginMethod
+	}
+
+	update := bson.M{
+		"$pull": bson.M{"identities": bson.M{"provider": providerName}},
+		"$set":  bson.M{"updatedAt": time.Now()},
+	}
+	if providerName == "google" {
+		update["$unset"] = bson.M{"googleId": ""}
+	}
+	if _, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		Action:  models.AuditOAuthUnlinked,
+		Changes: map[string]models.AuditChange{"identities": {From: providerName}},
+	})
+	return s.GetUserByID(userID)
+}
+
+// OAuthLoginRedirect builds the frontend URL the browser is sent to after
+// an OAuth login. The URL carries a one-time code rather than tokens, since
+// URLs end up in browser history, proxy logs and Referer headers; the
+// frontend exchanges it with ExchangeOAuthCode. ok is false if FRONTEND_URL
+// is not configured.
+func (s *AuthService) OAuthLoginRedirect(ctx context.Context, providerName string, user *models.User) (string, bool, error) {
+	if s.frontendURL == "" {
+		return "", false, nil
+	}
+
+	code, err := generateRefreshToken()
+	if err != nil {
+		return "", false, err
+	}
+	_, err = s.db.Collection("oauth_login_codes").InsertOne(ctx, oauthLoginCode{
+		ID:        hashToken(code),
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(oauthLoginCodeTTL),
+	})
+	if err != nil {
+		return "", false, err
+	}
+	redirectURL, ok := s.frontendRedirect("/auth/callback", url.Values{"provider": {providerName}, "code": {code}})
+	return redirectURL, ok, nil
+}
+
+// OAuthLoginErrorRedirect builds the frontend URL shown when an OAuth login
+// fails; ok is false if FRONTEND_URL is not configured.
+func (s *AuthService) OAuthLoginErrorRedirect(providerName, authErr string) (string, bool) {
+	return s.frontendRedirect("/login", url.Values{"oauth": {providerName}, "error": {authErr}})
+}
+
+// OAuthLinkRedirect builds the frontend URL shown after linking a provider.
+func (s *AuthService) OAuthLinkRedirect(providerName, linkErr string) (string, bool) {
+	q := url.Values{"linked": {providerName}}
+	if linkErr != "" {
+		q = url.Values{"oauth": {providerName}, "error": {linkErr}}
+	}
+	return s.frontendRedirect("/profile", q)
+}
+
+func (s *AuthService) frontendRedirect(path string, q url.Values) (string, bool) {
+	if s.frontendURL == "" {
+		return "", false
+	}
+	u, err := url.Parse(s.frontendURL + path)
+	if err != nil {
+		return "", false
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), true
+}
+
+func (s *AuthService) provider(name string) (oauth.Provider, error) {
+	if !oauth.IsSupported(name) {
+		return nil, ErrOAuthProviderNotFound
+	}
+	provider, ok := s.providers.Get(name)
+	if !ok {
+		return nil, ErrOAuthNotConfigured
+	}
+	return provider, nil
+}
+
+func (s *AuthService) fetchIdentity(ctx context.Context, providerName, code, verifier string) (*oauth.Identity, error) {
+	provider, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := provider.Exchange(ctx, code, verifier)
+	if err != nil {
+		return nil, apperr.BadRequest("failed to exchange authorization code").WithCode("oauth_exchange_failed").WithCause(err)
+	}
+	identity, err := provider.UserInfo(ctx, token)
+	if err != nil {
+		return nil, apperr.Upstream(providerName, err)
+	}
+	if identity.Subject == "" {
+		return nil, apperr.Upstream(providerName, errors.New("userinfo has no subject"))
+	}
+	return identity, nil
+}
+
+// findOrCreateOAuthUser finds the account by linked identity, then by an
+// email verified both by the provider and by the account owner (linking the
+// identity), and creates one otherwise.
+func (s *AuthService) findOrCreateOAuthUser(ctx context.Context, identity *oauth.Identity) (*models.User, error) {
+	collection := s.db.Collection("users")
+
+	var user models.User
+	err := collection.FindOne(ctx, identityFilter(identity)).Decode(&user)
+	if err == nil {
+		return &user, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	email := normalizeEmail(identity.Email)
+	if email == "" {
+		return nil, ErrOAuthEmailMissing
+	}
+
+	err = collection.FindOne(ctx, bson.M{"email": email}).Decode(&user)
+	if err == nil {
+		// Без подтверждения провайдером чужой адрес мог бы увести аккаунт.
+		// Неподтвержденный аккаунт мог зарегистрировать кто угодно, заранее
+		// задав свой пароль, поэтому к нему провайдер тоже не привязываем
+		if !identity.EmailVerified || !user.Verified {
+			return nil, ErrOAuthEmailUnverified
+		}
+		update := bson.M{
+			"$push": bson.M{"identities": newLinkedIdentity(identity)},
+			"$set":  profileFill(&user, identity),
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+			return nil, err
+		}
+		s.audit.Record(ctx, models.AuditEntry{
+			UserID:  user.ID.Hex(),
+			Action:  models.AuditOAuthLinked,
+			Changes: map[string]models.AuditChange{"identities": {To: identity.Provider}},
+		})
+		return s.GetUserByID(user.ID.Hex())
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	now := time.Now()
+	user = models.User{
+		ID:         primitive.NewObjectID(),
+		Email:      email,
+		Name:       identity.Name,
+		Avatar:     identity.AvatarURL,
+		Favorites:  []string{},
+		Verified:   identity.EmailVerified,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Provider:   identity.Provider,
+		Identities: []models.LinkedIdentity{newLinkedIdentity(identity)},
+	}
+	if _, err := collection.InsertOne(ctx, user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// linkIdentity attaches identity to the user who started the link flow.
+func (s *AuthService) linkIdentity(ctx context.Context, userID string, identity *oauth.Identity) (*models.User, error) {
+	user, err := s.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner models.User
+	err = s.db.Collection("users").FindOne(ctx, identityFilter(identity)).Decode(&owner)
+	switch {
+	case err == nil && owner.ID != user.ID:
+		return nil, ErrIdentityInUse
+	case err == nil:
+		// Уже привязан к этому пользователю
+		return user, nil
+	case err != mongo.ErrNoDocuments:
+		return nil, err
+	}
+	for _, linked := range user.Identities {
+		if linked.Provider == identity.Provider {
+			return nil, ErrProviderAlreadyLinked
+		}
+	}
+
+	update := bson.M{
+		"$push": bson.M{"identities": newLinkedIdentity(identity)},
+		"$set":  profileFill(user, identity),
+	}
+	if _, err := s.db.Collection("users").UpdateOne(ctx, bson.M{"_id": user.ID}, update); err != nil {
+		return nil, err
+	}
+
+	s.audit.Record(ctx, models.AuditEntry{
+		UserID:  userID,
+		Action:  models.AuditOAuthLinked,
+		Changes: map[string]models.AuditChange{"identities": {To: identity.Provider}},
+	})
+	return s.GetUserByID(userID)
+}
+
+// ensureOAuthIndexes makes an identity linkable to one user only, expires
+// pending states and moves legacy googleId values into identities.
+func (s *AuthService) ensureOAuthIndexes(ctx context.Context) error {
+	users := s.db.Collection("users")
+	_, err := users.UpdateMany(ctx,
+		bson.M{"googleId": bson.M{"$exists": true, "$ne": ""}, "identities": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"identities": bson.A{bson.M{
+			"provider": "google",
+			"subject":  "$googleId",
+			"email":    "$email",
+			"linkedAt": "$createdAt",
+		}}}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = users.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "identities.provider", Value: 1}, {Key: "identities.subject", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"identities.subject": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection("oauth_states").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection("oauth_login_codes").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+func identityFilter(identity *oauth.Identity) bson.M {
+	return bson.M{"identities": bson.M{"$elemMatch": bson.M{
+		"provider": identity.Provider,
+		"subject":  identity.Subject,
+	}}}
+}
+
+func newLinkedIdentity(identity *oauth.Identity) models.LinkedIdentity {
+	return models.LinkedIdentity{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+		LinkedAt: time.Now(),
+	}
+}
+
+// profileFill fills an empty name and avatar from the provider profile. A
+// verified provider email also confirms the account email.
+func profileFill(user *models.User, identity *oauth.Identity) bson.M {
+	set := bson.M{"updatedAt": time.Now()}
+	if user.Name == "" && identity.Name != "" {
+		set["name"] = identity.Name
+	}
+	if user.Avatar == "" && identity.AvatarURL != "" {
+		set["avatar"] = identity.AvatarURL
+	}
+	if identity.EmailVerified && normalizeEmail(identity.Email) == user.Email {
+		set["verified"] = true
+	}
+	return set
+}
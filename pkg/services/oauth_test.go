@@ -0,0 +1,431 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/oauth2"
+
+	"neomovies-api/pkg/models"
+	"neomovies-api/pkg/oauth"
+)
+
+// stubProvider отдает заранее заданную учетную запись и запоминает
+// verifier, с которым обменивался код
+type stubProvider struct {
+	identity oauth.Identity
+	verifier *string
+}
+
+func (p stubProvider) Name() string { return p.identity.Provider }
+
+func (p stubProvider) AuthCodeURL(state, verifier string) string {
+	return "https://provider.test/authorize?state=" + state
+}
+
+func (p stubProvider) Exchange(ctx context.Context, code, verifier string) (*oauth2.Token, error) {
+	if p.verifier != nil {
+		*p.verifier = verifier
+	}
+	return &oauth2.Token{AccessToken: "access-token"}, nil
+}
+
+func (p stubProvider) UserInfo(ctx context.Context, token *oauth2.Token) (*oauth.Identity, error) {
+	identity := p.identity
+	return &identity, nil
+}
+
+func newTestOAuthService(mt *mtest.T, provider oauth.Provider) *AuthService {
+	return NewAuthService(mt.DB, "secret", nil, NewSessionService(mt.DB, "secret"), NewAuditService(mt.DB), "http://api.test", "http://app.test", oauth.NewRegistry(provider))
+}
+
+func googleIdentity(email string, verified bool) oauth.Identity {
+	return oauth.Identity{Provider: "google", Subject: "g-1", Email: email, EmailVerified: verified, Name: "Neo"}
+}
+
+func TestOAuthState(t *testing.T) {
+	runMock(t, "start", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged())
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+		_, state, err := auth.StartOAuth(context.Background(), "google", "user-1")
+		if err != nil {
+			mt.Fatal(err)
+		}
+		insert, _ := findCommand(sentCommands(mt), "insert", "oauth_states")
+		doc := statement(insert, "documents")
+		// В базе только хэш state, а verifier хранится только на сервере
+		if got := doc.Lookup("_id").StringValue(); got != hashToken(state) {
+			mt.Errorf("stored state = %q", got)
+		}
+		if len(doc.Lookup("verifier").StringValue()) < 43 {
+			mt.Error("PKCE verifier is not stored")
+		}
+		if doc.Lookup("userId").StringValue() != "user-1" {
+			mt.Error("link target is not stored")
+		}
+	})
+
+	if _, _, err := (&AuthService{}).ConsumeOAuthState(context.Background(), "google", ""); !errors.Is(err, ErrInvalidOAuthState) {
+		t.Fatalf("empty state: err = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		pending []bson.D
+	}{
+		// Неизвестный, просроченный или выданный другому провайдеру state
+		{"unknown", nil},
+		{"without verifier", []bson.D{{{Key: "_id", Value: hashToken("state-1")}, {Key: "provider", Value: "google"}}}},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			var value interface{}
+			if len(tt.pending) > 0 {
+				value = tt.pending[0]
+			}
+			mt.AddMockResponses(acknowledged(bson.E{Key: "value", Value: value}))
+			auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+			if _, _, err := auth.ConsumeOAuthState(context.Background(), "google", "state-1"); !errors.Is(err, ErrInvalidOAuthState) {
+				mt.Fatalf("err = %v", err)
+			}
+			consume, _ := findCommand(sentCommands(mt), "findAndModify", "oauth_states")
+			if !consume.Lookup("remove").Boolean() || consume.Lookup("query", "provider").StringValue() != "google" {
+				mt.Errorf("state lookup = %v", consume)
+			}
+			if _, err := consume.LookupErr("query", "expiresAt", "$gt"); err != nil {
+				mt.Error("expired states are accepted")
+			}
+		})
+	}
+
+	runMock(t, "valid", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged(bson.E{Key: "value", Value: bson.D{
+			{Key: "_id", Value: hashToken("state-1")},
+			{Key: "provider", Value: "google"},
+			{Key: "userId", Value: "user-1"},
+			{Key: "verifier", Value: "verifier-1"},
+			{Key: "expiresAt", Value: time.Now().Add(time.Minute)},
+		}}))
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+		userID, verifier, err := auth.ConsumeOAuthState(context.Background(), "google", "state-1")
+		if err != nil || userID != "user-1" || verifier != "verifier-1" {
+			mt.Fatalf("userID = %q, verifier = %q, err = %v", userID, verifier, err)
+		}
+	})
+}
+
+func TestOAuthLoginByEmail(t *testing.T) {
+	tests := []struct {
+		name             string
+		identityVerified bool
+		accountVerified  bool
+		want             error
+	}{
+		{"both verified", true, true, nil},
+		{"provider email unverified", false, true, ErrOAuthEmailUnverified},
+		// Аккаунт, зарегистрированный заранее на чужой адрес, не должен
+		// получить учетную запись провайдера вместе с паролем злоумышленника
+		{"account unverified", true, false, ErrOAuthEmailUnverified},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			account := userDoc(id,
+				bson.E{Key: "verified", Value: tt.accountVerified},
+				bson.E{Key: "password", Value: "attacker-hash"},
+			)
+			mt.AddMockResponses(
+				found("test.users"),
+				found("test.users", account),
+				modified(1),
+				acknowledged(),
+				found("test.users", account),
+				found("test.sessions"),
+				acknowledged(),
+			)
+			var verifier string
+			auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("User@Example.com", tt.identityVerified), verifier: &verifier})
+
+			_, err := auth.CompleteOAuth(context.Background(), "google", "code", "verifier-1", "")
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if verifier != "verifier-1" {
+				mt.Errorf("code exchanged with verifier %q", verifier)
+			}
+
+			commands := sentCommands(mt)
+			lookups := 0
+			for _, c := range commands {
+				if c.name == "find" && c.collection == "users" {
+					lookups++
+					if lookups == 2 && c.command.Lookup("filter", "email").StringValue() != "user@example.com" {
+						mt.Errorf("email lookup = %v", c.command.Lookup("filter"))
+					}
+				}
+			}
+			link, linked := findCommand(commands, "update", "users")
+			if linked != (tt.want == nil) {
+				mt.Fatalf("identity linked = %v", linked)
+			}
+			if linked {
+				if got := statement(link, "updates").Lookup("u", "$push", "identities", "subject").StringValue(); got != "g-1" {
+					mt.Errorf("linked subject = %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestOAuthCreatesUser(t *testing.T) {
+	runMock(t, "new user", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			found("test.users"),
+			found("test.users"),
+			acknowledged(),
+		)
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity(" New@Example.com", true)})
+
+		result, err := auth.CompleteOAuth(context.Background(), "google", "code", "verifier-1", "")
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if result.Linked || result.User == nil {
+			mt.Fatalf("result = %+v", result)
+		}
+		insert, _ := findCommand(sentCommands(mt), "insert", "users")
+		doc := statement(insert, "documents")
+		if doc.Lookup("email").StringValue() != "new@example.com" || !doc.Lookup("verified").Boolean() {
+			mt.Errorf("created user = %v", doc)
+		}
+		if _, err := doc.LookupErr("password"); err == nil && doc.Lookup("password").StringValue() != "" {
+			mt.Error("OAuth user got a password")
+		}
+	})
+
+	runMock(t, "no email", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.users"))
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+		if _, err := auth.CompleteOAuth(context.Background(), "google", "code", "verifier-1", ""); !errors.Is(err, ErrOAuthEmailMissing) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestOAuthLoginCode(t *testing.T) {
+	runMock(t, "redirect and exchange", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			acknowledged(),
+			acknowledged(bson.E{Key: "value", Value: bson.D{
+				{Key: "_id", Value: "hash"},
+				{Key: "userId", Value: id},
+				{Key: "expiresAt", Value: time.Now().Add(time.Minute)},
+			}}),
+			found("test.users", userDoc(id)),
+			acknowledged(),
+		)
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+		redirectURL, ok, err := auth.OAuthLoginRedirect(context.Background(), "google", &models.User{ID: id})
+		if err != nil || !ok {
+			mt.Fatalf("ok = %v, err = %v", ok, err)
+		}
+		u, _ := url.Parse(redirectURL)
+		code := u.Query().Get("code")
+		// Токены не попадают в URL
+		if u.Path != "/auth/callback" || code == "" || u.Query().Has("token") || u.Query().Has("refreshToken") {
+			mt.Fatalf("redirect = %s", redirectURL)
+		}
+		insert, _ := findCommand(sentCommands(mt), "insert", "oauth_login_codes")
+		doc := statement(insert, "documents")
+		if doc.Lookup("_id").StringValue() != hashToken(code) || doc.Lookup("userId").ObjectID() != id {
+			mt.Errorf("stored code = %v", doc)
+		}
+
+		response, err := auth.ExchangeOAuthCode(context.Background(), code)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if response.Token == "" || response.RefreshToken == "" || response.User.ID != id {
+			mt.Errorf("response = %+v", response)
+		}
+		// Код удаляется при обмене, поэтому работает один раз
+		exchange, _ := findCommand(sentCommands(mt), "findAndModify", "oauth_login_codes")
+		if !exchange.Lookup("remove").Boolean() || exchange.Lookup("query", "_id").StringValue() != hashToken(code) {
+			mt.Errorf("exchange = %v", exchange)
+		}
+	})
+
+	runMock(t, "invalid code", func(mt *mtest.T) {
+		mt.AddMockResponses(acknowledged(bson.E{Key: "value", Value: nil}))
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+		if _, err := auth.ExchangeOAuthCode(context.Background(), "used"); !errors.Is(err, ErrInvalidOAuthCode) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+
+	runMock(t, "no frontend", func(mt *mtest.T) {
+		auth := NewAuthService(mt.DB, "secret", nil, NewSessionService(mt.DB, "secret"), NewAuditService(mt.DB), "http://api.test", "", nil)
+
+		if _, ok, err := auth.OAuthLoginRedirect(context.Background(), "google", &models.User{ID: primitive.NewObjectID()}); ok || err != nil {
+			mt.Fatalf("ok = %v, err = %v", ok, err)
+		}
+		if len(sentCommands(mt)) != 0 {
+			mt.Error("code stored without a frontend to redirect to")
+		}
+	})
+
+	runMock(t, "banned", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.users", userDoc(primitive.NewObjectID(),
+			bson.E{Key: "identities", Value: bson.A{bson.D{{Key: "provider", Value: "google"}, {Key: "subject", Value: "g-1"}}}},
+			bson.E{Key: "banned", Value: true},
+		)))
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("user@example.com", true)})
+
+		if _, err := auth.CompleteOAuth(context.Background(), "google", "code", "verifier-1", ""); !errors.Is(err, ErrAccountBanned) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestOAuthLink(t *testing.T) {
+	userID := primitive.NewObjectID()
+	linkedGoogle := bson.E{Key: "identities", Value: bson.A{bson.D{{Key: "provider", Value: "google"}, {Key: "subject", Value: "g-other"}}}}
+
+	tests := []struct {
+		name  string
+		owner []bson.D
+		user  bson.D
+		want  error
+	}{
+		{"owned by another user", []bson.D{userDoc(primitive.NewObjectID())}, userDoc(userID), ErrIdentityInUse},
+		{"provider already linked", nil, userDoc(userID, linkedGoogle), ErrProviderAlreadyLinked},
+		{"linked", nil, userDoc(userID), nil},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(
+				found("test.users", tt.user),
+				found("test.users", tt.owner...),
+				modified(1),
+				acknowledged(),
+				found("test.users", tt.user),
+			)
+			auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("other@gmail.com", true)})
+
+			result, err := auth.CompleteOAuth(context.Background(), "google", "code", "verifier-1", userID.Hex())
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			if !result.Linked || result.User == nil {
+				mt.Errorf("result = %+v", result)
+			}
+			update, _ := findCommand(sentCommands(mt), "update", "users")
+			// Чужой адрес провайдера не подтверждает email аккаунта
+			if _, err := statement(update, "updates").Lookup("u", "$set").Document().LookupErr("verified"); err == nil {
+				mt.Error("account email verified by a different provider email")
+			}
+		})
+	}
+}
+
+func TestUnlinkProvider(t *testing.T) {
+	identities := func(providers ...string) bson.E {
+		var list bson.A
+		for _, p := range providers {
+			list = append(list, bson.D{{Key: "provider", Value: p}, {Key: "subject", Value: p + "-1"}})
+		}
+		return bson.E{Key: "identities", Value: list}
+	}
+
+	tests := []struct {
+		name     string
+		provider string
+		user     bson.D
+		want     error
+	}{
+		{"unknown provider", "facebook", nil, ErrOAuthProviderNotFound},
+		{"not linked", "github", userDoc(primitive.NewObjectID(), identities("google")), ErrProviderNotLinked},
+		{"last login method", "google", userDoc(primitive.NewObjectID(), identities("google")), ErrLastLoginMethod},
+		{"other provider left", "google", userDoc(primitive.NewObjectID(), identities("google", "github")), nil},
+		{"password left", "google", userDoc(primitive.NewObjectID(), identities("google"), bson.E{Key: "password", Value: "hash"}), nil},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			if tt.user != nil {
+				id = tt.user[0].Value.(primitive.ObjectID)
+				mt.AddMockResponses(found("test.users", tt.user), modified(1), acknowledged(), found("test.users", tt.user))
+			}
+			auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+			_, err := auth.UnlinkProvider(context.Background(), id.Hex(), tt.provider)
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				return
+			}
+			update, _ := findCommand(sentCommands(mt), "update", "users")
+			u := statement(update, "updates").Lookup("u").Document()
+			if got := u.Lookup("$pull", "identities", "provider").StringValue(); got != tt.provider {
+				mt.Errorf("pulled provider = %q", got)
+			}
+			// Старое поле googleId иначе снова нашло бы аккаунт
+			if _, err := u.LookupErr("$unset", "googleId"); err != nil {
+				mt.Error("googleId is kept")
+			}
+		})
+	}
+}
+
+func TestEnsureOAuthIndexesMigratesGoogleID(t *testing.T) {
+	runMock(t, "migration", func(mt *mtest.T) {
+		mt.AddMockResponses(modified(3), acknowledged(), acknowledged(), acknowledged())
+		auth := newTestOAuthService(mt, stubProvider{identity: googleIdentity("", false)})
+
+		if err := auth.ensureOAuthIndexes(context.Background()); err != nil {
+			mt.Fatal(err)
+		}
+		commands := sentCommands(mt)
+		update, ok := findCommand(commands, "update", "users")
+		if !ok {
+			mt.Fatal("legacy googleId values were not migrated")
+		}
+		stmt := statement(update, "updates")
+		if !stmt.Lookup("multi").Boolean() {
+			mt.Error("migration updates a single user")
+		}
+		// Уже мигрированные пользователи не трогаются
+		if _, err := stmt.Lookup("q").Document().LookupErr("identities", "$exists"); err != nil {
+			mt.Errorf("filter = %v", stmt.Lookup("q"))
+		}
+		identity := stmt.Lookup("u").Array().Index(0).Value().Document().Lookup("$set", "identities").Array().Index(0).Value().Document()
+		if identity.Lookup("provider").StringValue() != "google" || identity.Lookup("subject").StringValue() != "$googleId" {
+			mt.Errorf("migrated identity = %v", identity)
+		}
+
+		index, ok := findCommand(commands, "createIndexes", "users")
+		if !ok {
+			mt.Fatal("identity index was not created")
+		}
+		if !index.Lookup("indexes").Array().Index(0).Value().Document().Lookup("unique").Boolean() {
+			mt.Error("identity index is not unique")
+		}
+	})
+}
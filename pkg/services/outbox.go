@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/background"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/models"
+)
+
+// Outbox delivery policy. A failed attempt is retried after 30s, 1m, 2m,
+// ... up to 30m between attempts; after outboxMaxAttempts the email is
+// dead-lettered.
+const (
+	outboxMaxAttempts  = 8
+	outboxBaseDelay    = 30 * time.Second
+	outboxMaxDelay     = 30 * time.Minute
+	outboxPollInterval = 10 * time.Second
+	// outboxLockTTL — через сколько письмо, зависшее в sending (например,
+	// процесс упал во время отправки), снова берется в работу
+	outboxLockTTL = 2 * time.Minute
+	outboxSentTTL = 7 * 24 * time.Hour
+	outboxDeadTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrOutboxEmailNotFound = apperr.NotFound("email not found").WithCode("email_not_found")
+	ErrOutboxEmailSent     = apperr.Conflict("email has already been sent").WithCode("email_already_sent")
+	ErrOutboxEmailExpired  = apperr.Conflict("email is past its delivery deadline").WithCode("email_expired")
+)
+
+// outboxSortFields maps sort parameters of List to document fields.
+var outboxSortFields = map[string]string{
+	"createdAt": "createdAt",
+}
+
+// OutboxMessage is an email to enqueue.
+type OutboxMessage struct {
+	// Key makes Enqueue idempotent: a message with a key that is already
+	// queued is not queued again. Empty means no deduplication.
+	Key     string
+	Kind    string
+	Message mail.Message
+	// ValidFor is how long the content stays useful, e.g. the lifetime of
+	// a verification code. Zero means no deadline.
+	ValidFor time.Duration
+}
+
+// OutboxService queues emails in the email_outbox collection. Each email is
+// sent right after it is queued; Run, or ProcessDue called by a scheduler
+// where nothing runs between requests, retries failures with backoff.
+type OutboxService struct {
+	db     *mongo.Database
+	mailer mail.Mailer
+	// worker — запущен ли Run; без него письмо отправляется в самом запросе
+	worker atomic.Bool
+}
+
+func NewOutboxService(db *mongo.Database, mailer mail.Mailer) *OutboxService {
+	return &OutboxService{db: db, mailer: mailer}
+}
+
+// EnsureIndexes creates the idempotency key index, the index the worker
+// polls and the TTL index that removes delivered and dead emails.
+func (s *OutboxService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection().Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "nextAttemptAt", Value: 1}}},
+		{
+			Keys:    bson.D{{Key: "purgeAt", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	return err
+}
+
+// Enqueue stores msg and starts delivering it in the background. If an
+// email with the same key is already queued, that email is returned.
+func (s *OutboxService) Enqueue(ctx context.Context, msg OutboxMessage) (*models.OutboxEmail, error) {
+	// Адреса храним в том же виде, что и в users, иначе фильтр List по
+	// получателю не находил бы письма на адрес с заглавными буквами
+	to := make([]string, len(msg.Message.To))
+	for i, addr := range msg.Message.To {
+		to[i] = normalizeEmail(addr)
+	}
+
+	now := time.Now()
+	email := models.OutboxEmail{
+		ID:            primitive.NewObjectID(),
+		Key:           msg.Key,
+		Kind:          msg.Kind,
+		To:            to,
+		Subject:       msg.Message.Subject,
+		Text:          msg.Message.Text,
+		HTML:          msg.Message.HTML,
+		Headers:       msg.Message.Headers,
+		Status:        models.OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if email.Key == "" {
+		email.Key = email.ID.Hex()
+	}
+	if msg.ValidFor > 0 {
+		deadline := now.Add(msg.ValidFor)
+		email.DeliverBefore = &deadline
+	}
+
+	_, err := s.collection().InsertOne(ctx, email)
+	if mongo.IsDuplicateKeyError(err) {
+		// Повтор того же запроса не должен отправлять письмо второй раз
+		var existing models.OutboxEmail
+		if err := s.collection().FindOne(ctx, bson.M{"key": email.Key}).Decode(&existing); err != nil {
+			return nil, err
+		}
+		return &existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.deliverLater(email.ID)
+	return &email, nil
+}
+
+// Run delivers due emails every outboxPollInterval until ctx is done.
+func (s *OutboxService) Run(ctx context.Context) {
+	s.worker.Store(true)
+	defer s.worker.Store(false)
+
+	ticker := time.NewTicker(outboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			slog.Warn("email outbox poll failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue delivers every email whose next attempt is due and returns
+// how many were attempted.
+func (s *OutboxService) ProcessDue(ctx context.Context) (int, error) {
+	processed := 0
+	for ctx.Err() == nil {
+		ok, err := s.deliver(ctx, bson.M{})
+		if err != nil || !ok {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+// List returns a page of queued and sent emails, newest first by default.
+func (s *OutboxService) List(ctx context.Context, filter models.OutboxFilter, page models.PageRequest) (*models.PaginatedResponse, error) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.To != "" {
+		query["to"] = normalizeEmail(filter.To)
+	}
+
+	// У писем нет типа медиа
+	page.MediaType = ""
+
+	docs, result, err := findPage(ctx, s.collection(), query, page, outboxSortFields)
+	if err != nil {
+		return nil, err
+	}
+
+	emails := make([]models.OutboxEmail, 0, len(docs))
+	for _, doc := range docs {
+		var email models.OutboxEmail
+		if err := bson.Unmarshal(doc, &email); err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	result.Items = emails
+	return result, nil
+}
+
+// Get returns a single email by ID.
+func (s *OutboxService) Get(ctx context.Context, emailID string) (*models.OutboxEmail, error) {
+	objectID, err := primitive.ObjectIDFromHex(emailID)
+	if err != nil {
+		return nil, ErrOutboxEmailNotFound
+	}
+
+	var email models.OutboxEmail
+	err = s.collection().FindOne(ctx, bson.M{"_id": objectID}).Decode(&email)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrOutboxEmailNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &email, nil
+}
+
+// Retry requeues a pending or dead email with a fresh attempt budget.
+func (s *OutboxService) Retry(ctx context.Context, emailID string) (*models.OutboxEmail, error) {
+	email, err := s.Get(ctx, emailID)
+	if err != nil {
+		return nil, err
+	}
+	if email.Status == models.OutboxSent {
+		return nil, ErrOutboxEmailSent
+	}
+	if email.DeliverBefore != nil && email.DeliverBefore.Before(time.Now()) {
+		return nil, ErrOutboxEmailExpired
+	}
+	// У писем со сроком действия тело удаляется вместе с переводом в dead,
+	// отправлять нечего: пользователь запросит новый код или ссылку
+	if email.DeliverBefore != nil && email.Status == models.OutboxDead {
+		return nil, ErrOutboxEmailExpired
+	}
+
+	now := time.Now()
+	result, err := s.collection().UpdateOne(ctx,
+		bson.M{"_id": email.ID, "status": bson.M{"$in": bson.A{models.OutboxPending, models.OutboxDead}}},
+		bson.M{
+			"$set":   bson.M{"status": models.OutboxPending, "attempts": 0, "nextAttemptAt": now, "updatedAt": now},
+			"$unset": bson.M{"lockedUntil": "", "purgeAt": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Письмо, которое прямо сейчас отправляется, не трогаем
+	if result.ModifiedCount > 0 {
+		s.deliverLater(email.ID)
+	}
+	return s.Get(ctx, emailID)
+}
+
+// deliverLater sends a just-queued email without holding up the request.
+// Without a running worker (serverless, where the instance may be frozen as
+// soon as the response is written) the email is sent before returning.
+func (s *OutboxService) deliverLater(id primitive.ObjectID) {
+	deliver := func() {
+		if _, err := s.deliver(context.Background(), bson.M{"_id": id}); err != nil {
+			slog.Warn("email outbox delivery failed", "email_id", id.Hex(), "error", err)
+		}
+	}
+	if !s.worker.Load() {
+		deliver()
+		return
+	}
+	background.Go("email outbox", deliver)
+}
+
+// deliver claims one due email matching filter and sends it. It returns
+// false if there was nothing to claim.
+func (s *OutboxService) deliver(ctx context.Context, filter bson.M) (bool, error) {
+	now := time.Now()
+	filter["$or"] = bson.A{
+		bson.M{"status": models.OutboxPending, "nextAttemptAt": bson.M{"$lte": now}},
+		bson.M{"status": models.OutboxSending, "lockedUntil": bson.M{"$lt": now}},
+	}
+
+	var email models.OutboxEmail
+	err := s.collection().FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"status": models.OutboxSending, "lockedUntil": now.Add(outboxLockTTL), "updatedAt": now}},
+		options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetReturnDocument(options.After),
+	).Decode(&email)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, s.send(ctx, &email)
+}
+
+func (s *OutboxService) send(ctx context.Context, email *models.OutboxEmail) error {
+	// Результат попытки сохраняем, даже если ctx отменили во время отправки
+	saveCtx := context.WithoutCancel(ctx)
+	attempts := email.Attempts + 1
+
+	if email.DeliverBefore != nil && email.DeliverBefore.Before(time.Now()) {
+		return s.markDead(saveCtx, email, email.Attempts, "delivery deadline passed")
+	}
+
+	sendErr := s.mailer.Send(ctx, &mail.Message{
+		To:      email.To,
+		Subject: email.Subject,
+		Text:    email.Text,
+		HTML:    email.HTML,
+		Headers: email.Headers,
+	})
+	if sendErr == nil {
+		now := time.Now()
+		_, err := s.collection().UpdateOne(saveCtx, bson.M{"_id": email.ID}, bson.M{
+			"$set": bson.M{
+				"status":    models.OutboxSent,
+				"attempts":  attempts,
+				"sentAt":    now,
+				"purgeAt":   now.Add(outboxSentTTL),
+				"updatedAt": now,
+			},
+			// Коды и ссылки из письма больше не нужны
+			"$unset": bson.M{"text": "", "html": "", "headers": "", "lockedUntil": ""},
+		})
+		return err
+	}
+
+	if attempts >= outboxMaxAttempts {
+		return s.markDead(saveCtx, email, attempts, sendErr.Error())
+	}
+
+	slog.Warn("email delivery failed, will retry",
+		"email_id", email.ID.Hex(), "kind", email.Kind, "attempt", attempts, "error", sendErr)
+	now := time.Now()
+	_, err := s.collection().UpdateOne(saveCtx, bson.M{"_id": email.ID}, bson.M{
+		"$set": bson.M{
+			"status":        models.OutboxPending,
+			"attempts":      attempts,
+			"lastError":     sendErr.Error(),
+			"nextAttemptAt": now.Add(outboxBackoff(attempts)),
+			"updatedAt":     now,
+		},
+		"$unset": bson.M{"lockedUntil": ""},
+	})
+	return err
+}
+
+func (s *OutboxService) markDead(ctx context.Context, email *models.OutboxEmail, attempts int, reason string) error {
+	slog.Error("email dead-lettered",
+		"email_id", email.ID.Hex(), "kind", email.Kind, "attempts", attempts, "error", reason)
+	unset := bson.M{"lockedUntil": ""}
+	// Коды и ссылки живут недолго, хранить их месяц незачем
+	if email.DeliverBefore != nil {
+		unset["text"], unset["html"], unset["headers"] = "", "", ""
+	}
+	now := time.Now()
+	_, err := s.collection().UpdateOne(ctx, bson.M{"_id": email.ID}, bson.M{
+		"$set": bson.M{
+			"status":    models.OutboxDead,
+			"attempts":  attempts,
+			"lastError": reason,
+			"purgeAt":   now.Add(outboxDeadTTL),
+			"updatedAt": now,
+		},
+		"$unset": unset,
+	})
+	return err
+}
+
+func (s *OutboxService) collection() *mongo.Collection {
+	return s.db.Collection("email_outbox")
+}
+
+// outboxBackoff is the delay before the next attempt after attempts
+// failures.
+func outboxBackoff(attempts int) time.Duration {
+	delay := outboxBaseDelay
+	for i := 1; i < attempts && delay < outboxMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > outboxMaxDelay {
+		delay = outboxMaxDelay
+	}
+	return delay
+}
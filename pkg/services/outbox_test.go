@@ -0,0 +1,281 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/background"
+	"neomovies-api/pkg/mail"
+	"neomovies-api/pkg/models"
+)
+
+// recordingMailer запоминает отправленные письма и возвращает err
+type recordingMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, *msg)
+	return nil
+}
+
+func waitBackground(t testing.TB) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := background.Wait(ctx); err != nil {
+		t.Fatal("background delivery did not finish")
+	}
+}
+
+func TestOutboxBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, 30 * time.Minute},
+		{20, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := outboxBackoff(tt.attempts); got != tt.want {
+			t.Errorf("outboxBackoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxEnqueue(t *testing.T) {
+	runMock(t, "new", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			acknowledged(),
+			acknowledged(bson.E{Key: "value", Value: bson.D{
+				{Key: "_id", Value: id},
+				{Key: "to", Value: bson.A{"user@example.com"}},
+				{Key: "subject", Value: "Код"},
+				{Key: "text", Value: "123456"},
+				{Key: "status", Value: models.OutboxSending},
+			}}),
+			modified(1),
+		)
+		mailer := &recordingMailer{}
+		outbox := NewOutboxService(mt.DB, mailer)
+
+		email, err := outbox.Enqueue(context.Background(), OutboxMessage{
+			Kind:     "verification",
+			Message:  mail.Message{To: []string{" User@Example.COM "}, Subject: "Код", Text: "123456"},
+			ValidFor: 15 * time.Minute,
+		})
+		if err != nil {
+			mt.Fatal(err)
+		}
+
+		if email.Key != email.ID.Hex() || email.Status != models.OutboxPending || email.DeliverBefore == nil {
+			mt.Errorf("email = %+v", email)
+		}
+		commands := sentCommands(mt)
+		insert, _ := findCommand(commands, "insert", "email_outbox")
+		if got := statement(insert, "documents").Lookup("to").Array().Index(0).Value().StringValue(); got != "user@example.com" {
+			mt.Errorf("stored recipient = %q", got)
+		}
+
+		// Письмо отправляется сразу, не дожидаясь опроса; без запущенного Run
+		// (serverless) — до возврата из Enqueue
+		if len(mailer.sent) != 1 || mailer.sent[0].To[0] != "user@example.com" {
+			mt.Fatalf("sent = %+v", mailer.sent)
+		}
+		update, _ := findCommand(commands, "update", "email_outbox")
+		u := statement(update, "updates").Lookup("u").Document()
+		if u.Lookup("$set", "status").StringValue() != models.OutboxSent {
+			mt.Errorf("update = %v", u)
+		}
+		if _, err := u.LookupErr("$unset", "text"); err != nil {
+			mt.Error("code is kept after delivery")
+		}
+	})
+
+	runMock(t, "duplicate key", func(mt *mtest.T) {
+		existing := bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "key", Value: "verify:user@example.com"},
+			{Key: "status", Value: models.OutboxSent},
+		}
+		mt.AddMockResponses(
+			mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key"}),
+			found("test.email_outbox", existing),
+		)
+		mailer := &recordingMailer{}
+		outbox := NewOutboxService(mt.DB, mailer)
+
+		email, err := outbox.Enqueue(context.Background(), OutboxMessage{
+			Key:     "verify:user@example.com",
+			Message: mail.Message{To: []string{"user@example.com"}, Subject: "Код", Text: "123456"},
+		})
+		if err != nil {
+			mt.Fatal(err)
+		}
+		waitBackground(mt)
+		if email.Status != models.OutboxSent {
+			mt.Errorf("email = %+v", email)
+		}
+		// Повторный запрос не отправляет письмо второй раз
+		if len(mailer.sent) != 0 {
+			mt.Error("duplicate email was sent")
+		}
+	})
+}
+
+func TestOutboxSend(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name          string
+		email         models.OutboxEmail
+		mailErr       error
+		status        string
+		attempts      int
+		sent          bool
+		nextAttemptAt bool
+		// Удаляется ли тело письма
+		purged bool
+	}{
+		{"sent", models.OutboxEmail{Attempts: 2}, nil, models.OutboxSent, 3, true, false, true},
+		{"retry", models.OutboxEmail{}, errors.New("connection refused"), models.OutboxPending, 1, false, true, false},
+		// Тело остается, чтобы письмо можно было повторить
+		{"attempts exhausted", models.OutboxEmail{Attempts: outboxMaxAttempts - 1}, errors.New("connection refused"), models.OutboxDead, outboxMaxAttempts, false, false, false},
+		{"code attempts exhausted", models.OutboxEmail{Attempts: outboxMaxAttempts - 1, DeliverBefore: &future}, errors.New("connection refused"), models.OutboxDead, outboxMaxAttempts, false, false, true},
+		// Просроченный код не отправляется вовсе
+		{"deadline passed", models.OutboxEmail{Attempts: 1, DeliverBefore: &past}, nil, models.OutboxDead, 1, false, false, true},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(modified(1))
+			mailer := &recordingMailer{err: tt.mailErr}
+			outbox := NewOutboxService(mt.DB, mailer)
+
+			email := tt.email
+			email.ID = primitive.NewObjectID()
+			email.To = []string{"user@example.com"}
+			if err := outbox.send(context.Background(), &email); err != nil {
+				mt.Fatal(err)
+			}
+
+			if got := len(mailer.sent) == 1; got != tt.sent {
+				mt.Errorf("sent = %v, want %v", got, tt.sent)
+			}
+			update, _ := findCommand(sentCommands(mt), "update", "email_outbox")
+			u := statement(update, "updates").Lookup("u").Document()
+			if _, err := u.LookupErr("$unset", "text"); (err == nil) != tt.purged {
+				mt.Errorf("body removed = %v, want %v", err == nil, tt.purged)
+			}
+			set := u.Lookup("$set").Document()
+			if got := set.Lookup("status").StringValue(); got != tt.status {
+				mt.Errorf("status = %q, want %q", got, tt.status)
+			}
+			if got := set.Lookup("attempts").AsInt64(); got != int64(tt.attempts) {
+				mt.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+			if _, err := set.LookupErr("nextAttemptAt"); (err == nil) != tt.nextAttemptAt {
+				mt.Errorf("nextAttemptAt set = %v", err == nil)
+			}
+			if tt.status == models.OutboxDead && set.Lookup("lastError").StringValue() == "" {
+				mt.Error("dead email has no reason")
+			}
+		})
+	}
+}
+
+func TestOutboxProcessDue(t *testing.T) {
+	runMock(t, "drains due emails", func(mt *mtest.T) {
+		due := bson.D{{Key: "_id", Value: primitive.NewObjectID()}, {Key: "to", Value: bson.A{"user@example.com"}}, {Key: "text", Value: "hi"}}
+		var none interface{}
+		mt.AddMockResponses(
+			acknowledged(bson.E{Key: "value", Value: due}),
+			modified(1),
+			acknowledged(bson.E{Key: "value", Value: none}),
+		)
+		mailer := &recordingMailer{}
+		outbox := NewOutboxService(mt.DB, mailer)
+
+		n, err := outbox.ProcessDue(context.Background())
+		if err != nil || n != 1 || len(mailer.sent) != 1 {
+			mt.Fatalf("processed = %d, sent = %d, err = %v", n, len(mailer.sent), err)
+		}
+		claim, _ := findCommand(sentCommands(mt), "findAndModify", "email_outbox")
+		// Зависшие в sending письма забираются после истечения блокировки
+		or := claim.Lookup("query", "$or").Array()
+		if or.Index(1).Value().Document().Lookup("status").StringValue() != models.OutboxSending {
+			mt.Errorf("claim filter = %v", or)
+		}
+	})
+}
+
+func TestOutboxListFiltersByRecipient(t *testing.T) {
+	runMock(t, "case-insensitive", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.email_outbox", bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "to", Value: bson.A{"user@example.com"}},
+			{Key: "createdAt", Value: time.Now()},
+		}))
+		outbox := NewOutboxService(mt.DB, &recordingMailer{})
+
+		result, err := outbox.List(context.Background(), models.OutboxFilter{Status: models.OutboxDead, To: " User@Example.com"}, models.PageRequest{})
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if emails := result.Items.([]models.OutboxEmail); len(emails) != 1 {
+			mt.Errorf("items = %+v", result.Items)
+		}
+		find, _ := findCommand(sentCommands(mt), "find", "email_outbox")
+		filter := find.Lookup("filter").Document()
+		if filter.Lookup("to").StringValue() != "user@example.com" || filter.Lookup("status").StringValue() != models.OutboxDead {
+			mt.Errorf("filter = %v", filter)
+		}
+	})
+}
+
+func TestOutboxRetry(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name  string
+		email bson.D
+		want  error
+	}{
+		{"sent", bson.D{{Key: "status", Value: models.OutboxSent}}, ErrOutboxEmailSent},
+		{"expired", bson.D{{Key: "status", Value: models.OutboxDead}, {Key: "deliverBefore", Value: past}}, ErrOutboxEmailExpired},
+		// Тело письма с кодом удалено при переводе в dead
+		{"dead code", bson.D{{Key: "status", Value: models.OutboxDead}, {Key: "deliverBefore", Value: time.Now().Add(time.Minute)}}, ErrOutboxEmailExpired},
+		{"not found", nil, ErrOutboxEmailNotFound},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			if tt.email != nil {
+				mt.AddMockResponses(found("test.email_outbox", append(bson.D{{Key: "_id", Value: id}}, tt.email...)))
+			} else {
+				mt.AddMockResponses(found("test.email_outbox"))
+			}
+			outbox := NewOutboxService(mt.DB, &recordingMailer{})
+
+			if _, err := outbox.Retry(context.Background(), id.Hex()); !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if _, ok := findCommand(sentCommands(mt), "update", "email_outbox"); ok {
+				mt.Error("email was requeued")
+			}
+		})
+	}
+}
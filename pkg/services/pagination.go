@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+var (
+	ErrInvalidCursor    = apperr.Validation("invalid pagination cursor").WithCode("invalid_cursor")
+	ErrInvalidSortField = apperr.Validation("unsupported sort field").WithCode("invalid_sort_field")
+	ErrInvalidSortOrder = apperr.Validation("sort order must be 'asc' or 'desc'").WithCode("invalid_sort_order")
+	ErrInvalidMediaType = apperr.Validation("media type must be 'movie' or 'tv'").WithCode("invalid_media_type")
+)
+
+// pageCursor is the decoded form of an opaque cursor: the sort key and _id
+// of the last returned document.
+type pageCursor struct {
+	Value bson.RawValue `bson:"v"`
+	ID    bson.RawValue `bson:"id"`
+}
+
+// normalizePage validates page against the allowed sort fields and fills in
+// defaults. Dates sort newest first, everything else alphabetically.
+func normalizePage(page models.PageRequest, sortFields map[string]string) (models.PageRequest, error) {
+	if page.SortBy == "" {
+		page.SortBy = "createdAt"
+	}
+	if _, ok := sortFields[page.SortBy]; !ok {
+		return page, ErrInvalidSortField
+	}
+	switch page.Order {
+	case "":
+		page.Order = "asc"
+		if page.SortBy == "createdAt" {
+			page.Order = "desc"
+		}
+	case "asc", "desc":
+	default:
+		return page, ErrInvalidSortOrder
+	}
+	if page.MediaType != "" && page.MediaType != "movie" && page.MediaType != "tv" {
+		return page, ErrInvalidMediaType
+	}
+	if page.Limit <= 0 {
+		page.Limit = DefaultPageLimit
+	}
+	if page.Limit > MaxPageLimit {
+		page.Limit = MaxPageLimit
+	}
+	return page, nil
+}
+
+// findPage runs a keyset-paginated query over collection and returns the raw
+// documents of the requested page together with the filled-in envelope.
+// Items of the envelope are left for the caller to set.
+func findPage(ctx context.Context, collection *mongo.Collection, filter bson.M, page models.PageRequest, sortFields map[string]string) ([]bson.Raw, *models.PaginatedResponse, error) {
+	page, err := normalizePage(page, sortFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	field := sortFields[page.SortBy]
+
+	if page.MediaType != "" {
+		filter["mediaType"] = page.MediaType
+	}
+
+	direction, cmp := 1, "$gt"
+	if page.Order == "desc" {
+		direction, cmp = -1, "$lt"
+	}
+
+	if page.Cursor != "" {
+		after, err := decodePageCursor(page.Cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		filter["$or"] = bson.A{
+			bson.M{field: bson.M{cmp: after.Value}},
+			bson.M{field: after.Value, "_id": bson.M{cmp: after.ID}},
+		}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}).
+		SetLimit(int64(page.Limit + 1))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bson.Raw
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, nil, err
+	}
+
+	result := &models.PaginatedResponse{
+		Limit:  page.Limit,
+		SortBy: page.SortBy,
+		Order:  page.Order,
+	}
+	if len(docs) > page.Limit {
+		docs = docs[:page.Limit]
+		result.HasMore = true
+		result.NextCursor, err = encodePageCursor(docs[len(docs)-1], field)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return docs, result, nil
+}
+
+func encodePageCursor(doc bson.Raw, field string) (string, error) {
+	c := pageCursor{Value: doc.Lookup(field), ID: doc.Lookup("_id")}
+	if c.Value.Type == 0 {
+		c.Value = bson.RawValue{Type: bsontype.Null}
+	}
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodePageCursor(s string) (*pageCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c pageCursor
+	if err := bson.Unmarshal(data, &c); err != nil || c.ID.Type == 0 || c.Value.Type == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/models"
+)
+
+var testSortFields = map[string]string{
+	"createdAt": "createdAt",
+	"title":     "title",
+}
+
+func TestNormalizePage(t *testing.T) {
+	tests := []struct {
+		name string
+		page models.PageRequest
+		want models.PageRequest
+		err  error
+	}{
+		{"defaults", models.PageRequest{}, models.PageRequest{SortBy: "createdAt", Order: "desc", Limit: DefaultPageLimit}, nil},
+		{"text sorts ascending", models.PageRequest{SortBy: "title"}, models.PageRequest{SortBy: "title", Order: "asc", Limit: DefaultPageLimit}, nil},
+		{"limit capped", models.PageRequest{Limit: 1000}, models.PageRequest{SortBy: "createdAt", Order: "desc", Limit: MaxPageLimit}, nil},
+		{"unknown field", models.PageRequest{SortBy: "password"}, models.PageRequest{}, ErrInvalidSortField},
+		{"bad order", models.PageRequest{Order: "up"}, models.PageRequest{}, ErrInvalidSortOrder},
+		{"bad media type", models.PageRequest{MediaType: "book"}, models.PageRequest{}, ErrInvalidMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := normalizePage(tt.page, testSortFields)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindPageCursor(t *testing.T) {
+	runMock(t, "pages", func(mt *mtest.T) {
+		base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+		var docs []bson.D
+		var ids []primitive.ObjectID
+		for i := 0; i < 3; i++ {
+			id := primitive.NewObjectID()
+			ids = append(ids, id)
+			docs = append(docs, bson.D{{Key: "_id", Value: id}, {Key: "createdAt", Value: base.Add(-time.Duration(i) * time.Hour)}})
+		}
+		mt.AddMockResponses(found("test.favorites", docs...), found("test.favorites", docs[2]))
+		coll := mt.DB.Collection("favorites")
+
+		// Запрашивается на один документ больше, чтобы узнать о следующей странице
+		page, result, err := findPage(context.Background(), coll, bson.M{"userId": "user-1"}, models.PageRequest{Limit: 2, MediaType: "movie"}, testSortFields)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(page) != 2 || !result.HasMore || result.NextCursor == "" {
+			mt.Fatalf("got %d docs, result %+v", len(page), result)
+		}
+		first, _ := findCommand(sentCommands(mt), "find", "favorites")
+		if got := first.Lookup("limit").AsInt64(); got != 3 {
+			mt.Errorf("limit = %d, want 3", got)
+		}
+		if got := first.Lookup("filter", "mediaType").StringValue(); got != "movie" {
+			mt.Errorf("mediaType filter = %q", got)
+		}
+
+		cursor, err := decodePageCursor(result.NextCursor)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if cursor.ID.ObjectID() != ids[1] {
+			mt.Errorf("cursor points at %v, want the last returned document", cursor.ID)
+		}
+
+		mt.ClearEvents()
+		page, result, err = findPage(context.Background(), coll, bson.M{"userId": "user-1"}, models.PageRequest{Limit: 2, Cursor: result.NextCursor}, testSortFields)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(page) != 1 || result.HasMore || result.NextCursor != "" {
+			mt.Fatalf("got %d docs, result %+v", len(page), result)
+		}
+		second, _ := findCommand(sentCommands(mt), "find", "favorites")
+		or := second.Lookup("filter", "$or").Array()
+		// Сортировка по убыванию: следующая страница строго раньше курсора
+		if _, err := or.Index(0).Value().Document().LookupErr("createdAt", "$lt"); err != nil {
+			mt.Errorf("keyset condition = %v", or)
+		}
+		if got := or.Index(1).Value().Document().Lookup("_id", "$lt").ObjectID(); got != ids[1] {
+			mt.Errorf("tie-breaker _id = %v", got)
+		}
+	})
+}
+
+func TestDecodePageCursorRejectsGarbage(t *testing.T) {
+	for _, cursor := range []string{"!!!", "e30", "AAAA"} {
+		if _, err := decodePageCursor(cursor); !errors.Is(err, ErrInvalidCursor) {
+			t.Errorf("decodePageCursor(%q) err = %v", cursor, err)
+		}
+	}
+}
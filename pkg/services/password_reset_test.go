@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/crypto/bcrypt"
+
+	"neomovies-api/pkg/models"
+)
+
+func newTestAuthService(mt *mtest.T) *AuthService {
+	return NewAuthService(mt.DB, "secret", nil, NewSessionService(mt.DB, "secret"), NewAuditService(mt.DB), "http://api.test", "http://app.test", nil)
+}
+
+func TestRequestPasswordResetNormalizesEmail(t *testing.T) {
+	runMock(t, "mixed case", func(mt *mtest.T) {
+		userID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found("test.password_reset_requests"),
+			modified(1),
+			found("test.users", bson.D{{Key: "_id", Value: userID}, {Key: "email", Value: "user@example.com"}}),
+			modified(1),
+		)
+		auth := newTestAuthService(mt)
+
+		if err := auth.RequestPasswordReset(context.Background(), models.ForgotPasswordRequest{Email: " User@Example.COM "}); err != nil {
+			mt.Fatal(err)
+		}
+
+		commands := sentCommands(mt)
+		rate, ok := findCommand(commands, "find", "password_reset_requests")
+		if !ok {
+			mt.Fatal("rate limit was not checked")
+		}
+		if got := rate.Lookup("filter", "_id").StringValue(); got != "user@example.com" {
+			mt.Errorf("rate limit key = %q", got)
+		}
+		lookup, ok := findCommand(commands, "find", "users")
+		if !ok {
+			mt.Fatal("user was not looked up")
+		}
+		if got := lookup.Lookup("filter", "email").StringValue(); got != "user@example.com" {
+			mt.Errorf("user looked up by %q", got)
+		}
+
+		store, ok := findCommand(commands, "update", "users")
+		if !ok {
+			mt.Fatal("reset token was not stored")
+		}
+		set := statement(store, "updates").Lookup("u", "$set")
+		if token := set.Document().Lookup("resetPasswordToken").StringValue(); len(token) != 64 {
+			mt.Errorf("stored token %q is not a SHA-256 hash", token)
+		}
+	})
+}
+
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	runMock(t, "unknown", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.password_reset_requests"), modified(1), found("test.users"))
+		auth := newTestAuthService(mt)
+
+		// Ответ не выдает, зарегистрирован ли адрес
+		if err := auth.RequestPasswordReset(context.Background(), models.ForgotPasswordRequest{Email: "nobody@example.com"}); err != nil {
+			mt.Fatal(err)
+		}
+		if _, ok := findCommand(sentCommands(mt), "update", "users"); ok {
+			mt.Error("token stored for an unknown email")
+		}
+	})
+}
+
+func TestRequestPasswordResetRateLimit(t *testing.T) {
+	runMock(t, "limited", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.password_reset_requests", bson.D{
+			{Key: "_id", Value: "user@example.com"},
+			{Key: "count", Value: passwordResetMaxRequests},
+			{Key: "windowStart", Value: time.Now().Add(-time.Minute)},
+		}))
+		auth := newTestAuthService(mt)
+
+		err := auth.RequestPasswordReset(context.Background(), models.ForgotPasswordRequest{Email: "USER@example.com"})
+		if !errors.Is(err, ErrTooManyResetRequests) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestResetPasswordInvalidToken(t *testing.T) {
+	runMock(t, "invalid", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.users"))
+		auth := newTestAuthService(mt)
+
+		err := auth.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "nope", Password: "new-password"})
+		if !errors.Is(err, ErrInvalidResetToken) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestResetPasswordIsSingleUse(t *testing.T) {
+	runMock(t, "single use", func(mt *mtest.T) {
+		userID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found("test.users", bson.D{
+				{Key: "_id", Value: userID},
+				{Key: "email", Value: "user@example.com"},
+				{Key: "resetPasswordToken", Value: hashToken("token")},
+			}),
+			modified(1),
+			acknowledged(bson.E{Key: "n", Value: 1}),
+			acknowledged(bson.E{Key: "n", Value: 1}),
+			found("test.sessions"),
+		)
+		auth := newTestAuthService(mt)
+
+		if err := auth.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "token", Password: "new-password"}); err != nil {
+			mt.Fatal(err)
+		}
+
+		commands := sentCommands(mt)
+		lookup, _ := findCommand(commands, "find", "users")
+		if got := lookup.Lookup("filter", "resetPasswordToken").StringValue(); got != hashToken("token") {
+			mt.Errorf("token looked up as %q, want its hash", got)
+		}
+
+		update, ok := findCommand(commands, "update", "users")
+		if !ok {
+			mt.Fatal("password was not updated")
+		}
+		stmt := statement(update, "updates")
+		// Условие на токен делает сброс одноразовым
+		if got := stmt.Lookup("q", "resetPasswordToken").StringValue(); got != hashToken("token") {
+			mt.Errorf("update filter token = %q", got)
+		}
+		if _, err := stmt.LookupErr("u", "$unset", "resetPasswordToken"); err != nil {
+			mt.Error("reset token is not cleared")
+		}
+		hash := stmt.Lookup("u", "$set", "password").StringValue()
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte("new-password")) != nil {
+			mt.Error("stored password does not match")
+		}
+
+		if _, ok := findCommand(commands, "find", "sessions"); !ok {
+			mt.Error("sessions were not revoked")
+		}
+	})
+}
+
+func TestNormalizeStoredEmails(t *testing.T) {
+	runMock(t, "migration", func(mt *mtest.T) {
+		legacy := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found("test.users",
+				userDoc(legacy, bson.E{Key: "email", Value: " User@Example.com"}),
+				// Два написания одного адреса
+				userDoc(primitive.NewObjectID(), bson.E{Key: "email", Value: "Twin@example.com"}),
+				userDoc(primitive.NewObjectID(), bson.E{Key: "email", Value: "TWIN@example.com"}),
+				// Адрес уже занят аккаунтом, зарегистрированным после нормализации
+				userDoc(primitive.NewObjectID(), bson.E{Key: "email", Value: "Taken@example.com"}),
+			),
+			found("test.users", bson.D{{Key: "n", Value: 0}}),
+			modified(1),
+			found("test.users", bson.D{{Key: "n", Value: 0}}),
+			found("test.users", bson.D{{Key: "n", Value: 1}}),
+		)
+		auth := newTestAuthService(mt)
+
+		if err := auth.normalizeStoredEmails(context.Background()); err != nil {
+			mt.Fatal(err)
+		}
+
+		var updates []bson.Raw
+		for _, c := range sentCommands(mt) {
+			if c.name == "update" && c.collection == "users" {
+				updates = append(updates, statement(c.command, "updates"))
+			}
+		}
+		// Конфликтующие аккаунты не меняются
+		if len(updates) != 1 {
+			mt.Fatalf("updated %d users, want 1", len(updates))
+		}
+		q := updates[0].Lookup("q").Document()
+		if q.Lookup("_id").ObjectID() != legacy || q.Lookup("email").StringValue() != " User@Example.com" {
+			mt.Errorf("filter = %v", q)
+		}
+		if got := updates[0].Lookup("u", "$set", "email").StringValue(); got != "user@example.com" {
+			mt.Errorf("email = %q", got)
+		}
+	})
+
+	runMock(t, "nothing to migrate", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.users"))
+		auth := newTestAuthService(mt)
+
+		if err := auth.normalizeStoredEmails(context.Background()); err != nil {
+			mt.Fatal(err)
+		}
+		if len(sentCommands(mt)) != 1 {
+			mt.Error("normalized users were checked for collisions")
+		}
+	})
+}
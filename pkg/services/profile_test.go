@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+	"golang.org/x/crypto/bcrypt"
+
+	"neomovies-api/pkg/models"
+)
+
+func userDoc(id primitive.ObjectID, fields ...bson.E) bson.D {
+	return append(bson.D{
+		{Key: "_id", Value: id},
+		{Key: "email", Value: "user@example.com"},
+		{Key: "name", Value: "Old"},
+		{Key: "verified", Value: true},
+	}, fields...)
+}
+
+func passwordHash(t testing.TB, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestUpdateProfileSetsOnlyChangedFields(t *testing.T) {
+	runMock(t, "changed", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found("test.users", userDoc(id)),
+			modified(1),
+			acknowledged(),
+			found("test.users", userDoc(id, bson.E{Key: "name", Value: "New"})),
+		)
+		auth := newTestAuthService(mt)
+
+		name, avatar := "  New  ", ""
+		if _, err := auth.UpdateProfile(context.Background(), id.Hex(), models.UpdateProfileRequest{Name: &name, Avatar: &avatar}); err != nil {
+			mt.Fatal(err)
+		}
+
+		commands := sentCommands(mt)
+		update, _ := findCommand(commands, "update", "users")
+		set := statement(update, "updates").Lookup("u", "$set").Document()
+		if got := set.Lookup("name").StringValue(); got != "New" {
+			mt.Errorf("name = %q", got)
+		}
+		if _, err := set.LookupErr("avatar"); err == nil {
+			mt.Error("unchanged avatar is rewritten")
+		}
+		audit, ok := findCommand(commands, "insert", "audit_log")
+		if !ok {
+			mt.Fatal("change was not audited")
+		}
+		if got := statement(audit, "documents").Lookup("changes", "name", "to").StringValue(); got != "New" {
+			mt.Errorf("audited change = %q", got)
+		}
+	})
+
+	runMock(t, "unchanged", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(found("test.users", userDoc(id)))
+		auth := newTestAuthService(mt)
+
+		name := "Old"
+		if _, err := auth.UpdateProfile(context.Background(), id.Hex(), models.UpdateProfileRequest{Name: &name}); err != nil {
+			mt.Fatal(err)
+		}
+		if _, ok := findCommand(sentCommands(mt), "update", "users"); ok {
+			mt.Error("profile without changes was written")
+		}
+	})
+}
+
+func TestChangePassword(t *testing.T) {
+	runMock(t, "wrong current password", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(
+			found("test.users", userDoc(id, bson.E{Key: "password", Value: passwordHash(mt, "secret123")})),
+			found("test.auth_attempts"),
+			acknowledged(bson.E{Key: "value", Value: bson.D{{Key: "failures", Value: 1}}}),
+		)
+		auth := newTestAuthService(mt)
+
+		err := auth.ChangePassword(context.Background(), id.Hex(), "", models.ChangePasswordRequest{CurrentPassword: "guess1234", NewPassword: "newsecret1"})
+		if !errors.Is(err, ErrInvalidPassword) {
+			mt.Fatalf("err = %v", err)
+		}
+		commands := sentCommands(mt)
+		if _, ok := findCommand(commands, "findAndModify", "auth_attempts"); !ok {
+			mt.Error("failed attempt was not counted")
+		}
+		if _, ok := findCommand(commands, "update", "users"); ok {
+			mt.Error("password changed without the current one")
+		}
+	})
+
+	runMock(t, "success", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		keep := primitive.NewObjectID()
+		old := passwordHash(mt, "secret123")
+		mt.AddMockResponses(
+			found("test.users", userDoc(id, bson.E{Key: "password", Value: old})),
+			found("test.auth_attempts"),
+			acknowledged(bson.E{Key: "n", Value: 0}),
+			modified(1),
+			acknowledged(),
+			found("test.sessions"),
+		)
+		auth := newTestAuthService(mt)
+
+		err := auth.ChangePassword(context.Background(), id.Hex(), keep.Hex(), models.ChangePasswordRequest{CurrentPassword: "secret123", NewPassword: "newsecret1"})
+		if err != nil {
+			mt.Fatal(err)
+		}
+
+		commands := sentCommands(mt)
+		update, _ := findCommand(commands, "update", "users")
+		u := statement(update, "updates").Lookup("u").Document()
+		hash := u.Lookup("$set", "password").StringValue()
+		if hash == old || bcrypt.CompareHashAndPassword([]byte(hash), []byte("newsecret1")) != nil {
+			mt.Error("new password is not stored")
+		}
+		if _, err := u.LookupErr("$unset", "resetPasswordToken"); err != nil {
+			mt.Error("pending reset link is kept")
+		}
+		// Текущая сессия остается, остальные завершаются
+		sessions, ok := findCommand(commands, "find", "sessions")
+		if !ok {
+			mt.Fatal("other sessions were not revoked")
+		}
+		if got := sessions.Lookup("filter", "_id", "$ne").ObjectID(); got != keep {
+			mt.Errorf("kept session = %v, want %v", got, keep)
+		}
+	})
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		newEmail string
+		taken    bool
+		want     error
+	}{
+		{"same address", "USER@example.com", false, ErrEmailUnchanged},
+		{"taken", "other@example.com", true, ErrEmailTaken},
+		{"ok", " New@Example.com ", false, nil},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			taken := 0
+			if tt.taken {
+				taken = 1
+			}
+			mt.AddMockResponses(
+				found("test.users", userDoc(id, bson.E{Key: "password", Value: passwordHash(mt, "secret123")})),
+				found("test.auth_attempts"),
+				acknowledged(bson.E{Key: "n", Value: 0}),
+				found("test.users", bson.D{{Key: "n", Value: taken}}),
+				modified(1),
+				acknowledged(),
+			)
+			auth := newTestAuthService(mt)
+
+			err := auth.RequestEmailChange(context.Background(), id.Hex(), models.ChangeEmailRequest{NewEmail: tt.newEmail, Password: "secret123"})
+			if !errors.Is(err, tt.want) {
+				mt.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil {
+				return
+			}
+			// До подтверждения адрес только ожидает смены
+			update, _ := findCommand(sentCommands(mt), "update", "users")
+			set := statement(update, "updates").Lookup("u", "$set").Document()
+			if got := set.Lookup("pendingEmail").StringValue(); got != "new@example.com" {
+				mt.Errorf("pendingEmail = %q", got)
+			}
+			if _, err := set.LookupErr("email"); err == nil {
+				mt.Error("email switched before confirmation")
+			}
+		})
+	}
+}
+
+func TestConfirmEmailChangeBurnsCode(t *testing.T) {
+	tests := []struct {
+		name string
+		// Счетчик, с которым сверялся код, и счетчик на момент записи
+		read, current int
+		// Сколько обновлений ушло в базу
+		updates int
+	}{
+		{"last attempt", maxCodeAttempts - 1, maxCodeAttempts - 1, 1},
+		// Параллельная неверная попытка успела посчитаться: эта становится
+		// последней и тоже уничтожает код
+		{"concurrent attempt", maxCodeAttempts - 2, maxCodeAttempts - 1, 2},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			id := primitive.NewObjectID()
+			pending := func(attempts int) bson.D {
+				return userDoc(id,
+					bson.E{Key: "pendingEmail", Value: "new@example.com"},
+					bson.E{Key: "pendingEmailCode", Value: hashToken("123456")},
+					bson.E{Key: "pendingEmailExpires", Value: time.Now().Add(time.Hour)},
+					bson.E{Key: "pendingEmailAttempts", Value: attempts},
+				)
+			}
+			responses := []bson.D{found("test.users", pending(tt.read))}
+			if tt.updates > 1 {
+				responses = append(responses, modified(0), found("test.users", pending(tt.current)))
+			}
+			mt.AddMockResponses(append(responses, modified(1))...)
+			auth := newTestAuthService(mt)
+
+			_, err := auth.ConfirmEmailChange(context.Background(), id.Hex(), models.ConfirmEmailChangeRequest{Code: "654321"})
+			if !errors.Is(err, ErrInvalidVerificationCode) {
+				mt.Fatalf("err = %v", err)
+			}
+
+			var updates []bson.Raw
+			for _, cmd := range sentCommands(mt) {
+				if cmd.name == "update" && cmd.collection == "users" {
+					updates = append(updates, statement(cmd.command, "updates"))
+				}
+			}
+			if len(updates) != tt.updates {
+				mt.Fatalf("updates = %d, want %d", len(updates), tt.updates)
+			}
+			last := updates[len(updates)-1]
+			if got := last.Lookup("q", "pendingEmailAttempts").AsInt64(); got != int64(tt.current) {
+				mt.Errorf("update is guarded by attempts = %d, want %d", got, tt.current)
+			}
+			// Последняя неверная попытка уничтожает код в том же обновлении
+			if _, err := last.Lookup("u", "$unset").Document().LookupErr("pendingEmailCode"); err != nil {
+				mt.Error("code survived the last attempt")
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -12,7 +13,10 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/background"
 	"neomovies-api/pkg/config"
+	"neomovies-api/pkg/metrics"
 	"neomovies-api/pkg/models"
 )
 
@@ -24,7 +28,7 @@ type ReactionsService struct {
 func NewReactionsService(db *mongo.Database) *ReactionsService {
 	return &ReactionsService{
 		db:     db,
-		client: &http.Client{},
+		client: metrics.NewClient("cubrip", 0),
 	}
 }
 
@@ -100,7 +104,7 @@ func (s *ReactionsService) GetMyReaction(userID, mediaType, mediaID string) (str
 
 func (s *ReactionsService) SetReaction(userID, mediaType, mediaID, reactionType string) error {
 	if !s.isValidReactionType(reactionType) {
-		return fmt.Errorf("invalid reaction type")
+		return apperr.Validation("invalid reaction type").WithCode("invalid_reaction_type")
 	}
 
 	collection := s.db.Collection("reactions")
@@ -109,11 +113,15 @@ func (s *ReactionsService) SetReaction(userID, mediaType, mediaID, reactionType
 	_, err := collection.UpdateOne(
 		ctx,
 		bson.M{"userId": userID, "mediaType": mediaType, "mediaId": mediaID},
-		bson.M{"$set": bson.M{"type": reactionType, "updatedAt": time.Now()}},
+		bson.M{
+			"$set":         bson.M{"type": reactionType, "updatedAt": time.Now()},
+			"$setOnInsert": bson.M{"createdAt": time.Now()},
+		},
 		options.Update().SetUpsert(true),
 	)
 	if err == nil {
-		go s.sendReactionToCub(fmt.Sprintf("%s_%s", mediaType, mediaID), reactionType)
+		cubID := fmt.Sprintf("%s_%s", mediaType, mediaID)
+		background.Go("cub.rip reaction", func() { s.sendReactionToCub(cubID, reactionType) })
 	}
 	return err
 }
@@ -129,28 +137,56 @@ func (s *ReactionsService) RemoveReaction(userID, mediaType, mediaID string) err
 	})
 
 	fullMediaID := fmt.Sprintf("%s_%s", mediaType, mediaID)
-	go s.sendReactionToCub(fullMediaID, "remove")
+	background.Go("cub.rip reaction", func() { s.sendReactionToCub(fullMediaID, "remove") })
 
 	return err
 }
 
-// Получить все реакции пользователя
-func (s *ReactionsService) GetUserReactions(userID string, limit int) ([]models.Reaction, error) {
+// reactionsSortFields maps sort parameters of GetUserReactions to document fields.
+var reactionsSortFields = map[string]string{
+	"createdAt": "createdAt",
+}
+
+// EnsureIndexes creates indexes backing reaction lookups and the paginated
+// user reactions query. Reactions stored before createdAt was tracked get it
+// backfilled from updatedAt so that they take part in sorting.
+func (s *ReactionsService) EnsureIndexes(ctx context.Context) error {
 	collection := s.db.Collection("reactions")
 
-	ctx := context.Background()
-	cursor, err := collection.Find(ctx, bson.M{"userId": userID})
+	_, err := collection.UpdateMany(ctx,
+		bson.M{"createdAt": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"createdAt": bson.M{"$ifNull": bson.A{"$updatedAt", "$$NOW"}}}}}},
+	)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer cursor.Close(ctx)
 
-	var reactions []models.Reaction
-	if err := cursor.All(ctx, &reactions); err != nil {
+	_, err = collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "mediaType", Value: 1}, {Key: "mediaId", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "mediaType", Value: 1}, {Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}},
+	})
+	return err
+}
+
+// Получить реакции пользователя постранично
+func (s *ReactionsService) GetUserReactions(ctx context.Context, userID string, page models.PageRequest) (*models.PaginatedResponse, error) {
+	docs, result, err := findPage(ctx, s.db.Collection("reactions"), bson.M{"userId": userID}, page, reactionsSortFields)
+	if err != nil {
 		return nil, err
 	}
 
-	return reactions, nil
+	reactions := make([]models.Reaction, 0, len(docs))
+	for _, doc := range docs {
+		var reaction models.Reaction
+		if err := bson.Unmarshal(doc, &reaction); err != nil {
+			return nil, err
+		}
+		reactions = append(reactions, reaction)
+	}
+
+	result.Items = reactions
+	return result, nil
 }
 
 func (s *ReactionsService) isValidReactionType(reactionType string) bool {
@@ -183,6 +219,6 @@ func (s *ReactionsService) sendReactionToCub(mediaID, reactionType string) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusOK {
-		fmt.Printf("Reaction sent to cub.rip: %s - %s\n", mediaID, reactionType)
+		slog.Debug("reaction sent to cub.rip", "media_id", mediaID, "type", reactionType)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,298 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+var ErrInvalidRefreshToken = apperr.Unauthorized("invalid or expired refresh token").WithCode("invalid_refresh_token")
+
+// SessionService issues access/refresh token pairs, rotates refresh tokens
+// and keeps the deny list of revoked access tokens.
+type SessionService struct {
+	db        *mongo.Database
+	jwtSecret string
+}
+
+func NewSessionService(db *mongo.Database, jwtSecret string) *SessionService {
+	return &SessionService{
+		db:        db,
+		jwtSecret: jwtSecret,
+	}
+}
+
+// EnsureIndexes creates indexes for the sessions and revoked_tokens collections.
+func (s *SessionService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("sessions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "refreshTokenHash", Value: 1}}},
+		{Keys: bson.D{{Key: "previousTokenHash", Value: 1}}},
+		{Keys: bson.D{{Key: "userId", Value: 1}}},
+		{Keys: bson.D{{Key: "expiresAt", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection("revoked_tokens").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// CreateSession starts a new session for the user and returns its first token pair.
+func (s *SessionService) CreateSession(ctx context.Context, userID string) (*models.TokenPair, error) {
+	refreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID := primitive.NewObjectID()
+	accessToken, jti, err := s.signAccessToken(userID, sessionID.Hex())
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:               sessionID,
+		UserID:           userID,
+		RefreshTokenHash: hashToken(refreshToken),
+		AccessTokenID:    jti,
+		CreatedAt:        now,
+		LastUsedAt:       now,
+		ExpiresAt:        now.Add(RefreshTokenTTL),
+	}
+
+	if _, err := s.db.Collection("sessions").InsertOne(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(AccessTokenTTL.Seconds()),
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new pair, rotating the refresh token.
+// Presenting an already rotated token revokes the whole session, since it means
+// the token was copied.
+func (s *SessionService) Refresh(ctx context.Context, refreshToken string) (*models.TokenPair, string, error) {
+	collection := s.db.Collection("sessions")
+	tokenHash := hashToken(refreshToken)
+	now := time.Now()
+
+	var session models.Session
+	err := collection.FindOne(ctx, bson.M{"refreshTokenHash": tokenHash}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		var reused models.Session
+		if collection.FindOne(ctx, bson.M{"previousTokenHash": tokenHash}).Decode(&reused) == nil {
+			_ = s.revokeSession(ctx, reused)
+		}
+		return nil, "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	newRefreshToken, err := generateRefreshToken()
+	if err != nil {
+		return nil, "", err
+	}
+
+	accessToken, jti, err := s.signAccessToken(session.UserID, session.ID.Hex())
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Условие на старый хэш защищает от параллельного использования одного токена
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": session.ID, "refreshTokenHash": tokenHash},
+		bson.M{"$set": bson.M{
+			"refreshTokenHash":  hashToken(newRefreshToken),
+			"previousTokenHash": tokenHash,
+			"accessTokenId":     jti,
+			"lastUsedAt":        now,
+		}},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	if result.ModifiedCount == 0 {
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	// Прежний access-токен выдан вместе с прошлой ротацией и больше не нужен:
+	// иначе после отзыва сессии по повторному refresh он жил бы до истечения
+	if session.AccessTokenID != "" {
+		if expiresAt := session.LastUsedAt.Add(AccessTokenTTL); expiresAt.After(now) {
+			if err := s.denyToken(ctx, session.UserID, session.AccessTokenID, expiresAt); err != nil {
+				return nil, "", err
+			}
+		}
+	}
+
+	return &models.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(AccessTokenTTL.Seconds()),
+	}, session.UserID, nil
+}
+
+// Revoke ends a single session and denies the access token that was used.
+func (s *SessionService) Revoke(ctx context.Context, userID, sessionID, jti string) error {
+	if jti != "" {
+		// Токен не может прожить дольше AccessTokenTTL с момента отзыва
+		if err := s.denyToken(ctx, userID, jti, time.Now().Add(AccessTokenTTL)); err != nil {
+			return err
+		}
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(sessionID)
+	if err != nil {
+		return nil
+	}
+
+	var session models.Session
+	err = s.db.Collection("sessions").FindOne(ctx, bson.M{"_id": objectID, "userId": userID}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.revokeSession(ctx, session)
+}
+
+// RevokeAllForUser logs the user out of every device.
+func (s *SessionService) RevokeAllForUser(ctx context.Context, userID string) error {
+	return s.RevokeOthers(ctx, userID, "")
+}
+
+// RevokeOthers logs the user out of every device except the session
+// keepSessionID, e.g. after a password change made from that session.
+func (s *SessionService) RevokeOthers(ctx context.Context, userID, keepSessionID string) error {
+	filter := bson.M{
+		"userId":    userID,
+		"revokedAt": bson.M{"$exists": false},
+	}
+	if keep, err := primitive.ObjectIDFromHex(keepSessionID); err == nil {
+		filter["_id"] = bson.M{"$ne": keep}
+	}
+
+	cursor, err := s.db.Collection("sessions").Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []models.Session
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := s.revokeSession(ctx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAllForUser removes every session of the user, e.g. on account deletion.
+func (s *SessionService) DeleteAllForUser(ctx context.Context, userID string) error {
+	if err := s.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	_, err := s.db.Collection("sessions").DeleteMany(ctx, bson.M{"userId": userID})
+	return err
+}
+
+// IsTokenRevoked reports whether the access token jti is on the deny list.
+func (s *SessionService) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	count, err := s.db.Collection("revoked_tokens").CountDocuments(ctx, bson.M{"_id": jti}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *SessionService) revokeSession(ctx context.Context, session models.Session) error {
+	if session.AccessTokenID != "" {
+		if err := s.denyToken(ctx, session.UserID, session.AccessTokenID, time.Now().Add(AccessTokenTTL)); err != nil {
+			return err
+		}
+	}
+
+	_, err := s.db.Collection("sessions").UpdateOne(ctx,
+		bson.M{"_id": session.ID},
+		bson.M{"$set": bson.M{"revokedAt": time.Now()}},
+	)
+	return err
+}
+
+// denyToken puts the access token jti on the deny list until expiresAt, when
+// the token would have expired anyway.
+func (s *SessionService) denyToken(ctx context.Context, userID, jti string, expiresAt time.Time) error {
+	_, err := s.db.Collection("revoked_tokens").UpdateOne(ctx,
+		bson.M{"_id": jti},
+		bson.M{"$set": bson.M{
+			"userId":    userID,
+			"expiresAt": expiresAt,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (s *SessionService) signAccessToken(userID, sessionID string) (string, string, error) {
+	jti := uuid.New().String()
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"sid":     sessionID,
+		"exp":     now.Add(AccessTokenTTL).Unix(),
+		"iat":     now.Unix(),
+		"jti":     jti,
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(s.jwtSecret))
+	return signed, jti, err
+}
+
+func generateRefreshToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func sessionDoc(lastUsedAt time.Time, revoked bool) bson.D {
+	doc := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "userId", Value: "user-1"},
+		{Key: "refreshTokenHash", Value: hashToken("refresh")},
+		{Key: "accessTokenId", Value: "old-jti"},
+		{Key: "createdAt", Value: lastUsedAt},
+		{Key: "lastUsedAt", Value: lastUsedAt},
+		{Key: "expiresAt", Value: time.Now().Add(RefreshTokenTTL)},
+	}
+	if revoked {
+		doc = append(doc, bson.E{Key: "revokedAt", Value: time.Now()})
+	}
+	return doc
+}
+
+func TestRefreshDeniesPreviousAccessToken(t *testing.T) {
+	runMock(t, "rotation", func(mt *mtest.T) {
+		lastUsedAt := time.Now().Add(-5 * time.Minute).Truncate(time.Millisecond)
+		mt.AddMockResponses(
+			found("test.sessions", sessionDoc(lastUsedAt, false)),
+			modified(1),
+			modified(1),
+		)
+		sessions := NewSessionService(mt.DB, "secret")
+
+		pair, userID, err := sessions.Refresh(context.Background(), "refresh")
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if userID != "user-1" || pair.RefreshToken == "refresh" {
+			mt.Fatalf("userID = %q, refresh token not rotated", userID)
+		}
+
+		deny, ok := findCommand(sentCommands(mt), "update", "revoked_tokens")
+		if !ok {
+			mt.Fatal("previous access token was not denied")
+		}
+		update := statement(deny, "updates")
+		if got := update.Lookup("q", "_id").StringValue(); got != "old-jti" {
+			mt.Errorf("denied jti = %q", got)
+		}
+		// Запись живет ровно до истечения старого токена
+		expiresAt := update.Lookup("u", "$set", "expiresAt").Time()
+		if want := lastUsedAt.Add(AccessTokenTTL); !expiresAt.Equal(want) {
+			mt.Errorf("expiresAt = %v, want %v", expiresAt, want)
+		}
+	})
+}
+
+func TestRefreshSkipsExpiredAccessToken(t *testing.T) {
+	runMock(t, "expired", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			found("test.sessions", sessionDoc(time.Now().Add(-AccessTokenTTL-time.Minute), false)),
+			modified(1),
+		)
+		sessions := NewSessionService(mt.DB, "secret")
+
+		if _, _, err := sessions.Refresh(context.Background(), "refresh"); err != nil {
+			mt.Fatal(err)
+		}
+		if _, ok := findCommand(sentCommands(mt), "update", "revoked_tokens"); ok {
+			mt.Error("expired access token should not be put on the deny list")
+		}
+	})
+}
+
+func TestRefreshReuseRevokesSession(t *testing.T) {
+	runMock(t, "reuse", func(mt *mtest.T) {
+		mt.AddMockResponses(
+			found("test.sessions"),
+			found("test.sessions", sessionDoc(time.Now(), false)),
+			modified(1),
+			modified(1),
+		)
+		sessions := NewSessionService(mt.DB, "secret")
+
+		_, _, err := sessions.Refresh(context.Background(), "refresh")
+		if !errors.Is(err, ErrInvalidRefreshToken) {
+			mt.Fatalf("err = %v", err)
+		}
+
+		commands := sentCommands(mt)
+		if _, ok := findCommand(commands, "update", "revoked_tokens"); !ok {
+			mt.Error("access token of the reused session was not denied")
+		}
+		revoke, ok := findCommand(commands, "update", "sessions")
+		if !ok {
+			mt.Fatal("session was not revoked")
+		}
+		update := statement(revoke, "updates")
+		if _, err := update.LookupErr("u", "$set", "revokedAt"); err != nil {
+			mt.Errorf("update does not set revokedAt: %v", update)
+		}
+	})
+}
+
+func TestRefreshRejectsRevokedSession(t *testing.T) {
+	runMock(t, "revoked", func(mt *mtest.T) {
+		mt.AddMockResponses(found("test.sessions", sessionDoc(time.Now(), true)))
+		sessions := NewSessionService(mt.DB, "secret")
+
+		if _, _, err := sessions.Refresh(context.Background(), "refresh"); !errors.Is(err, ErrInvalidRefreshToken) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestRefreshLosesRace(t *testing.T) {
+	runMock(t, "race", func(mt *mtest.T) {
+		// Параллельный запрос уже сменил хэш
+		mt.AddMockResponses(found("test.sessions", sessionDoc(time.Now(), false)), modified(0))
+		sessions := NewSessionService(mt.DB, "secret")
+
+		if _, _, err := sessions.Refresh(context.Background(), "refresh"); !errors.Is(err, ErrInvalidRefreshToken) {
+			mt.Fatalf("err = %v", err)
+		}
+	})
+}
+
+func TestSignAccessTokenClaims(t *testing.T) {
+	sessions := NewSessionService(nil, "secret")
+
+	signed, jti, err := sessions.signAccessToken("user-1", "session-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return []byte("secret"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["user_id"] != "user-1" || claims["sid"] != "session-1" || claims["jti"] != jti || jti == "" {
+		t.Fatalf("claims = %v", claims)
+	}
+	exp, _ := claims.GetExpirationTime()
+	if ttl := time.Until(exp.Time); ttl > AccessTokenTTL || ttl < AccessTokenTTL-time.Minute {
+		t.Errorf("token lives %v", ttl)
+	}
+}
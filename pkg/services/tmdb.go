@@ -1,50 +1,192 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/cache"
+	"neomovies-api/pkg/metrics"
 	"neomovies-api/pkg/models"
 )
 
+const DefaultTMDBBaseURL = "https://api.themoviedb.org/3"
+
 type TMDBService struct {
 	accessToken string
 	baseURL     string
 	client      *http.Client
+	cache       cache.Cache
 }
 
 func NewTMDBService(accessToken string) *TMDBService {
+	return NewTMDBServiceWithConfig(accessToken, DefaultTMDBBaseURL, nil)
+}
+
+// NewTMDBServiceWithConfig allows overriding the TMDB base URL (e.g. a local
+// fake server) and plugging a response cache. A nil cache disables caching.
+func NewTMDBServiceWithConfig(accessToken, baseURL string, responseCache cache.Cache) *TMDBService {
+	if baseURL == "" {
+		baseURL = DefaultTMDBBaseURL
+	}
+	if responseCache == nil {
+		responseCache = cache.NewNoop()
+	}
 	return &TMDBService{
 		accessToken: accessToken,
-		baseURL:     "https://api.themoviedb.org/3",
-		client:      &http.Client{},
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		client:      metrics.NewClient("tmdb", 10*time.Second),
+		cache:       responseCache,
+	}
+}
+
+// CacheStats возвращает статистику попаданий в кэш ответов TMDB
+func (s *TMDBService) CacheStats() cache.Stats {
+	return s.cache.Stats()
+}
+
+// PurgeCache очищает кэш ответов TMDB
+func (s *TMDBService) PurgeCache(ctx context.Context) error {
+	return s.cache.Clear(ctx)
+}
+
+// Ping проверяет доступность TMDB и валидность токена доступа
+func (s *TMDBService) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL+"/configuration", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return fmt.Errorf("TMDB rejected the access token")
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// tmdbCacheRule задает TTL для семейства эндпоинтов TMDB
+type tmdbCacheRule struct {
+	match func(path string) bool
+	ttl   time.Duration
+}
+
+var tmdbCacheRules = []tmdbCacheRule{
+	// Жанры меняются крайне редко
+	{match: func(p string) bool { return strings.HasPrefix(p, "/genre/") }, ttl: 24 * time.Hour},
+	// Поиск — короткий TTL, запросов много и они разнообразны
+	{match: func(p string) bool { return strings.HasPrefix(p, "/search/") }, ttl: 5 * time.Minute},
+	// Подборки и списки обновляются в течение дня
+	{match: func(p string) bool { return strings.HasPrefix(p, "/discover/") }, ttl: 15 * time.Minute},
+	{match: func(p string) bool {
+		for _, suffix := range []string{"/popular", "/top_rated", "/upcoming", "/now_playing", "/on_the_air", "/airing_today"} {
+			if strings.HasSuffix(p, suffix) {
+				return true
+			}
+		}
+		return false
+	}, ttl: 10 * time.Minute},
+	// Детали фильмов, сериалов, сезонов, рекомендации и внешние ID
+	{match: func(p string) bool { return strings.HasPrefix(p, "/movie/") || strings.HasPrefix(p, "/tv/") }, ttl: 6 * time.Hour},
+}
+
+// cacheTTL возвращает TTL для эндпоинта; 0 означает, что ответ не кэшируется
+func (s *TMDBService) cacheTTL(endpoint string) time.Duration {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return 0
+	}
+	path := u.Path
+	if base, err := url.Parse(s.baseURL); err == nil {
+		path = strings.TrimPrefix(path, base.Path)
 	}
+	for _, rule := range tmdbCacheRules {
+		if rule.match(path) {
+			return rule.ttl
+		}
+	}
+	return 0
 }
 
+// tmdbCacheTimeout ограничивает обращение к кэшу ответов: зависший кэш не
+// должен задерживать запрос дольше, чем поход в сам TMDB
+const tmdbCacheTimeout = 300 * time.Millisecond
+
 func (s *TMDBService) makeRequest(endpoint string, target interface{}) error {
-	req, err := http.NewRequest("GET", endpoint, nil)
+	ttl := s.cacheTTL(endpoint)
+
+	if ttl > 0 {
+		// Не ответивший вовремя кэш считается промахом
+		ctx, cancel := context.WithTimeout(context.Background(), tmdbCacheTimeout)
+		body, ok := s.cache.Get(ctx, endpoint)
+		cancel()
+		if ok {
+			return json.Unmarshal(body, target)
+		}
+	}
+
+	body, err := s.fetch(endpoint)
 	if err != nil {
 		return err
 	}
 
+	if err := json.Unmarshal(body, target); err != nil {
+		return apperr.Upstream("tmdb", err)
+	}
+
+	if ttl > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), tmdbCacheTimeout)
+		_ = s.cache.Set(ctx, endpoint, body, ttl)
+		cancel()
+	}
+	return nil
+}
+
+func (s *TMDBService) fetch(endpoint string) ([]byte, error) {
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	// Используем Bearer токен вместо API key в query параметрах
 	req.Header.Set("Authorization", "Bearer "+s.accessToken)
 	req.Header.Set("Accept", "application/json")
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return err
+		return nil, apperr.Upstream("tmdb", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("TMDB API error: %d", resp.StatusCode)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		// Отсутствующий в TMDB ресурс — это 404 и для клиентов API
+		return nil, apperr.NotFound("Resource not found in TMDB").WithCode("tmdb_not_found")
+	case resp.StatusCode != http.StatusOK:
+		return nil, apperr.Upstream("tmdb", fmt.Errorf("TMDB API error: %d", resp.StatusCode))
 	}
 
-	return json.NewDecoder(resp.Body).Decode(target)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, apperr.Upstream("tmdb", err)
+	}
+	return body, nil
 }
 
 func (s *TMDBService) SearchMovies(query string, page int, language, region string, year int) (*models.TMDBResponse, error) {
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/cache"
+)
+
+// recordingCache запоминает TTL, с которым сохранялся каждый ответ
+type recordingCache struct {
+	*cache.Memory
+	ttls map[string]time.Duration
+}
+
+func newRecordingCache() *recordingCache {
+	return &recordingCache{Memory: cache.NewMemory(100), ttls: make(map[string]time.Duration)}
+}
+
+func (c *recordingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.ttls[key] = ttl
+	return c.Memory.Set(ctx, key, value, ttl)
+}
+
+// hangingCache не отвечает, пока не истечет контекст запроса
+type hangingCache struct {
+	*cache.Memory
+}
+
+func (c hangingCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	<-ctx.Done()
+	return nil, false
+}
+
+func (c hangingCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+// newTMDBStub поднимает фейковый TMDB под префиксом /3 и считает запросы
+func newTMDBStub(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if got := r.Header.Get("Authorization"); got != "Bearer token" {
+			t.Errorf("Authorization = %q", got)
+		}
+		r.URL.Path = strings.TrimPrefix(r.URL.Path, "/3")
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestTMDBRepeatCallIsCacheHit(t *testing.T) {
+	srv, calls := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":550,"title":"Fight Club"}`))
+	})
+	responseCache := cache.NewMemory(100)
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", responseCache)
+
+	for i := 0; i < 3; i++ {
+		movie, err := tmdb.GetMovie(550, "en-US")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if movie.Title != "Fight Club" {
+			t.Fatalf("title = %q", movie.Title)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("upstream calls = %d, want 1", got)
+	}
+	stats := tmdb.CacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	// Другой язык — другой ключ
+	if _, err := tmdb.GetMovie(550, "ru-RU"); err != nil {
+		t.Fatal(err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("upstream calls = %d, want 2", got)
+	}
+}
+
+func TestTMDBCacheTTLPerEndpoint(t *testing.T) {
+	srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	})
+	recorder := newRecordingCache()
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", recorder)
+
+	tests := []struct {
+		name string
+		call func() error
+		path string
+		ttl  time.Duration
+	}{
+		{"genres", func() error { _, err := tmdb.GetGenres("movie", ""); return err }, "/genre/movie/list", 24 * time.Hour},
+		{"search", func() error { _, err := tmdb.SearchMovies("matrix", 1, "", "", 0); return err }, "/search/movie", 5 * time.Minute},
+		{"discover", func() error { _, err := tmdb.DiscoverMoviesByGenre(28, 1, ""); return err }, "/discover/movie", 15 * time.Minute},
+		// Подборки проверяются раньше общего правила для /movie/
+		{"popular", func() error { _, err := tmdb.GetPopularMovies(1, "", ""); return err }, "/movie/popular", 10 * time.Minute},
+		{"details", func() error { _, err := tmdb.GetMovie(550, ""); return err }, "/movie/550", 6 * time.Hour},
+		{"tv details", func() error { _, err := tmdb.GetTVShow(1399, ""); return err }, "/tv/1399", 6 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for key, ttl := range recorder.ttls {
+				if strings.HasPrefix(key, srv.URL+"/3"+tt.path+"?") {
+					found = true
+					if ttl != tt.ttl {
+						t.Errorf("ttl = %v, want %v", ttl, tt.ttl)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("response for %s was not cached", tt.path)
+			}
+		})
+	}
+}
+
+func TestTMDBUncachedEndpoint(t *testing.T) {
+	tmdb := NewTMDBServiceWithConfig("token", "http://tmdb.test/3", nil)
+	if ttl := tmdb.cacheTTL("http://tmdb.test/3/configuration"); ttl != 0 {
+		t.Errorf("ttl = %v, want 0", ttl)
+	}
+}
+
+func TestTMDBNotFound(t *testing.T) {
+	srv, calls := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"status_code":34}`))
+	})
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", cache.NewMemory(100))
+
+	for i := 0; i < 2; i++ {
+		_, err := tmdb.GetMovie(1, "")
+		var appErr *apperr.Error
+		if !errors.As(err, &appErr) {
+			t.Fatalf("err = %v, want *apperr.Error", err)
+		}
+		if appErr.Status != http.StatusNotFound || appErr.Code != "tmdb_not_found" {
+			t.Fatalf("got %d %s", appErr.Status, appErr.Code)
+		}
+	}
+	// Ошибки не кэшируются
+	if got := calls.Load(); got != 2 {
+		t.Errorf("upstream calls = %d, want 2", got)
+	}
+}
+
+func TestTMDBUpstreamError(t *testing.T) {
+	srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", nil)
+
+	_, err := tmdb.GetMovie(1, "")
+	if got := apperr.From(err); got.Status != http.StatusBadGateway || got.Code != apperr.CodeUpstream {
+		t.Fatalf("got %d %s", got.Status, got.Code)
+	}
+}
+
+func TestTMDBHangingCacheFallsBackToTMDB(t *testing.T) {
+	srv, calls := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":550,"title":"Fight Club"}`))
+	})
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", hangingCache{cache.NewMemory(100)})
+
+	done := make(chan error, 1)
+	go func() {
+		movie, err := tmdb.GetMovie(550, "en-US")
+		if err == nil && movie.Title != "Fight Club" {
+			err = fmt.Errorf("title = %q", movie.Title)
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("request hangs on the cache")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("TMDB calls = %d, want 1", calls.Load())
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,6 +13,8 @@ import (
 	"strings"
 	"time"
 
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/metrics"
 	"neomovies-api/pkg/models"
 )
 
@@ -23,7 +26,7 @@ type TorrentService struct {
 
 func NewTorrentServiceWithConfig(baseURL, apiKey string) *TorrentService {
 	return &TorrentService{
-		client:  &http.Client{Timeout: 8 * time.Second},
+		client:  metrics.NewClient("redapi", 8*time.Second),
 		baseURL: baseURL,
 		apiKey:  apiKey,
 	}
@@ -31,12 +34,32 @@ func NewTorrentServiceWithConfig(baseURL, apiKey string) *TorrentService {
 
 func NewTorrentService() *TorrentService {
 	return &TorrentService{
-		client:  &http.Client{Timeout: 8 * time.Second},
+		client:  metrics.NewClient("redapi", 8*time.Second),
 		baseURL: "http://redapi.cfhttp.top",
 		apiKey:  "",
 	}
 }
 
+// Ping проверяет, что RedAPI отвечает. Любой ответ без 5xx считается успешным,
+// так как корневой путь может отдавать редирект или 404
+func (s *TorrentService) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.baseURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("RedAPI error: %d", resp.StatusCode)
+	}
+	return nil
+}
+
 // SearchTorrents - основной метод поиска торрентов через RedAPI
 func (s *TorrentService) SearchTorrents(params map[string]string) (*models.TorrentSearchResponse, error) {
 	searchParams := url.Values{}
@@ -59,18 +82,22 @@ func (s *TorrentService) SearchTorrents(params map[string]string) (*models.Torre
 
 	resp, err := s.client.Get(searchURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search torrents: %w", err)
+		return nil, apperr.Upstream("redapi", fmt.Errorf("failed to search torrents: %w", err))
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, apperr.Upstream("redapi", fmt.Errorf("RedAPI error: %d", resp.StatusCode))
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, apperr.Upstream("redapi", fmt.Errorf("failed to read response: %w", err))
 	}
 
 	var redAPIResponse models.RedAPIResponse
 	if err := json.Unmarshal(body, &redAPIResponse); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+		return nil, apperr.Upstream("redapi", fmt.Errorf("failed to parse response: %w", err))
 	}
 
 	results := s.parseRedAPIResults(redAPIResponse)
@@ -375,7 +402,7 @@ func (s *TorrentService) getMovieInfoByIMDB(imdbID string) (string, string, stri
 	}
 
 	if allohaResponse.Status != "success" {
-		return "", "", "", fmt.Errorf("no results found for IMDB ID: %s", imdbID)
+		return "", "", "", apperr.NotFound("no results found for IMDB ID: " + imdbID).WithCode("imdb_not_found")
 	}
 
 	title := allohaResponse.Data.Name
@@ -462,7 +489,7 @@ func (s *TorrentService) getTitleFromTMDB(tmdbService *TMDBService, imdbID, medi
 		return title, originalTitle, year, nil
 	}
 
-	return "", "", "", fmt.Errorf("no results found for IMDB ID: %s", imdbID)
+	return "", "", "", apperr.NotFound("no results found for IMDB ID: " + imdbID).WithCode("imdb_not_found")
 }
 
 // FilterByContentType - фильтрация по типу контента (как в JS)
@@ -807,7 +834,7 @@ func (s *TorrentService) GetAvailableSeasons(title, originalTitle, year string)
 // SearchByImdb - поиск по IMDB ID (movie/serial/anime).
 func (s *TorrentService) SearchByImdb(imdbID, contentType string, season *int) ([]models.TorrentResult, error) {
 	if imdbID == "" || !strings.HasPrefix(imdbID, "tt") {
-		return nil, fmt.Errorf("Неверный формат IMDB ID. Должен быть в формате tt1234567")
+		return nil, apperr.Validation("Неверный формат IMDB ID. Должен быть в формате tt1234567").WithCode("invalid_imdb_id")
 	}
 
 	// НЕ добавляем title, originalTitle, year, чтобы запрос не был слишком строгим.
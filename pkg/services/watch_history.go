@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+// Доля просмотренного, после которой фильм или эпизод считается досмотренным
+const watchedThreshold = 0.9
+
+type WatchHistoryService struct {
+	db   *mongo.Database
+	tmdb *TMDBService
+}
+
+func NewWatchHistoryService(db *mongo.Database, tmdb *TMDBService) *WatchHistoryService {
+	return &WatchHistoryService{
+		db:   db,
+		tmdb: tmdb,
+	}
+}
+
+// EnsureIndexes creates indexes for the watch_history collection.
+func (s *WatchHistoryService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("watch_history").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "userId", Value: 1},
+				{Key: "mediaType", Value: 1},
+				{Key: "mediaId", Value: 1},
+				{Key: "seasonNumber", Value: 1},
+				{Key: "episodeNumber", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "userId", Value: 1}, {Key: "updatedAt", Value: -1}}},
+	})
+	return err
+}
+
+// RecordProgress saves the playback position for a movie or an episode.
+func (s *WatchHistoryService) RecordProgress(ctx context.Context, userID string, req models.WatchProgressRequest) (*models.WatchProgress, error) {
+	if req.MediaType != "movie" && req.MediaType != "tv" {
+		return nil, ErrInvalidMediaType
+	}
+	if req.MediaType == "tv" && (req.SeasonNumber < 1 || req.EpisodeNumber < 1) {
+		return nil, apperr.Validation("season and episode numbers are required for tv")
+	}
+	if req.MediaType == "movie" {
+		req.SeasonNumber, req.EpisodeNumber = 0, 0
+	}
+
+	completed := req.Completed
+	if req.Duration > 0 && float64(req.Position) >= float64(req.Duration)*watchedThreshold {
+		completed = true
+	}
+
+	return s.upsert(ctx, userID, req.MediaType, req.MediaID, req.SeasonNumber, req.EpisodeNumber, bson.M{
+		"position":  req.Position,
+		"duration":  req.Duration,
+		"completed": completed,
+	})
+}
+
+// MarkEpisodeWatched marks a series episode as fully watched.
+func (s *WatchHistoryService) MarkEpisodeWatched(ctx context.Context, userID, tvID string, seasonNumber, episodeNumber int) (*models.WatchProgress, error) {
+	if seasonNumber < 1 || episodeNumber < 1 {
+		return nil, apperr.Validation("season and episode numbers must be positive")
+	}
+	return s.upsert(ctx, userID, "tv", tvID, seasonNumber, episodeNumber, bson.M{
+		"completed": true,
+	})
+}
+
+// GetContinueWatching returns unfinished titles ordered by last activity,
+// one entry per movie or series.
+func (s *WatchHistoryService) GetContinueWatching(ctx context.Context, userID string, limit int) ([]models.WatchProgress, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	cursor, err := s.db.Collection("watch_history").Find(ctx,
+		bson.M{"userId": userID, "completed": false},
+		options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := []models.WatchProgress{}
+	seen := make(map[string]bool)
+	for cursor.Next(ctx) && len(result) < limit {
+		var entry models.WatchProgress
+		if err := cursor.Decode(&entry); err != nil {
+			return nil, err
+		}
+		key := entry.MediaType + ":" + entry.MediaID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		result = append(result, entry)
+	}
+	return result, cursor.Err()
+}
+
+// GetHistory returns the most recent watch entries of the user.
+func (s *WatchHistoryService) GetHistory(ctx context.Context, userID string, limit int) ([]models.WatchProgress, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	cursor, err := s.db.Collection("watch_history").Find(ctx,
+		bson.M{"userId": userID},
+		options.Find().SetSort(bson.D{{Key: "updatedAt", Value: -1}}).SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	history := []models.WatchProgress{}
+	if err := cursor.All(ctx, &history); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// GetNextEpisode finds the episode after the last watched one using TMDB season data.
+func (s *WatchHistoryService) GetNextEpisode(ctx context.Context, userID, tvID string) (*models.NextEpisode, error) {
+	tvIDInt, err := strconv.Atoi(tvID)
+	if err != nil {
+		return nil, apperr.Validation("invalid media ID: " + tvID)
+	}
+
+	var last models.WatchProgress
+	err = s.db.Collection("watch_history").FindOne(ctx,
+		bson.M{"userId": userID, "mediaType": "tv", "mediaId": tvID, "completed": true},
+		options.FindOne().SetSort(bson.D{{Key: "seasonNumber", Value: -1}, {Key: "episodeNumber", Value: -1}}),
+	).Decode(&last)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	seasonNumber, episodeNumber := 1, 1
+	if err == nil {
+		seasonNumber, episodeNumber = last.SeasonNumber, last.EpisodeNumber+1
+	}
+
+	season, err := s.tmdb.GetTVSeason(tvIDInt, seasonNumber, "")
+	if err != nil {
+		return nil, err
+	}
+	if episode := findEpisode(season, episodeNumber); episode != nil {
+		return &models.NextEpisode{MediaID: tvID, SeasonNumber: seasonNumber, EpisodeNumber: episodeNumber, Episode: episode}, nil
+	}
+
+	// Сезон закончился — переходим к первому эпизоду следующего сезона
+	show, err := s.tmdb.GetTVShow(tvIDInt, "")
+	if err != nil {
+		return nil, err
+	}
+	if seasonNumber >= show.NumberOfSeasons {
+		return &models.NextEpisode{MediaID: tvID, SeasonNumber: seasonNumber, EpisodeNumber: episodeNumber - 1, Finished: true}, nil
+	}
+
+	nextSeason, err := s.tmdb.GetTVSeason(tvIDInt, seasonNumber+1, "")
+	if err != nil {
+		return nil, err
+	}
+	return &models.NextEpisode{
+		MediaID:       tvID,
+		SeasonNumber:  seasonNumber + 1,
+		EpisodeNumber: 1,
+		Episode:       findEpisode(nextSeason, 1),
+	}, nil
+}
+
+// RemoveFromHistory deletes all entries of a title from the user's history.
+func (s *WatchHistoryService) RemoveFromHistory(ctx context.Context, userID, mediaType, mediaID string) error {
+	_, err := s.db.Collection("watch_history").DeleteMany(ctx, bson.M{
+		"userId":    userID,
+		"mediaType": mediaType,
+		"mediaId":   mediaID,
+	})
+	return err
+}
+
+func (s *WatchHistoryService) upsert(ctx context.Context, userID, mediaType, mediaID string, seasonNumber, episodeNumber int, fields bson.M) (*models.WatchProgress, error) {
+	collection := s.db.Collection("watch_history")
+	filter := bson.M{
+		"userId":        userID,
+		"mediaType":     mediaType,
+		"mediaId":       mediaID,
+		"seasonNumber":  seasonNumber,
+		"episodeNumber": episodeNumber,
+	}
+
+	now := time.Now()
+	fields["updatedAt"] = now
+	setOnInsert := bson.M{"createdAt": now}
+
+	// Название и постер запрашиваем в TMDB только при первой записи
+	count, err := collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		title, posterPath, err := fetchMediaSummary(s.tmdb, mediaID, mediaType)
+		if err != nil {
+			return nil, err
+		}
+		setOnInsert["title"] = title
+		setOnInsert["posterPath"] = posterPath
+		if _, ok := fields["position"]; !ok {
+			setOnInsert["position"] = 0
+			setOnInsert["duration"] = 0
+		}
+	}
+
+	var progress models.WatchProgress
+	err = collection.FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": fields, "$setOnInsert": setOnInsert},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&progress)
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func findEpisode(season *models.SeasonDetails, episodeNumber int) *models.Episode {
+	for i := range season.Episodes {
+		if season.Episodes[i].EpisodeNumber == episodeNumber {
+			return &season.Episodes[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+func TestRecordProgressValidation(t *testing.T) {
+	history := NewWatchHistoryService(nil, nil)
+
+	tests := []struct {
+		name string
+		req  models.WatchProgressRequest
+		want error
+	}{
+		{"media type", models.WatchProgressRequest{MediaType: "book", MediaID: "1"}, ErrInvalidMediaType},
+		{"tv without episode", models.WatchProgressRequest{MediaType: "tv", MediaID: "1", SeasonNumber: 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := history.RecordProgress(context.Background(), "user-1", tt.req)
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if got := apperr.From(err); got.Status != http.StatusBadRequest {
+				t.Fatalf("status = %d", got.Status)
+			}
+		})
+	}
+}
+
+func TestRecordProgressMarksCompleted(t *testing.T) {
+	tests := []struct {
+		name      string
+		position  int
+		completed bool
+	}{
+		{"in progress", 500, false},
+		{"past threshold", 900, true},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(
+				found("test.watch_history", bson.D{{Key: "n", Value: 1}}),
+				acknowledged(bson.E{Key: "value", Value: bson.D{{Key: "mediaId", Value: "550"}}}),
+			)
+			history := NewWatchHistoryService(mt.DB, nil)
+
+			_, err := history.RecordProgress(context.Background(), "user-1", models.WatchProgressRequest{
+				MediaType: "movie", MediaID: "550", Position: tt.position, Duration: 1000,
+				// Для фильма сезон и эпизод сбрасываются
+				SeasonNumber: 3, EpisodeNumber: 4,
+			})
+			if err != nil {
+				mt.Fatal(err)
+			}
+
+			update, ok := findCommand(sentCommands(mt), "findAndModify", "watch_history")
+			if !ok {
+				mt.Fatal("progress was not saved")
+			}
+			if got := update.Lookup("update", "$set", "completed").Boolean(); got != tt.completed {
+				mt.Errorf("completed = %v, want %v", got, tt.completed)
+			}
+			if got := update.Lookup("query", "seasonNumber").AsInt64(); got != 0 {
+				mt.Errorf("seasonNumber = %d, want 0", got)
+			}
+		})
+	}
+}
+
+func TestGetContinueWatchingOneEntryPerTitle(t *testing.T) {
+	runMock(t, "dedupe", func(mt *mtest.T) {
+		entry := func(mediaType, mediaID string, episode int) bson.D {
+			return bson.D{{Key: "mediaType", Value: mediaType}, {Key: "mediaId", Value: mediaID}, {Key: "episodeNumber", Value: episode}}
+		}
+		mt.AddMockResponses(found("test.watch_history",
+			entry("tv", "1", 3),
+			entry("tv", "1", 2),
+			entry("movie", "1", 0),
+			entry("tv", "2", 1),
+		))
+		history := NewWatchHistoryService(mt.DB, nil)
+
+		items, err := history.GetContinueWatching(context.Background(), "user-1", 10)
+		if err != nil {
+			mt.Fatal(err)
+		}
+		if len(items) != 3 {
+			mt.Fatalf("got %d items, want 3", len(items))
+		}
+		// Остается самая свежая запись сериала
+		if items[0].EpisodeNumber != 3 || items[1].MediaType != "movie" {
+			mt.Errorf("items = %+v", items)
+		}
+	})
+}
+
+func TestGetNextEpisode(t *testing.T) {
+	srv, _ := newTMDBStub(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tv/1":
+			w.Write([]byte(`{"id":1,"number_of_seasons":2}`))
+		case "/tv/1/season/1":
+			w.Write([]byte(`{"season_number":1,"episodes":[{"episode_number":1},{"episode_number":2}]}`))
+		case "/tv/1/season/2":
+			w.Write([]byte(`{"season_number":2,"episodes":[{"episode_number":1,"name":"Premiere"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	tmdb := NewTMDBServiceWithConfig("token", srv.URL+"/3", nil)
+
+	last := func(season, episode int) bson.D {
+		return bson.D{{Key: "mediaType", Value: "tv"}, {Key: "mediaId", Value: "1"}, {Key: "seasonNumber", Value: season}, {Key: "episodeNumber", Value: episode}}
+	}
+	tests := []struct {
+		name     string
+		watched  []bson.D
+		season   int
+		episode  int
+		finished bool
+	}{
+		{"nothing watched", nil, 1, 1, false},
+		{"same season", []bson.D{last(1, 1)}, 1, 2, false},
+		{"next season", []bson.D{last(1, 2)}, 2, 1, false},
+		{"finished", []bson.D{last(2, 1)}, 2, 1, true},
+	}
+	for _, tt := range tests {
+		runMock(t, tt.name, func(mt *mtest.T) {
+			mt.AddMockResponses(found("test.watch_history", tt.watched...))
+			history := NewWatchHistoryService(mt.DB, tmdb)
+
+			next, err := history.GetNextEpisode(context.Background(), "user-1", "1")
+			if err != nil {
+				mt.Fatal(err)
+			}
+			if next.SeasonNumber != tt.season || next.EpisodeNumber != tt.episode || next.Finished != tt.finished {
+				mt.Fatalf("next = S%dE%d finished=%v, want S%dE%d finished=%v",
+					next.SeasonNumber, next.EpisodeNumber, next.Finished, tt.season, tt.episode, tt.finished)
+			}
+			if !tt.finished && next.Episode == nil {
+				mt.Error("episode details are missing")
+			}
+		})
+	}
+}
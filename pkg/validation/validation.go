@@ -0,0 +1,183 @@
+// Package validation enforces the go-playground `validate` tags declared on
+// request models and reports failures as apperr validation errors with
+// field-level details.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+
+	"neomovies-api/pkg/apperr"
+)
+
+// Password policy. bcrypt ignores everything after 72 bytes, so longer
+// passwords are rejected instead of being silently truncated.
+const (
+	PasswordMinLength = 8
+	PasswordMaxBytes  = 72
+)
+
+// FieldError describes one invalid field. Field is the JSON (or query)
+// name, Rule is the failed validate tag.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		for _, key := range []string{"json", "query"} {
+			name := strings.Split(f.Tag.Get(key), ",")[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return f.Name
+	})
+	v.RegisterValidation("password", func(fl validator.FieldLevel) bool {
+		return checkPassword(fl.Field().String()) == ""
+	})
+	v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	return v
+}
+
+// Struct validates s against its validate tags. It returns nil or an
+// *apperr.Error with code validation_failed and []FieldError details.
+func Struct(s interface{}) error {
+	// Тела без схемы (map) проверять нечем
+	if reflect.Indirect(reflect.ValueOf(s)).Kind() != reflect.Struct {
+		return nil
+	}
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return apperr.Internal(err)
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(fe),
+		})
+	}
+	return Fields(fields...)
+}
+
+// Fields builds the validation error for already collected field errors.
+func Fields(fields ...FieldError) error {
+	msgs := make([]string, len(fields))
+	for i, f := range fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return apperr.Validation("Invalid request: " + strings.Join(msgs, "; ")).WithDetails(fields)
+}
+
+// fieldPath returns the namespace without the root struct name, e.g.
+// items[0].mediaType.
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+	return fe.Field()
+}
+
+func message(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	isCollection := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+	// Для альтернатив вида http_url|eq= описываем основное правило
+	switch tag, _, _ := strings.Cut(fe.Tag(), "|"); tag {
+	case "required", "notblank":
+		return "is required"
+	case "required_if":
+		parts := strings.Fields(fe.Param())
+		if len(parts) == 2 {
+			return fmt.Sprintf("is required when %s is %s", lowerFirst(parts[0]), parts[1])
+		}
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid http(s) URL"
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min":
+		switch {
+		case isString:
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		case isCollection:
+			return fmt.Sprintf("must contain at least %s items", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		switch {
+		case isString:
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		case isCollection:
+			return fmt.Sprintf("must contain at most %s items", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "len":
+		if isString {
+			return fmt.Sprintf("must be exactly %s characters long", fe.Param())
+		}
+		return "must have length " + fe.Param()
+	case "password":
+		return checkPassword(fmt.Sprint(fe.Value()))
+	}
+	return "is invalid"
+}
+
+// checkPassword returns why password violates the policy, or "" if it
+// satisfies it.
+func checkPassword(password string) string {
+	if len([]rune(password)) < PasswordMinLength {
+		return fmt.Sprintf("must be at least %d characters long", PasswordMinLength)
+	}
+	if len(password) > PasswordMaxBytes {
+		return fmt.Sprintf("must be at most %d bytes long", PasswordMaxBytes)
+	}
+	var letter, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return "must contain at least one letter and one digit"
+	}
+	return ""
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"neomovies-api/pkg/apperr"
+	"neomovies-api/pkg/models"
+)
+
+func fieldErrors(t *testing.T, err error) []FieldError {
+	t.Helper()
+	var e *apperr.Error
+	if !errors.As(err, &e) || e.Status != http.StatusBadRequest || e.Code != apperr.CodeValidation {
+		t.Fatalf("err = %v, want a validation error", err)
+	}
+	fields, ok := e.Details.([]FieldError)
+	if !ok {
+		t.Fatalf("details = %#v", e.Details)
+	}
+	return fields
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   interface{}
+		field   string
+		rule    string
+		message string
+	}{
+		{"email", models.RegisterRequest{Email: "not-an-email", Password: "secret123", Name: "Neo"}, "email", "email", "must be a valid email address"},
+		{"blank name", models.RegisterRequest{Email: "neo@example.com", Password: "secret123", Name: "   "}, "name", "notblank", "is required"},
+		{"max length", models.CreateListRequest{Name: strings.Repeat("a", 101)}, "name", "max", "must be at most 100 characters long"},
+		{"oneof", models.ListItemRequest{MediaID: "1", MediaType: "book"}, "mediaType", "oneof", "must be one of: movie, tv"},
+		// Путь до элемента вложенного среза
+		{"dive", models.ReorderListRequest{Items: []models.ListItemRequest{{MediaID: "1", MediaType: "tv"}, {MediaType: "movie"}}}, "items[1].mediaId", "required", "is required"},
+		{"avatar url", models.UpdateProfileRequest{Avatar: ptr("javascript:alert(1)")}, "avatar", "http_url|eq=", "must be a valid http(s) URL"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := fieldErrors(t, Struct(tt.value))
+			if len(fields) != 1 {
+				t.Fatalf("fields = %+v", fields)
+			}
+			if f := fields[0]; f.Field != tt.field || f.Rule != tt.rule || f.Message != tt.message {
+				t.Errorf("field error = %+v", f)
+			}
+		})
+	}
+}
+
+func TestStructValid(t *testing.T) {
+	for _, value := range []interface{}{
+		models.RegisterRequest{Email: "neo@example.com", Password: "secret123", Name: "Neo"},
+		// Пустая строка очищает аватар
+		models.UpdateProfileRequest{Avatar: ptr("")},
+		&models.UpdateProfileRequest{Avatar: ptr("https://example.com/a.png")},
+		map[string]interface{}{"anything": true},
+	} {
+		if err := Struct(value); err != nil {
+			t.Errorf("Struct(%+v) = %v", value, err)
+		}
+	}
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	tests := []struct {
+		password string
+		problem  string
+	}{
+		{"abc123", "must be at least 8 characters long"},
+		{"password", "must contain at least one letter and one digit"},
+		{"12345678", "must contain at least one letter and one digit"},
+		{strings.Repeat("a1", 37), "must be at most 72 bytes long"},
+		// Длина считается в символах, лимит bcrypt — в байтах
+		{"пароль12", ""},
+		{"secret123", ""},
+	}
+	for _, tt := range tests {
+		if got := checkPassword(tt.password); got != tt.problem {
+			t.Errorf("checkPassword(%q) = %q, want %q", tt.password, got, tt.problem)
+		}
+	}
+
+	fields := fieldErrors(t, Struct(models.RegisterRequest{Email: "neo@example.com", Password: "password", Name: "Neo"}))
+	if fields[0].Rule != "password" || fields[0].Message != "must contain at least one letter and one digit" {
+		t.Errorf("field error = %+v", fields[0])
+	}
+}
+
+func ptr(s string) *string { return &s }